@@ -15,36 +15,43 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/atotto/clipboard"
+	"github.com/cybrota/recaller/strategies"
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
 	tb "github.com/nsf/termbox-go"
-	"github.com/patrickmn/go-cache"
 )
 
 // ============================================================================
 // CONSTANTS AND CONFIGURATION
 // ============================================================================
 
-const (
-	Green = "\033[32m"
-	Reset = "\033[0m"
-)
-
 const (
 	debounceDelay     = 100 * time.Millisecond
 	fsDebounceDelay   = 150 * time.Millisecond
 	maxPathDisplayLen = 80
 	fileSizeUnit      = 1024
+	// Number of co-occurring commands shown for the selected command
+	maxRelatedCommands = 5
 )
 
 // Filter modes for filesystem search
@@ -59,15 +66,160 @@ var (
 	filterIcons = []string{"📁📄", "📁", "📄"}
 )
 
+// filterFilesByMode keeps the files that pass a filesystem filter mode
+func filterFilesByMode(files []RankedFile, mode int) []RankedFile {
+	filtered := []RankedFile{}
+	for _, file := range files {
+		switch mode {
+		case filterModeAll:
+			filtered = append(filtered, file)
+		case filterModeDirs:
+			if file.Metadata.IsDirectory {
+				filtered = append(filtered, file)
+			}
+		case filterModeFiles:
+			if !file.Metadata.IsDirectory {
+				filtered = append(filtered, file)
+			}
+		}
+	}
+	return filtered
+}
+
 // ============================================================================
 // TERMINAL AND SYSTEM UTILITIES
 // ============================================================================
 
+// sendCommandToTerminal sends command to a new terminal and reports the
+// outcome. The UI must already be closed.
+func sendCommandToTerminal(command string) {
+	if command == "" {
+		return
+	}
+	if err := sendToTerminal(command); err != nil {
+		log.Printf("Failed to send command to terminal: %v", err)
+	} else {
+		cliPrintf("⚡ Sent `%s` to terminal\n", command)
+	}
+}
+
+// Things the history UI can do with the selected command (history.enter_action)
+const (
+	commandActionCopy = "copy"
+	commandActionSend = "send"
+	commandActionRun  = "run"
+)
+
+// commandActionLabels name each command action in the shortcuts footer
+var commandActionLabels = map[string]string{
+	commandActionCopy: "Copy command(s)",
+	commandActionSend: "Send to terminal",
+	commandActionRun:  "Run here",
+}
+
+// commandKeyActions returns the actions of Enter, <C-e> and <C-o>: Enter
+// performs enterAction and the other keys the remaining two actions in the
+// order copy, send, run. An unknown enterAction falls back to copy.
+func commandKeyActions(enterAction string) [3]string {
+	if _, ok := commandActionLabels[enterAction]; !ok {
+		if enterAction != "" {
+			log.Printf("Unknown history.enter_action %q, using copy", enterAction)
+		}
+		enterAction = commandActionCopy
+	}
+	actions := [3]string{enterAction}
+	i := 1
+	for _, action := range []string{commandActionCopy, commandActionSend, commandActionRun} {
+		if action != enterAction {
+			actions[i] = action
+			i++
+		}
+	}
+	return actions
+}
+
+// commandKeys maps the command action keys to their index in commandKeyActions
+var commandKeys = map[string]int{"<Enter>": 0, "<C-e>": 1, "<C-o>": 2}
+
+// runCommandAction sends command to a new terminal or runs it here, diffing
+// its output against the last run with diffOutput. The UI must already be
+// closed.
+func runCommandAction(action string, command string, diffOutput bool) {
+	switch action {
+	case commandActionSend:
+		sendCommandToTerminal(command)
+	case commandActionRun:
+		runCommandHere(command, diffOutput)
+	}
+}
+
+// runCommandHere runs command in this terminal through the user's shell and
+// reports how it went. The command gets the terminal like any other, so
+// interactive ones (ssh, sudo, git commit) work. With diffOutput its output is
+// also captured and compared with the last run's (history.diff_output). The
+// UI must already be closed.
+func runCommandHere(command string, diffOutput bool) {
+	if command == "" {
+		return
+	}
+	cliPrintf("▶️  Running `%s`\n", command)
+	var stdout io.Writer = os.Stdout
+	var captured bytes.Buffer
+	if diffOutput {
+		stdout = io.MultiWriter(os.Stdout, &captured)
+	}
+	if err := runInShell(command, stdout); err != nil {
+		cliFprintf(os.Stderr, "❌ `%s` failed: %v\n", command, err)
+	}
+	if diffOutput {
+		reportOutputChanges(command, captured.String())
+	}
+}
+
+// warnIfDangerous flags a copied command matching safety.dangerous_patterns
+func warnIfDangerous(patterns dangerousPatterns, command string) {
+	if pattern, dangerous := patterns.Match(command); dangerous {
+		cliFprintf(os.Stderr, "⚠️  `%s` looks destructive (matches %s); double-check before running it.\n", command, pattern)
+	}
+}
+
 // DisableMouseInput in termbox-go. This should be called after ui.Init()
 func DisableMouseInput() {
 	tb.SetInputMode(tb.InputEsc)
 }
 
+// restoreTerminalOnSignal restores the terminal (leaving raw mode and the
+// alternate screen) and exits when recaller is terminated or its terminal
+// hangs up while the UI is up. Call the returned function when the UI closes.
+func restoreTerminalOnSignal() (stop func()) {
+	return onTerminationSignal(ui.Close, os.Exit)
+}
+
+// onTerminationSignal runs cleanup and then exit with the conventional
+// 128+signal status on SIGTERM or SIGHUP, until stop is called
+func onTerminationSignal(cleanup func(), exit func(int)) (stop func()) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-sigChan:
+			cleanup()
+			exit(128 + int(sig.(syscall.Signal)))
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(sigChan)
+			close(done)
+		})
+	}
+}
+
 // sendToTerminal sends a command to the terminal (cross-platform)
 func sendToTerminal(command string) error {
 	switch runtime.GOOS {
@@ -119,21 +271,14 @@ func sendToTerminalLinux(command string) error {
 		wrappedCommand = fmt.Sprintf("%s; exec bash", command)
 	}
 
-	terminals := []struct {
-		name string
-		cmd  []string
-	}{
-		{"gnome-terminal", []string{"gnome-terminal", "--tab", "--", "bash", "-lc", wrappedCommand}},
-		{"konsole", []string{"konsole", "--new-tab", "-e", "bash", "-lc", wrappedCommand}},
-		{"xfce4-terminal", []string{"xfce4-terminal", "--tab", "-x", "bash", "-lc", wrappedCommand}},
-		{"tilix", []string{"tilix", "-a", "session-add-down", "-e", "bash", "-lc", wrappedCommand}},
-		{"terminator", []string{"terminator", "--new-tab", "-e", "bash", "-lc", wrappedCommand}},
-		{"alacritty", []string{"alacritty", "-e", "bash", "-lc", wrappedCommand}},
-		{"kitty", []string{"kitty", "--tab", "bash", "-lc", wrappedCommand}},
-		{"xterm", []string{"xterm", "-e", "bash", "-lc", wrappedCommand}},
+	// Inside a multiplexer, open a window there so it stays attached to the user's session
+	if mux := multiplexerCommand(wrappedCommand, os.Getenv); mux != nil {
+		if _, err := exec.LookPath(mux[0]); err == nil {
+			return exec.Command(mux[0], mux[1:]...).Run()
+		}
 	}
 
-	for _, terminal := range terminals {
+	for _, terminal := range linuxTerminalLaunchers(wrappedCommand, os.Getenv) {
 		if _, err := exec.LookPath(terminal.name); err == nil {
 			cmd := exec.Command(terminal.cmd[0], terminal.cmd[1:]...)
 			return cmd.Start()
@@ -143,6 +288,48 @@ func sendToTerminalLinux(command string) error {
 	return fmt.Errorf("no supported terminal emulator found")
 }
 
+// terminalLauncher is a Linux terminal emulator and the argv that runs a command in it
+type terminalLauncher struct {
+	name string
+	cmd  []string
+}
+
+// linuxTerminalLaunchers lists supported emulators in order of preference.
+// command is always passed as a single argv element to bash -lc, so it is
+// never re-parsed by an intermediate shell.
+func linuxTerminalLaunchers(command string, getenv func(string) string) []terminalLauncher {
+	// Inside WezTerm, spawn a tab in the current window; otherwise start a new window
+	wezterm := []string{"wezterm", "start", "--", "bash", "-lc", command}
+	if getenv("WEZTERM_PANE") != "" {
+		wezterm = []string{"wezterm", "cli", "spawn", "--", "bash", "-lc", command}
+	}
+
+	return []terminalLauncher{
+		{"gnome-terminal", []string{"gnome-terminal", "--tab", "--", "bash", "-lc", command}},
+		{"konsole", []string{"konsole", "--new-tab", "-e", "bash", "-lc", command}},
+		{"xfce4-terminal", []string{"xfce4-terminal", "--tab", "-x", "bash", "-lc", command}},
+		{"tilix", []string{"tilix", "-a", "session-add-down", "-e", "bash", "-lc", command}},
+		{"terminator", []string{"terminator", "--new-tab", "-e", "bash", "-lc", command}},
+		{"wezterm", wezterm},
+		{"alacritty", []string{"alacritty", "-e", "bash", "-lc", command}},
+		{"kitty", []string{"kitty", "--tab", "bash", "-lc", command}},
+		{"foot", []string{"foot", "bash", "-lc", command}},
+		{"xterm", []string{"xterm", "-e", "bash", "-lc", command}},
+	}
+}
+
+// multiplexerCommand returns the tmux or screen invocation that runs command
+// in a new window of the current session, or nil outside a multiplexer
+func multiplexerCommand(command string, getenv func(string) string) []string {
+	if getenv("TMUX") != "" {
+		return []string{"tmux", "new-window", "bash", "-lc", command}
+	}
+	if session := getenv("STY"); session != "" {
+		return []string{"screen", "-S", session, "-X", "screen", "bash", "-lc", command}
+	}
+	return nil
+}
+
 // openFileWithDefaultApp opens a file or directory with the system's default application
 func openFileWithDefaultApp(path string) error {
 	switch runtime.GOOS {
@@ -157,36 +344,130 @@ func openFileWithDefaultApp(path string) error {
 	}
 }
 
+// openWithRule returns the configured opener for path's extension, if any
+func openWithRule(path string, rules map[string]OpenWithRule) (OpenWithRule, bool) {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	if ext == "" {
+		return OpenWithRule{}, false
+	}
+	for key, rule := range rules {
+		if strings.ToLower(strings.TrimPrefix(key, ".")) == ext && rule.Command != "" {
+			return rule, true
+		}
+	}
+	return OpenWithRule{}, false
+}
+
+// openWithCommandLine builds the shell command that opens path using rule
+func openWithCommandLine(rule OpenWithRule, path string) string {
+	quoted := "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	if strings.Contains(rule.Command, "{}") {
+		return strings.ReplaceAll(rule.Command, "{}", quoted)
+	}
+	return rule.Command + " " + quoted
+}
+
+// runShellCommand runs command through the user's shell. Interactive commands
+// are attached to this terminal and waited for; others run in the background.
+func runShellCommand(command string, interactive bool) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.Command(shell, "-c", command)
+	if !interactive {
+		return cmd.Start()
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
 // ============================================================================
 // HELP AND CACHE UTILITIES
 // ============================================================================
 
-func GetOrfillCache(c *cache.Cache, cmd string) string {
+func GetOrfillCache(c *HelpCache, cmd string) string {
+	helpTxt, _ := GetOrfillCacheContext(context.Background(), c, cmd)
+	return helpTxt
+}
+
+// GetOrfillCacheContext returns the cached help for cmd, fetching and caching
+// it on a miss. A cancelled fetch returns ctx's error and caches nothing.
+func GetOrfillCacheContext(ctx context.Context, c *HelpCache, cmd string) (string, error) {
 	parts, err := splitCommand(cmd)
 	if err != nil {
-		return fmt.Sprintf("Failed to parse command: %v", err)
+		return fmt.Sprintf("Failed to parse command: %v", err), nil
+	}
+
+	if page := GetHelpPage(c, cmd); page != "" {
+		return page, nil
 	}
 
-	page := GetHelpPage(c, cmd)
-	var helpTxt string
+	if helpCacheBySubcommand {
+		parts = helpCommandParts(parts)
+	}
 
-	if page == "" {
-		helpTxt, err = getCommandHelp(parts)
-		if err != nil {
-			helpTxt = fmt.Sprintf("Relax and take a deep breath.\n%s", err.Error())
+	res, err := resolveCommandHelpContext(ctx, parts)
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil {
+		helpTxt := commandHelpFailureText(parts, err)
+		if !helpErrorIsTransient(err) {
+			cacheHelpFailure(c, cmd, helpTxt)
 		}
-		CacheHelpPage(c, cmd, helpTxt)
-	} else {
-		helpTxt = page
+		return helpTxt, nil
 	}
+	CacheHelpPageWithSource(c, cmd, res.Text, res.Invocation)
 
-	return helpTxt
+	return res.Text, nil
 }
 
-func repaintHelpWidget(c *cache.Cache, l *widgets.List, cmd string) {
-	helpTxt := GetOrfillCache(c, cmd)
-	lines := strings.Split(helpTxt, "\n")
-	l.Rows = dedupeLines(lines)
+// repaintHelpWidget fills the help pane for cmd. When showSource is set, the
+// command that produced the help text is shown above it, and a user note for
+// the command heads the pane.
+func repaintHelpWidget(c *HelpCache, l *widgets.List, cmd string, showSource bool, note string) {
+	lines := helpLines(GetOrfillCache(c, cmd), cmd)
+	if note != "" {
+		lines = append([]string{fmt.Sprintf("[📝 %s](fg:magenta,mod:bold)", note), ""}, lines...)
+	}
+	if showSource {
+		source := GetHelpSource(c, cmd)
+		if source == "" {
+			source = "unknown"
+		}
+		lines = append([]string{fmt.Sprintf("[Source: %s](fg:cyan)", source), ""}, lines...)
+	}
+	l.Rows = lines
+}
+
+// helpCopyText returns help as <C-x> copies it: unchanged for the "raw"
+// format, otherwise as plain text without ANSI escapes or man overstrikes
+func helpCopyText(helpTxt string, format string) string {
+	if format == "raw" {
+		return helpTxt
+	}
+	return strategies.RemoveOverstrike(StripANSI(helpTxt))
+}
+
+// helpLines splits help text into pane rows. Help that is empty or only
+// whitespace yields a notice instead of a mysteriously blank pane.
+func helpLines(helpTxt string, cmd string) []string {
+	if strings.TrimSpace(helpTxt) == "" {
+		return []string{fmt.Sprintf("No help text available for %s", cmd)}
+	}
+	return dedupeLines(strings.Split(helpTxt, "\n"))
+}
+
+// streamingHelpLines renders help that is still arriving: a loading banner
+// above the plain text received so far
+func streamingHelpLines(partial string, cmd string) []string {
+	banner := fmt.Sprintf("[⏳ Loading help for %s ...](fg:yellow)", cmd)
+	partial = strategies.RemoveOverstrike(StripANSI(partial))
+	if strings.TrimSpace(partial) == "" {
+		return []string{banner}
+	}
+	return append([]string{banner, ""}, dedupeLines(strings.Split(partial, "\n"))...)
 }
 
 // dedupeLines removes consecutive duplicate lines from a slice of strings.
@@ -207,10 +488,38 @@ func dedupeLines(lines []string) []string {
 // UI LAYOUT AND WIDGET MANAGEMENT
 // ============================================================================
 
-func createKeyboardShortcutsWidget() *widgets.Paragraph {
+// historyShortcuts lists the history UI keys, with the command actions of
+// Enter, <C-e> and <C-o> as returned by commandKeyActions
+func historyShortcuts(actions [3]string) []shortcut {
+	return []shortcut{
+		{"<enter>", commandActionLabels[actions[0]], "<Enter>"},
+		{"<ctrl+space>", "Select", "<C-<Space>>"},
+		{"<ctrl+y> 1-9", "Yank to register", "<C-y>"},
+		{"<ctrl+g>", "Copy registers", "<C-g>"},
+		{"<ctrl+f>", "Match mode", "<C-f>"},
+		{"<ctrl+n>", "Edit note", "<C-n>"},
+		{"<ctrl+e>", commandActionLabels[actions[1]], "<C-e>"},
+		{"<ctrl+o>", commandActionLabels[actions[2]], "<C-o>"},
+		{"<ctrl+r>", "Reset input", "<C-r>"},
+		{"<tab>", "Switch panels", "<Tab>"},
+		{"<up/down>", "Navigate", ""},
+		{"<ctrl+u>", "Insert command", "<C-u>"},
+		{"<ctrl+j/k>", "Jump first/last", ""},
+		{"<F1>", "Show help", "<F1>"},
+		{"<F2>", "Help source", "<F2>"},
+		{"</ n N>", "Search help", ""},
+		{"<ctrl+z>", "Copy text", "<C-z>"},
+		{"<ctrl+x>", "Copy help", "<C-x>"},
+		{"<ctrl+p> ?", "Actions", ""},
+		{"<esc>", "Quit", "<Escape>"},
+	}
+}
+
+// createKeyboardShortcutsWidget lists the history UI keys in the footer
+func createKeyboardShortcutsWidget(actions [3]string) *widgets.Paragraph {
 	keyboardList := widgets.NewParagraph()
 	keyboardList.Title = " Keyboard Shortcuts "
-	keyboardList.Text = `[<enter>](fg:green) Copy command  [<ctrl+e>](fg:green) Send to terminal  [<ctrl+r>](fg:green) Reset input  [<tab>](fg:green) Switch panels  [<up/down>](fg:green) Navigate  [<ctrl+u>](fg:green) Insert command  [<ctrl+j/k>](fg:green) Jump first/last  [<F1>](fg:green) Show help  [<ctrl+z>](fg:green) Copy text  [<esc>](fg:green) Quit`
+	keyboardList.Text = shortcutsText(historyShortcuts(actions))
 	keyboardList.TextStyle.Fg = ui.ColorWhite
 	keyboardList.BorderStyle.Fg = ui.ColorWhite
 	return keyboardList
@@ -236,6 +545,15 @@ func createSuggestionListWidget() *widgets.List {
 	return suggestionList
 }
 
+func createRelatedListWidget() *widgets.List {
+	relatedList := widgets.NewList()
+	relatedList.Title = " Related Commands 🔗 "
+	relatedList.Rows = []string{}
+	relatedList.TextStyle.Fg = ui.ColorWhite
+	relatedList.BorderStyle = ui.NewStyle(ui.ColorWhite)
+	return relatedList
+}
+
 func createHelpListWidget() *widgets.List {
 	helpList := widgets.NewList()
 	helpList.Title = " Help Doc "
@@ -250,6 +568,7 @@ func showAIWidget(
 	grid *ui.Grid,
 	inputPara *widgets.Paragraph,
 	suggestionList *widgets.List,
+	relatedList *widgets.List,
 	helpList *widgets.List,
 	aiResponsePara *widgets.Paragraph,
 	keyboardList *widgets.Paragraph,
@@ -259,7 +578,8 @@ func showAIWidget(
 		ui.NewRow(0.93,
 			ui.NewCol(0.3,
 				ui.NewRow(0.2, inputPara),
-				ui.NewRow(0.82, suggestionList),
+				ui.NewRow(0.6, suggestionList),
+				ui.NewRow(0.2, relatedList),
 			),
 			ui.NewCol(0.7, helpList),
 		),
@@ -267,77 +587,804 @@ func showAIWidget(
 	)
 }
 
-func showHelpWidget(
-	grid *ui.Grid,
-	inputPara *widgets.Paragraph,
-	suggestionList *widgets.List,
-	helpList *widgets.List,
-	aiResponsePara *widgets.Paragraph,
-	keyboardList *widgets.Paragraph,
-) {
-	aiResponsePara.Text = ""
-	grid.Set(
-		ui.NewRow(0.93,
-			ui.NewCol(0.3,
-				ui.NewRow(0.2, inputPara),
-				ui.NewRow(0.82, suggestionList),
-			),
-			ui.NewCol(0.7, helpList),
-		),
-		ui.NewRow(0.07, keyboardList),
-	)
+func showHelpWidget(
+	grid *ui.Grid,
+	inputPara *widgets.Paragraph,
+	suggestionList *widgets.List,
+	relatedList *widgets.List,
+	helpList *widgets.List,
+	aiResponsePara *widgets.Paragraph,
+	keyboardList *widgets.Paragraph,
+) {
+	aiResponsePara.Text = ""
+	grid.Set(
+		ui.NewRow(0.93,
+			ui.NewCol(0.3,
+				ui.NewRow(0.2, inputPara),
+				ui.NewRow(0.6, suggestionList),
+				ui.NewRow(0.2, relatedList),
+			),
+			ui.NewCol(0.7, helpList),
+		),
+		ui.NewRow(0.07, keyboardList),
+	)
+}
+
+// borderStyles are the borders of the focused panel and of the others
+type borderStyles struct {
+	focused ui.Style
+	blurred ui.Style
+}
+
+// colorGray is a mid gray from the 256-color palette, readable on both dark
+// and light backgrounds
+const colorGray = ui.Color(244)
+
+// Makes "fg:gray" usable in styled text, which only knows the 8 basic colors
+func init() {
+	ui.StyleParserColorMap["gray"] = colorGray
+}
+
+// borderThemes are the presets for ui.border_theme
+var borderThemes = map[string]borderStyles{
+	"default":       {focused: ui.NewStyle(ui.ColorCyan), blurred: ui.NewStyle(ui.ColorWhite)},
+	"high-contrast": {focused: ui.NewStyle(ui.ColorMagenta, ui.ColorClear, ui.ModifierBold), blurred: ui.NewStyle(colorGray)},
+}
+
+// uiColorNames are the color names accepted for border colors
+var uiColorNames = map[string]ui.Color{
+	"black":   ui.ColorBlack,
+	"red":     ui.ColorRed,
+	"green":   ui.ColorGreen,
+	"yellow":  ui.ColorYellow,
+	"blue":    ui.ColorBlue,
+	"magenta": ui.ColorMagenta,
+	"cyan":    ui.ColorCyan,
+	"white":   ui.ColorWhite,
+}
+
+// newBorderStyles resolves the border theme and color overrides in cfg,
+// logging settings it does not understand
+func newBorderStyles(cfg UIConfig) borderStyles {
+	styles, ok := borderThemes[cfg.BorderTheme]
+	if !ok {
+		log.Printf("Unknown ui.border_theme %q, using default", cfg.BorderTheme)
+		styles = borderThemes["default"]
+	}
+	for _, override := range []struct {
+		name  string
+		value string
+		style *ui.Style
+	}{
+		{"focused_border_color", cfg.FocusedBorderColor, &styles.focused},
+		{"blurred_border_color", cfg.BlurredBorderColor, &styles.blurred},
+	} {
+		if override.value == "" {
+			continue
+		}
+		if color, ok := parseUIColor(override.value); ok {
+			override.style.Fg = color
+		} else {
+			log.Printf("Unknown ui.%s %q; use a color name or 0-255", override.name, override.value)
+		}
+	}
+	return styles
+}
+
+// parseUIColor reads a color name or a 256-color index
+func parseUIColor(value string) (ui.Color, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if color, ok := uiColorNames[value]; ok {
+		return color, true
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 255 {
+		return ui.Color(n), true
+	}
+	return ui.ColorClear, false
+}
+
+// toggleBorders moves the focused border between the given widgets
+func toggleBorders(w1 *widgets.List, w2 *widgets.List, styles borderStyles) {
+	if w1.BorderStyle == styles.focused {
+		w1.BorderStyle = styles.blurred
+		w2.BorderStyle = styles.focused
+	} else {
+		w1.BorderStyle = styles.focused
+		w2.BorderStyle = styles.blurred
+	}
+}
+
+// ============================================================================
+// SEARCH AND SUGGESTION UTILITIES
+// ============================================================================
+
+// getSuggestions returns the commands matching searchStr in the given order,
+// best first by default. A limit of zero or less returns every match;
+// otherwise the first limit matches in that order are kept before any
+// reversal.
+func getSuggestions(searchStr string, tree *AVLTree, config HistoryConfig, limit int, order historyOrder) []string {
+	matches := SearchHistory(tree, searchStr, config)
+	sortHistory(matches, order.sortBy)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	if order.reverse {
+		slices.Reverse(matches)
+	}
+	results := []string{}
+
+	for _, node := range matches {
+		results = append(results, fmt.Sprintf("%s", node.Command))
+	}
+
+	return results
+}
+
+// historySortKeys are the orders `recaller history --sort` accepts
+var historySortKeys = []string{"score", "recency", "frequency", "alpha"}
+
+// historyOrder is how `recaller history` orders the commands it prints
+type historyOrder struct {
+	sortBy  string // One of historySortKeys; "" is score
+	reverse bool   // Print the kept commands last to first
+}
+
+// sortHistory reorders ranked matches by key: most recently run first for
+// recency, most often run first for frequency, A to Z for alpha. Score keeps
+// the ranking, which also breaks ties for the other keys.
+func sortHistory(matches []RankedCommand, key string) {
+	switch key {
+	case "recency":
+		sort.SliceStable(matches, func(i, j int) bool {
+			return compareRecency(matches[i].Metadata.Timestamp, matches[j].Metadata.Timestamp) > 0
+		})
+	case "frequency":
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Metadata.Frequency > matches[j].Metadata.Frequency
+		})
+	case "alpha":
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Command < matches[j].Command
+		})
+	}
+}
+
+// ============================================================================
+// MULTI-SELECTION
+// ============================================================================
+
+const selectedRowMarker = "✔ "
+
+// multiSelection tracks items toggled for batch actions. Items are keyed by
+// value rather than row index so a selection survives changing the query,
+// and are returned in the order they were picked.
+type multiSelection struct {
+	order []string
+	set   map[string]bool
+}
+
+func newMultiSelection() *multiSelection {
+	return &multiSelection{set: make(map[string]bool)}
+}
+
+// Toggle adds item to the selection, or removes it if already selected
+func (ms *multiSelection) Toggle(item string) {
+	if ms.set[item] {
+		delete(ms.set, item)
+		for i, existing := range ms.order {
+			if existing == item {
+				ms.order = append(ms.order[:i], ms.order[i+1:]...)
+				break
+			}
+		}
+		return
+	}
+	ms.set[item] = true
+	ms.order = append(ms.order, item)
+}
+
+func (ms *multiSelection) Contains(item string) bool {
+	return ms.set[item]
+}
+
+func (ms *multiSelection) Len() int {
+	return len(ms.order)
+}
+
+// Items returns a copy of the selected items in selection order
+func (ms *multiSelection) Items() []string {
+	return append([]string{}, ms.order...)
+}
+
+// markSelected prefixes a display row with a checkmark when it is selected
+func markSelected(row string, selected bool) string {
+	if selected {
+		return selectedRowMarker + row
+	}
+	return row
+}
+
+// ============================================================================
+// CLIPBOARD REGISTERS
+// ============================================================================
+
+const registerCount = 9
+
+// clipboardRegisters holds commands yanked into numbered slots (1-9) during
+// a session, for composing multi-command snippets.
+type clipboardRegisters struct {
+	slots [registerCount]string
+}
+
+// Set stores command in slot (1-based). Out-of-range slots are ignored.
+func (r *clipboardRegisters) Set(slot int, command string) bool {
+	if slot < 1 || slot > registerCount {
+		return false
+	}
+	r.slots[slot-1] = command
+	return true
+}
+
+// Filled returns the numbers of the non-empty slots in ascending order
+func (r *clipboardRegisters) Filled() []int {
+	var filled []int
+	for i, command := range r.slots {
+		if command != "" {
+			filled = append(filled, i+1)
+		}
+	}
+	return filled
+}
+
+// Dump returns all filled slots in slot order, newline-joined
+func (r *clipboardRegisters) Dump() string {
+	var commands []string
+	for _, slot := range r.Filled() {
+		commands = append(commands, r.slots[slot-1])
+	}
+	return strings.Join(commands, "\n")
+}
+
+// inputTitle returns the search input title, listing filled registers and a
+// hint while a yank is pending
+func (r *clipboardRegisters) inputTitle(yankPending bool) string {
+	title := " Type Command "
+	if filled := r.Filled(); len(filled) > 0 {
+		title += fmt.Sprintf("| Registers: %s ", strings.Trim(fmt.Sprint(filled), "[]"))
+	}
+	if yankPending {
+		title += "| Yank to register 1-9... "
+	}
+	return title
+}
+
+// refreshInputTitle shows registers, a pending yank and the match mode in the input title
+func (state *historySearchState) refreshInputTitle(inputPara *widgets.Paragraph) {
+	inputPara.Title = state.registers.inputTitle(state.yankPending) + fmt.Sprintf("| Match: %s ", matchModeName(state.matching))
+}
+
+// nextMatchMode cycles history matching: fuzzy, words, word start, prefix,
+// shell words, fuzzy...
+func nextMatchMode(cfg HistoryConfig) HistoryConfig {
+	i := slices.Index(matchModes, cfg.activeMatchMode())
+	cfg.MatchMode = matchModes[(i+1)%len(matchModes)]
+	return cfg
+}
+
+// matchModeName names the active history match mode for display
+func matchModeName(cfg HistoryConfig) string {
+	switch cfg.activeMatchMode() {
+	case matchModeShellWords:
+		return "Shell words"
+	case matchModeWordStart:
+		return "Word start"
+	case matchModeWord:
+		return "Words"
+	case matchModeSubstring:
+		return "Fuzzy"
+	default:
+		return "Prefix"
+	}
+}
+
+// registerSlot parses a key ID of "1".."9" into a register number
+func registerSlot(id string) (int, bool) {
+	if len(id) == 1 && id[0] >= '1' && id[0] <= '9' {
+		return int(id[0] - '0'), true
+	}
+	return 0, false
+}
+
+// ============================================================================
+// COMMAND HISTORY SEARCH UI
+// ============================================================================
+
+type historySearchState struct {
+	inputBuffer     string
+	selectedIndex   int
+	lastSearchQuery string
+	focusOnHelp     bool
+	emptyHistory    bool // No commands to search; the help pane explains how to get some
+	searchStale     bool // The history or match mode changed, so the current query must be searched again
+	currentCommands []RankedCommand
+	results         *resultsCache[RankedCommand] // Recent queries; cleared when the match mode changes
+	selection       *multiSelection
+	showHelpSource  bool
+	registers       clipboardRegisters
+	yankPending     bool
+	ui              UIConfig
+	matching        HistoryConfig // History settings with the live match mode (<C-f>)
+	notes           *CommandNotes
+	dangerous       dangerousPatterns
+	// Copy failures while the UI stays open, printed once it closes
+	clipboardReport *strings.Builder
+
+	// Dangerous command awaiting "y" before confirmAction (send or run) is
+	// performed on it
+	confirmCommand string
+	confirmAction  string
+
+	// Note being written for noteCommand (<C-n>); keys go to noteBuffer meanwhile
+	noteEditing bool
+	noteCommand string
+	noteBuffer  string
+
+	// Search within the help pane (/, n, N while it has focus)
+	helpSearch helpSearch
+
+	// searchMu serializes searches, which run on the debouncer goroutine,
+	// with changes to the tree, results and match mode
+	searchMu sync.Mutex
+
+	// Searchable list of the footer's actions (<C-p>, or ? with an empty input)
+	palette *commandPalette
+
+	// Help lookups are debounced and cancelled when the selection moves on
+	helpMu        sync.Mutex
+	helpDebouncer *time.Timer
+	helpDelay     time.Duration
+	helpCancel    context.CancelFunc
+	helpCmd       string
+
+	// Help for the prefetchCount suggestions either side of the selection is
+	// fetched in the background once the selected help is shown
+	prefetchCount  int
+	prefetchQueue  []string
+	prefetchCancel context.CancelFunc
+}
+
+// requestHelp shows cached help for cmd right away. Otherwise it shows a
+// placeholder and fetches the help once the selection has settled for
+// helpDelay. Any fetch still running for a previous selection is cancelled.
+func (state *historySearchState) requestHelp(hc *HelpCache, helpList *widgets.List, grid *ui.Grid, cmd string) {
+	state.helpMu.Lock()
+	defer state.helpMu.Unlock()
+
+	if state.helpCancel != nil {
+		state.helpCancel()
+		state.helpCancel = nil
+	}
+	state.helpCmd = cmd
+	state.cancelPrefetch()
+	state.prefetchQueue = neighborCommands(state.currentCommands, state.selectedIndex, state.prefetchCount)
+
+	if GetHelpPage(hc, cmd) != "" || state.helpDebouncer == nil {
+		if state.helpDebouncer != nil {
+			state.helpDebouncer.Stop()
+		}
+		state.repaintHelp(hc, helpList, cmd)
+		state.startPrefetch(hc)
+		return
+	}
+
+	helpList.Rows = []string{fmt.Sprintf("⏳ Loading help for %s ...", cmd)}
+	state.helpDebouncer.Reset(state.helpDelay)
+}
+
+// repaintHelp fills the help pane for cmd. A command with an env prefix is
+// headed by both of its forms, marking the one that is copied.
+func (state *historySearchState) repaintHelp(hc *HelpCache, helpList *widgets.List, cmd string) {
+	repaintHelpWidget(hc, helpList, cmd, state.showHelpSource, state.notes.Lookup(cmd))
+	prefix, rest := splitEnvPrefix(cmd)
+	if prefix == "" {
+		return
+	}
+	copied, other := cmd, rest
+	otherLabel := "Without env"
+	if state.matching.CopyStripEnvPrefix {
+		copied, other = rest, cmd
+		otherLabel = "With env"
+	}
+	helpList.Rows = append([]string{
+		fmt.Sprintf("[Copies: %s](fg:green,mod:bold)", copied),
+		fmt.Sprintf("[%s: %s](fg:white)", otherLabel, other),
+		"",
+	}, helpList.Rows...)
+}
+
+// performCommandAction copies, sends or runs the selected command, or the
+// typed text when nothing matches. Copying takes every multi-selected command;
+// sending and running act on the highlighted one. A command matching
+// safety.dangerous_patterns is only sent or run once confirmed with "y". It
+// reports whether the UI was closed.
+func (state *historySearchState) performCommandAction(action string, inputPara *widgets.Paragraph, grid *ui.Grid) bool {
+	if action == commandActionCopy {
+		state.copyCommands()
+		return true
+	}
+
+	command := state.inputBuffer
+	if len(state.currentCommands) > 0 {
+		command = state.outputCommand(state.selectedCommand())
+	}
+	if pattern, dangerous := state.dangerous.Match(command); dangerous {
+		state.confirmCommand, state.confirmAction = command, action
+		inputPara.Title = fmt.Sprintf(" ⚠️  Looks destructive | <y> %s anyway  <any key> Cancel ", commandActionLabels[action])
+		inputPara.Text = fmt.Sprintf("%s  (matches %s)", command, pattern)
+		ui.Render(grid)
+		return false
+	}
+	ui.Close()
+	runCommandAction(action, command, state.matching.DiffOutput)
+	return true
+}
+
+// copyInUI copies text while the UI stays open and returns a status for a
+// widget title. A failure is reported in full once the UI closes.
+func (state *historySearchState) copyInUI(text string) string {
+	if err := copyToClipboard(text); err != nil {
+		reportClipboardFailure(state.clipboardReport, err, "")
+		return fmt.Sprintf("❌ Copy failed: %v", err)
+	}
+	return "📋 Copied"
+}
+
+// copyCommands closes the UI and copies the multi-selected commands, or else
+// the selected command or typed text, to the clipboard
+func (state *historySearchState) copyCommands() {
+	if state.selection.Len() > 0 {
+		var commands []string
+		for _, command := range state.selection.Items() {
+			commands = append(commands, state.outputCommand(command))
+		}
+		text := strings.Join(commands, "\n")
+		err := copyToClipboard(text)
+		ui.Close()
+		if err != nil {
+			reportClipboardFailure(os.Stderr, err, text)
+		} else {
+			cliFprintf(os.Stderr, "📋 Copied %s%d commands%s to clipboard.\n", Green, len(commands), Reset)
+		}
+		for _, command := range commands {
+			warnIfDangerous(state.dangerous, command)
+		}
+		return
+	}
+
+	var commandToCopy string
+	if len(state.currentCommands) > 0 {
+		commandToCopy = state.outputCommand(state.selectedCommand())
+	} else {
+		commandToCopy = state.inputBuffer
+	}
+	var copyErr error
+	if commandToCopy != "" {
+		copyErr = copyToClipboard(commandToCopy)
+	}
+	ui.Close()
+	if copyErr != nil {
+		reportClipboardFailure(os.Stderr, copyErr, commandToCopy)
+	} else if commandToCopy != "" {
+		cliFprintf(os.Stderr, "📋 Copied %s%s%s to clipboard.\n", Green, commandToCopy, Reset)
+	}
+	if commandToCopy != "" {
+		warnIfDangerous(state.dangerous, commandToCopy)
+	}
+}
+
+// outputCommand is the form of command that is copied or sent to a terminal,
+// without its env prefix when history.copy_strip_env_prefix is set
+func (state *historySearchState) outputCommand(command string) string {
+	if !state.matching.CopyStripEnvPrefix {
+		return command
+	}
+	_, rest := splitEnvPrefix(command)
+	return rest
+}
+
+// fetchPendingHelp fetches help for the most recently requested command in the
+// background and paints it if that command is still selected. Help from a
+// slow command is painted as it streams in, under a loading banner.
+func (state *historySearchState) fetchPendingHelp(hc *HelpCache, helpList *widgets.List, grid *ui.Grid) {
+	state.helpMu.Lock()
+	cmd := state.helpCmd
+	ctx, cancel := context.WithCancel(context.Background())
+	state.helpCancel = cancel
+	state.helpMu.Unlock()
+
+	ctx = strategies.WithProgress(ctx, func(partial string) {
+		state.helpMu.Lock()
+		defer state.helpMu.Unlock()
+		if state.helpCmd != cmd || ctx.Err() != nil {
+			return
+		}
+		helpList.Rows = streamingHelpLines(partial, cmd)
+		renderUnderPalette(grid, state.palette)
+	})
+
+	go func() {
+		defer cancel()
+		if _, err := GetOrfillCacheContext(ctx, hc, cmd); err != nil {
+			return
+		}
+
+		state.helpMu.Lock()
+		defer state.helpMu.Unlock()
+		if state.helpCmd != cmd {
+			return
+		}
+		state.repaintHelp(hc, helpList, cmd)
+		renderUnderPalette(grid, state.palette)
+		state.startPrefetch(hc)
+	}()
+}
+
+// neighborCommands returns up to n commands on each side of selected, nearest
+// first and alternating below and above, which is the order help is
+// prefetched in
+func neighborCommands(commands []RankedCommand, selected int, n int) []string {
+	var neighbors []string
+	for distance := 1; distance <= n; distance++ {
+		for _, i := range []int{selected + distance, selected - distance} {
+			if i >= 0 && i < len(commands) {
+				neighbors = append(neighbors, commands[i].Command)
+			}
+		}
+	}
+	return neighbors
+}
+
+// startPrefetch fetches help for the queued neighbors of the selection one at
+// a time, so prefetching never takes more than one of the fetch slots the
+// selected command needs. The caller holds helpMu.
+func (state *historySearchState) startPrefetch(hc *HelpCache) {
+	queue := state.prefetchQueue
+	state.prefetchQueue = nil
+	if len(queue) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state.prefetchCancel = cancel
+	go func() {
+		defer cancel()
+		prefetchHelp(ctx, hc, queue)
+	}()
+}
+
+// cancelPrefetch stops prefetching for the previous selection or query. The
+// caller holds helpMu.
+func (state *historySearchState) cancelPrefetch() {
+	if state.prefetchCancel != nil {
+		state.prefetchCancel()
+		state.prefetchCancel = nil
+	}
+	state.prefetchQueue = nil
+}
+
+// prefetchHelp fills the cache with help for commands in order, skipping
+// cached ones, until ctx is cancelled
+func prefetchHelp(ctx context.Context, hc *HelpCache, commands []string) {
+	for _, cmd := range commands {
+		if ctx.Err() != nil {
+			return
+		}
+		if GetHelpPage(hc, cmd) != "" {
+			continue
+		}
+		GetOrfillCacheContext(ctx, hc, cmd)
+	}
+}
+
+// startNoteEdit begins writing a note for the selected command, prefilled
+// with its current note
+func (state *historySearchState) startNoteEdit(inputPara *widgets.Paragraph) {
+	command := state.selectedCommand()
+	if command == "" || state.notes == nil {
+		return
+	}
+	if err := state.notes.ReadOnly(); err != nil {
+		inputPara.Title = fmt.Sprintf(" ⚠️  %v ", err)
+		return
+	}
+	state.noteEditing = true
+	state.noteCommand = command
+	state.noteBuffer = state.notes.ExactNote(command)
+	inputPara.Title = fmt.Sprintf(" 📝 Note for %s | <enter> Save  <esc> Cancel ", command)
+}
+
+// handleNoteKey applies a key press to the note being written. Enter saves
+// the note (an empty note removes it) and Escape discards it. It reports
+// whether editing has finished.
+func (state *historySearchState) handleNoteKey(e ui.Event) bool {
+	switch e.ID {
+	case "<Enter>":
+		state.notes.Set(state.noteCommand, strings.TrimSpace(state.noteBuffer))
+		if err := state.notes.Save(); err != nil {
+			log.Printf("Failed to save note: %v", err)
+		}
+		state.noteEditing = false
+	case "<Escape>", "<C-c>":
+		state.noteEditing = false
+	case "<Backspace>":
+		state.noteBuffer = dropLastRune(state.noteBuffer)
+	case "<Space>":
+		state.noteBuffer += " "
+	default:
+		if text, ok := typedText(e); ok {
+			state.noteBuffer += text
+		}
+	}
+	return !state.noteEditing
+}
+
+// startHelpSearch lets the next keys type a term to find in the help pane
+func (state *historySearchState) startHelpSearch(inputPara *widgets.Paragraph) {
+	state.helpSearch.editing = true
+	state.helpSearch.buffer = ""
+	inputPara.Title = " 🔎 Search help | <enter> Find  <esc> Cancel "
+}
+
+// handleHelpSearchKey applies a key press to the help search term. Enter
+// finds the term in the help pane and Escape leaves the pane unchanged. It
+// reports whether typing the term has finished.
+func (state *historySearchState) handleHelpSearchKey(e ui.Event, helpList *widgets.List) bool {
+	switch e.ID {
+	case "<Enter>":
+		state.helpSearch.editing = false
+		state.helpSearch.find(helpList, state.helpSearch.buffer)
+		state.refreshHelpSearchTitle(helpList)
+	case "<Escape>", "<C-c>":
+		state.helpSearch.editing = false
+	case "<Backspace>":
+		state.helpSearch.buffer = dropLastRune(state.helpSearch.buffer)
+	case "<Space>":
+		state.helpSearch.buffer += " "
+	default:
+		if text, ok := typedText(e); ok {
+			state.helpSearch.buffer += text
+		}
+	}
+	return !state.helpSearch.editing
+}
+
+// handleHelpPaneKey handles the keys typed while the help pane has focus:
+// "/" searches it, "n" and "N" jump to the next and previous match
+func (state *historySearchState) handleHelpPaneKey(key string, helpList *widgets.List, inputPara *widgets.Paragraph) {
+	switch key {
+	case "/":
+		state.startHelpSearch(inputPara)
+	case "n", "N":
+		if state.helpSearch.term != "" {
+			state.helpSearch.step(helpList, key == "n")
+			state.refreshHelpSearchTitle(helpList)
+		}
+	}
+}
+
+// refreshHelpSearchTitle shows the search term and match position in the
+// help pane title
+func (state *historySearchState) refreshHelpSearchTitle(helpList *widgets.List) {
+	state.repaintUsageTitle(helpList)
+	if state.helpSearch.term != "" {
+		helpList.Title = strings.TrimSuffix(helpList.Title, " ") + " | " + state.helpSearch.status(helpList.SelectedRow) + " "
+	}
 }
 
-// toggleBorders toggles borders of given widgets b/w White & Cyan
-func toggleBorders(w1 *widgets.List, w2 *widgets.List) {
-	if w1.BorderStyle.Fg == ui.ColorCyan {
-		w1.BorderStyle = ui.NewStyle(ui.ColorWhite)
-		w2.BorderStyle = ui.NewStyle(ui.ColorCyan)
-	} else {
-		w1.BorderStyle = ui.NewStyle(ui.ColorCyan)
-		w2.BorderStyle = ui.NewStyle(ui.ColorWhite)
+// typedText returns the text a keyboard event types into an input buffer.
+// termui reports a typed character as its string form, so accented letters,
+// CJK and emoji arrive as a single multi-byte ID rather than one byte.
+func typedText(e ui.Event) (string, bool) {
+	if e.Type != ui.KeyboardEvent || utf8.RuneCountInString(e.ID) != 1 {
+		return "", false
 	}
+	r, _ := utf8.DecodeRuneInString(e.ID)
+	if r == utf8.RuneError || unicode.IsControl(r) {
+		return "", false
+	}
+	return e.ID, true
 }
 
-// ============================================================================
-// SEARCH AND SUGGESTION UTILITIES
-// ============================================================================
+// dropLastRune removes the last character of s without splitting a
+// multi-byte rune
+func dropLastRune(s string) string {
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
+}
 
-// getSuggestions searches through file tree and returns list of matches
-func getSuggestions(searchStr string, tree *AVLTree, enableFuzzing bool) []string {
-	matches := SearchWithRanking(tree, searchStr, enableFuzzing)
-	results := []string{}
+// selectedCommand returns the command under the cursor, or "" if there are no results
+func (state *historySearchState) selectedCommand() string {
+	if state.selectedIndex < 0 || state.selectedIndex >= len(state.currentCommands) {
+		return ""
+	}
+	return state.currentCommands[state.selectedIndex].Command
+}
 
-	for _, node := range matches {
-		results = append(results, fmt.Sprintf("%s", node.Command))
+// dangerBadge marks commands matching safety.dangerous_patterns in the results
+const dangerBadge = "[⚠](fg:red,mod:bold) "
+
+// refreshSuggestionRows rebuilds the visible rows from the current results
+func (state *historySearchState) refreshSuggestionRows(suggestionList *widgets.List) {
+	now := time.Now()
+	suggestionList.Rows = suggestionList.Rows[:0]
+	for _, cmd := range state.currentCommands {
+		row := recencyBadge(cmd.Metadata.Timestamp, now, state.ui) + cmd.Command
+		if _, dangerous := state.dangerous.Match(cmd.Command); dangerous {
+			row = dangerBadge + row
+		}
+		suggestionList.Rows = append(suggestionList.Rows, markSelected(row, state.selection.Contains(cmd.Command)))
+	}
+	if state.emptyHistory {
+		suggestionList.Rows = append(suggestionList.Rows, "No shell history yet")
 	}
+}
 
-	return results
+// emptyHistoryLines fills the help pane when there is no history to search,
+// explaining how to create it. err is why reading history failed, if it did.
+func emptyHistoryLines(err error) []string {
+	lines := []string{"[👋 No shell history to search yet](fg:yellow,mod:bold)", ""}
+	if err != nil {
+		lines = append(lines, err.Error(), "")
+	}
+	return append(lines,
+		"Recaller reads the history file your shell writes:",
+		"  • zsh writes ~/.zsh_history as you run commands (see docs/setup-zsh.md)",
+		"  • bash needs HISTTIMEFORMAT and 'history -a' or 'history -w' (see docs/setup-bash.md)",
+		"",
+		"Run a few commands, then start recaller again. Press <esc> to quit.",
+	)
 }
 
-// ============================================================================
-// COMMAND HISTORY SEARCH UI
-// ============================================================================
+// recencyBadge returns a colored marker showing how long ago a command was
+// last used: green when fresh, yellow when recent and dimmed when older.
+// Commands without a timestamp get no badge.
+func recencyBadge(ts *time.Time, now time.Time, cfg UIConfig) string {
+	if !cfg.RecencyBadges || ts == nil || ts.IsZero() {
+		return ""
+	}
 
-type historySearchState struct {
-	inputBuffer     string
-	selectedIndex   int
-	lastSearchQuery string
-	focusOnHelp     bool
+	age := now.Sub(*ts)
+	style := "fg:gray"
+	switch {
+	case age < time.Duration(cfg.BadgeFreshHours)*time.Hour:
+		style = "fg:green"
+	case age < time.Duration(cfg.BadgeRecentHours)*time.Hour:
+		style = "fg:yellow"
+	}
+	return fmt.Sprintf("[%3s](%s) ", RelativeTime(*ts, now), style)
 }
 
-func (state *historySearchState) updateSearchResults(tree *AVLTree, config *Config, suggestionList *widgets.List, helpList *widgets.List, hc *cache.Cache, grid *ui.Grid) {
-	if state.inputBuffer == state.lastSearchQuery {
+func (state *historySearchState) updateSearchResults(tree *AVLTree, config *Config, suggestionList *widgets.List, relatedList *widgets.List, helpList *widgets.List, hc *HelpCache, grid *ui.Grid) {
+	state.searchMu.Lock()
+	defer state.searchMu.Unlock()
+	if state.inputBuffer == state.lastSearchQuery && !state.searchStale {
 		return
 	}
 	state.lastSearchQuery = state.inputBuffer
+	state.searchStale = false
 
-	matches := SearchWithRanking(tree, state.inputBuffer, config.History.EnableFuzzing)
-	suggestionList.Rows = suggestionList.Rows[:0]
+	// Neighbors of the old results are no longer worth fetching
+	state.helpMu.Lock()
+	state.cancelPrefetch()
+	state.helpMu.Unlock()
 
-	for _, node := range matches {
-		suggestionList.Rows = append(suggestionList.Rows, node.Command)
+	commands, ok := state.results.Get(state.inputBuffer)
+	if !ok {
+		commands = SearchHistory(tree, state.inputBuffer, state.matching)
+		state.results.Put(state.inputBuffer, commands)
 	}
+	state.currentCommands = commands
+	state.refreshSuggestionRows(suggestionList)
 
 	if state.selectedIndex >= len(suggestionList.Rows) {
 		state.selectedIndex = 0
@@ -347,16 +1394,18 @@ func (state *historySearchState) updateSearchResults(tree *AVLTree, config *Conf
 	}
 	suggestionList.SelectedRow = state.selectedIndex
 
-	if len(suggestionList.Rows) > 0 {
-		selectedCmd := suggestionList.Rows[state.selectedIndex]
+	if len(state.currentCommands) > 0 {
+		selectedCmd := state.selectedCommand()
 		helpList.SelectedRow = 0
-		repaintHelpWidget(hc, helpList, selectedCmd)
+		state.requestHelp(hc, helpList, grid, selectedCmd)
 	}
+	state.repaintRelatedWidget(relatedList)
+	state.repaintUsageTitle(helpList)
 
-	ui.Render(grid)
+	renderUnderPalette(grid, state.palette)
 }
 
-func (state *historySearchState) handleNavigation(direction string, suggestionList *widgets.List, helpList *widgets.List, hc *cache.Cache, grid *ui.Grid, inputPara *widgets.Paragraph, aiResponsePara *widgets.Paragraph, keyboardList *widgets.Paragraph) {
+func (state *historySearchState) handleNavigation(direction string, suggestionList *widgets.List, relatedList *widgets.List, helpList *widgets.List, hc *HelpCache, grid *ui.Grid, inputPara *widgets.Paragraph, aiResponsePara *widgets.Paragraph, keyboardList *widgets.Paragraph) {
 	if state.focusOnHelp {
 		switch direction {
 		case "up":
@@ -382,19 +1431,19 @@ func (state *historySearchState) handleNavigation(direction string, suggestionLi
 			if state.selectedIndex > 0 {
 				state.selectedIndex--
 				suggestionList.SelectedRow = state.selectedIndex
-				selectedCmd := suggestionList.Rows[state.selectedIndex]
+				selectedCmd := state.selectedCommand()
 				helpList.SelectedRow = 0
-				repaintHelpWidget(hc, helpList, selectedCmd)
-				showHelpWidget(grid, inputPara, suggestionList, helpList, aiResponsePara, keyboardList)
+				state.requestHelp(hc, helpList, grid, selectedCmd)
+				showHelpWidget(grid, inputPara, suggestionList, relatedList, helpList, aiResponsePara, keyboardList)
 			}
 		case "down":
 			if state.selectedIndex < len(suggestionList.Rows)-1 {
 				state.selectedIndex++
 				suggestionList.SelectedRow = state.selectedIndex
-				selectedCmd := suggestionList.Rows[state.selectedIndex]
+				selectedCmd := state.selectedCommand()
 				helpList.SelectedRow = 0
-				repaintHelpWidget(hc, helpList, selectedCmd)
-				showHelpWidget(grid, inputPara, suggestionList, helpList, aiResponsePara, keyboardList)
+				state.requestHelp(hc, helpList, grid, selectedCmd)
+				showHelpWidget(grid, inputPara, suggestionList, relatedList, helpList, aiResponsePara, keyboardList)
 			}
 		case "first":
 			state.selectedIndex = 0
@@ -405,31 +1454,105 @@ func (state *historySearchState) handleNavigation(direction string, suggestionLi
 				suggestionList.SelectedRow = state.selectedIndex
 			}
 		}
+		state.repaintRelatedWidget(relatedList)
+		state.repaintUsageTitle(helpList)
+	}
+}
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// renderSparkline draws counts as a unicode sparkline. Zero days use the
+// lowest bar; any use shows at least the second level so it stays visible.
+func renderSparkline(counts []int) string {
+	max := 0
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+
+	var sb strings.Builder
+	for _, c := range counts {
+		level := 0
+		if c > 0 {
+			level = 1 + c*(len(sparklineLevels)-2)/max
+		}
+		sb.WriteRune(sparklineLevels[level])
+	}
+	return sb.String()
+}
+
+// repaintUsageTitle shows the selected command's daily usage sparkline in the
+// help pane title when usage tracking is enabled
+func (state *historySearchState) repaintUsageTitle(helpList *widgets.List) {
+	helpList.Title = " Help Doc "
+	if state.selectedIndex < 0 || state.selectedIndex >= len(state.currentCommands) {
+		return
+	}
+	counts := state.currentCommands[state.selectedIndex].Metadata.DailyCounts
+	if len(counts) == 0 {
+		return
+	}
+	helpList.Title = fmt.Sprintf(" Help Doc | Last %dd %s ", len(counts), renderSparkline(counts))
+}
+
+// repaintRelatedWidget lists the commands most often run next to the selected one
+func (state *historySearchState) repaintRelatedWidget(relatedList *widgets.List) {
+	relatedList.SelectedRow = 0
+	if state.selectedIndex < 0 || state.selectedIndex >= len(state.currentCommands) {
+		relatedList.Rows = []string{}
+		return
+	}
+
+	related := TopRelatedCommands(state.currentCommands[state.selectedIndex].Metadata, maxRelatedCommands)
+	if len(related) == 0 {
+		relatedList.Rows = []string{"No related commands found"}
+		return
 	}
+	relatedList.Rows = related
 }
 
-func run(tree *AVLTree, hc *cache.Cache) {
+// run shows the history search UI over tree. When tree is empty, the UI
+// explains how to build up history instead, quoting historyErr if reading
+// the history failed. When tail is set, commands appended to the history file
+// while the UI is open are added to tree and the search is run again.
+func run(tree *AVLTree, hc *HelpCache, historyErr error, tail *historyTail) error {
 	config, err := LoadConfig()
 	if err != nil {
 		log.Printf("Failed to load configuration: %v. Using default settings.", err)
-		config = &Config{History: HistoryConfig{EnableFuzzing: true}}
+		config = cloneDefaultConfig()
 	}
 
+	// Loading notes may move the notes file and logs problems, so it
+	// happens before the UI takes over the terminal
+	notes := loadCommandNotes()
+	clipboardReport := &strings.Builder{}
+	defer func() { fmt.Fprint(os.Stderr, clipboardReport.String()) }()
+
 	done := make(chan bool)
 	searchDebouncer := time.NewTimer(0)
 	searchDebouncer.Stop()
 
 	if err := ui.Init(); err != nil {
-		log.Fatalf("failed to initialize termui: %v", err)
+		return fmt.Errorf("failed to initialize termui: %v", err)
 	}
 	DisableMouseInput()
 	defer ui.Close()
+	defer restoreTerminalOnSignal()()
+
+	// Enter, <C-e> and <C-o> copy, send or run the selected command
+	keyActions := commandKeyActions(config.History.EnterAction)
 
 	// Create UI widgets
-	keyboardList := createKeyboardShortcutsWidget()
+	keyboardList := createKeyboardShortcutsWidget(keyActions)
 	inputPara := createInputWidget()
 	suggestionList := createSuggestionListWidget()
+	relatedList := createRelatedListWidget()
 	helpList := createHelpListWidget()
+	borders := newBorderStyles(config.UI)
+	suggestionList.BorderStyle = borders.focused
+	relatedList.BorderStyle = borders.blurred
+	helpList.BorderStyle = borders.blurred
 	aiResponsePara := widgets.NewParagraph()
 	aiResponsePara.Title = " AI Doc "
 	aiResponsePara.Text = ""
@@ -439,7 +1562,7 @@ func run(tree *AVLTree, hc *cache.Cache) {
 	termWidth, termHeight := ui.TerminalDimensions()
 	grid := ui.NewGrid()
 	grid.SetRect(0, 0, termWidth, termHeight)
-	showHelpWidget(grid, inputPara, suggestionList, helpList, aiResponsePara, keyboardList)
+	showHelpWidget(grid, inputPara, suggestionList, relatedList, helpList, aiResponsePara, keyboardList)
 	ui.Render(grid)
 
 	// Initialize search state
@@ -448,108 +1571,252 @@ func run(tree *AVLTree, hc *cache.Cache) {
 		selectedIndex:   0,
 		lastSearchQuery: "",
 		focusOnHelp:     false,
+		results:         newResultsCache[RankedCommand](searchResultsCacheSize),
+		selection:       newMultiSelection(),
+		helpDelay:       time.Duration(config.Help.DebounceMs) * time.Millisecond,
+		prefetchCount:   config.Help.PrefetchNeighbors,
+		ui:              config.UI,
+		matching:        config.History,
+		notes:           notes,
+		clipboardReport: clipboardReport,
+		palette:         newCommandPalette(historyShortcuts(keyActions)),
 	}
+	state.dangerous, err = compileDangerousPatterns(config.Safety.DangerousPatterns)
+	if err != nil {
+		log.Printf("Safety settings: %v", err)
+	}
+	state.refreshInputTitle(inputPara)
+	configureHelpManager(config.Help)
+	helpCacheBySubcommand = config.Help.CacheBySubcommand
+	hc.SetMaxBytes(int64(config.Help.CacheMaxMB) << 20)
+	state.helpDebouncer = time.AfterFunc(time.Hour, func() {
+		state.fetchPendingHelp(hc, helpList, grid)
+	})
+	state.helpDebouncer.Stop()
+	defer state.helpDebouncer.Stop()
 
 	uiEvents := ui.PollEvents()
 
-	// Start debouncer goroutine
+	historyUpdates := make(chan []HistoryEntry)
+	if tail != nil {
+		stopTail := make(chan struct{})
+		defer close(stopTail)
+		go tail.watch(historyPollInterval, historyUpdates, stopTail)
+	}
+
+	// Start debouncer goroutine. Searches run here; new history and match
+	// mode changes take searchMu so they never overlap a search.
 	go func() {
 		for {
 			select {
 			case <-done:
 				return
 			case <-searchDebouncer.C:
-				state.updateSearchResults(tree, config, suggestionList, helpList, hc, grid)
+				state.updateSearchResults(tree, config, suggestionList, relatedList, helpList, hc, grid)
 			}
 		}
 	}()
 
+	if tree.Root == nil {
+		state.emptyHistory = true
+		helpList.Rows = emptyHistoryLines(historyErr)
+	}
+
 	// Perform initial search
-	state.updateSearchResults(tree, config, suggestionList, helpList, hc, grid)
+	state.updateSearchResults(tree, config, suggestionList, relatedList, helpList, hc, grid)
 
 	for {
-		e := <-uiEvents
+		var e ui.Event
+		select {
+		case e = <-uiEvents:
+		case entries := <-historyUpdates:
+			// New history is added here rather than on the debouncer
+			// goroutine: it updates the Related maps of commands that the
+			// event loop reads while navigating
+			state.searchMu.Lock()
+			added := tail.addHistoryEntries(tree, entries, config.History)
+			if added {
+				state.emptyHistory = false
+				state.results.Clear()
+				state.searchStale = true
+			}
+			state.searchMu.Unlock()
+			if added {
+				searchDebouncer.Reset(0)
+			}
+			continue
+		}
+
+		// While a note is being written every key edits the note
+		if state.noteEditing {
+			if state.handleNoteKey(e) {
+				state.refreshInputTitle(inputPara)
+				inputPara.Text = state.inputBuffer
+				state.requestHelp(hc, helpList, grid, state.selectedCommand())
+			} else {
+				inputPara.Text = state.noteBuffer
+			}
+			ui.Render(grid)
+			continue
+		}
+
+		// While a help search term is being typed every key edits the term
+		if state.helpSearch.editing {
+			if state.handleHelpSearchKey(e, helpList) {
+				state.refreshInputTitle(inputPara)
+				inputPara.Text = state.inputBuffer
+			} else {
+				inputPara.Text = "/" + state.helpSearch.buffer
+			}
+			ui.Render(grid)
+			continue
+		}
+
+		// A dangerous command waits for "y" before being sent or run; any other key cancels
+		if state.confirmCommand != "" {
+			command, action := state.confirmCommand, state.confirmAction
+			state.confirmCommand, state.confirmAction = "", ""
+			if e.ID == "y" || e.ID == "Y" {
+				ui.Close()
+				runCommandAction(action, command, state.matching.DiffOutput)
+				return nil
+			}
+			state.refreshInputTitle(inputPara)
+			inputPara.Text = state.inputBuffer
+			ui.Render(grid)
+			continue
+		}
+
+		// A yank (<C-y>) consumes the next key as the register number
+		if state.yankPending {
+			state.yankPending = false
+			if slot, ok := registerSlot(e.ID); ok {
+				if command := state.selectedCommand(); command != "" {
+					state.registers.Set(slot, state.outputCommand(command))
+				}
+				state.refreshInputTitle(inputPara)
+				ui.Render(grid)
+				continue
+			}
+			state.refreshInputTitle(inputPara)
+		}
+
+		// The command palette takes every key; a chosen action runs as if its key was pressed
+		if state.palette.open && e.ID != "<Resize>" {
+			key := state.palette.handleKey(e)
+			if key == "" {
+				renderUnderPalette(grid, state.palette)
+				continue
+			}
+			e = ui.Event{Type: ui.KeyboardEvent, ID: key}
+		}
+
 		switch e.ID {
 		case "<C-c>", "<Escape>":
 			done <- true
-			return
+			return nil
+		case "<C-p>":
+			state.palette.show()
+		case "<C-y>":
+			if len(state.currentCommands) > 0 {
+				state.yankPending = true
+				state.refreshInputTitle(inputPara)
+			}
+		case "<C-g>":
+			if dump := state.registers.Dump(); dump != "" {
+				err := copyToClipboard(dump)
+				ui.Close()
+				if err != nil {
+					reportClipboardFailure(os.Stderr, err, dump)
+					return nil
+				}
+				cliFprintf(os.Stderr, "📋 Copied %s%d registers%s to clipboard.\n", Green, len(state.registers.Filled()), Reset)
+				return nil
+			}
 		case "<C-z>":
 			selectedText := helpList.Rows[helpList.SelectedRow]
-			if err := clipboard.WriteAll(selectedText); err != nil {
+			if err := copyToClipboard(selectedText); err != nil {
 				log.Printf("Failed to copy text: %v", err)
 			} else {
 				log.Println("Text successfully copied to clipboard!")
 			}
+		case "<C-x>":
+			var helpCmd string
+			if len(state.currentCommands) > 0 {
+				helpCmd = state.selectedCommand()
+			} else {
+				helpCmd = state.inputBuffer
+			}
+			if helpTxt := GetHelpPage(hc, helpCmd); helpTxt != "" {
+				helpList.Title = strings.TrimSuffix(helpList.Title, " ") + " | " + state.copyInUI(helpCopyText(helpTxt, state.ui.HelpCopyFormat)) + " "
+			}
 		case "<Tab>":
 			state.focusOnHelp = !state.focusOnHelp
-			toggleBorders(suggestionList, helpList)
+			toggleBorders(suggestionList, helpList, borders)
 		case "<Backspace>":
-			if len(state.inputBuffer) > 0 {
-				state.inputBuffer = state.inputBuffer[:len(state.inputBuffer)-1]
-			}
+			state.inputBuffer = dropLastRune(state.inputBuffer)
 			searchDebouncer.Reset(debounceDelay)
 		case "<Space>":
 			state.inputBuffer += " "
 			searchDebouncer.Reset(debounceDelay)
-		case "<Enter>":
-			var commandToCopy string
-			if len(suggestionList.Rows) > 0 {
-				commandToCopy = suggestionList.Rows[state.selectedIndex]
-			} else {
-				commandToCopy = state.inputBuffer
-			}
-			if commandToCopy != "" {
-				if err := clipboard.WriteAll(commandToCopy); err != nil {
-					log.Printf("Failed to copy command to clipboard: %v", err)
-				}
+		case "<Enter>", "<C-e>", "<C-o>":
+			if state.performCommandAction(keyActions[commandKeys[e.ID]], inputPara, grid) {
+				return nil
 			}
-			ui.Close()
-			if commandToCopy != "" {
-				fmt.Fprintf(os.Stderr, "📋 Copied %s%s%s to clipboard.\n", Green, commandToCopy, Reset)
-			}
-			return
-		case "<C-e>":
-			var commandToSend string
-			if len(suggestionList.Rows) > 0 {
-				commandToSend = suggestionList.Rows[state.selectedIndex]
-			} else {
-				commandToSend = state.inputBuffer
+			if state.confirmCommand != "" {
+				continue
 			}
-
-			if commandToSend != "" {
-				if err := sendToTerminal(commandToSend); err != nil {
-					log.Printf("Failed to send command to terminal: %v", err)
-				} else {
-					fmt.Printf("⚡ Sent `%s` to terminal\n", commandToSend)
-				}
+		case "<C-<Space>>":
+			if !state.focusOnHelp && len(state.currentCommands) > 0 {
+				state.selection.Toggle(state.selectedCommand())
+				state.refreshSuggestionRows(suggestionList)
 			}
-			ui.Close()
-			return
 		case "<Up>":
-			state.handleNavigation("up", suggestionList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
+			state.handleNavigation("up", suggestionList, relatedList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
 		case "<Down>":
-			state.handleNavigation("down", suggestionList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
+			state.handleNavigation("down", suggestionList, relatedList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
 		case "<F1>":
 			var selectedCmd string
-			if len(suggestionList.Rows) > 0 {
-				selectedCmd = suggestionList.Rows[state.selectedIndex]
+			if len(state.currentCommands) > 0 {
+				selectedCmd = state.selectedCommand()
 			} else {
 				selectedCmd = inputPara.Text
 			}
-			repaintHelpWidget(hc, helpList, selectedCmd)
-			showHelpWidget(grid, inputPara, suggestionList, helpList, aiResponsePara, keyboardList)
+			state.requestHelp(hc, helpList, grid, selectedCmd)
+			showHelpWidget(grid, inputPara, suggestionList, relatedList, helpList, aiResponsePara, keyboardList)
+		case "<F2>":
+			// Debug aid: toggle showing the command that produced the help text
+			state.showHelpSource = !state.showHelpSource
+			if len(state.currentCommands) > 0 {
+				state.requestHelp(hc, helpList, grid, state.selectedCommand())
+			}
+		case "<C-n>":
+			state.startNoteEdit(inputPara)
+			if state.noteEditing {
+				inputPara.Text = state.noteBuffer
+				ui.Render(grid)
+				continue
+			}
 		case "<C-u>":
-			if !state.focusOnHelp {
-				state.inputBuffer = suggestionList.Rows[state.selectedIndex]
+			if !state.focusOnHelp && len(state.currentCommands) > 0 {
+				state.inputBuffer = state.selectedCommand()
 			}
+		case "<C-f>":
+			state.searchMu.Lock()
+			state.matching = nextMatchMode(state.matching)
+			state.results.Clear()
+			state.searchStale = true
+			state.searchMu.Unlock()
+			state.refreshInputTitle(inputPara)
+			searchDebouncer.Reset(0)
 		case "<C-r>":
 			if !state.focusOnHelp {
 				state.inputBuffer = ""
 			}
 		case "<C-j>":
-			state.handleNavigation("last", suggestionList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
+			state.handleNavigation("last", suggestionList, relatedList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
 		case "<C-k>":
-			state.handleNavigation("first", suggestionList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
+			state.handleNavigation("first", suggestionList, relatedList, helpList, hc, grid, inputPara, aiResponsePara, keyboardList)
 		case "<Resize>":
 			if payload, ok := e.Payload.(ui.Resize); ok {
 				grid.SetRect(0, 0, payload.Width, payload.Height)
@@ -557,20 +1824,27 @@ func run(tree *AVLTree, hc *cache.Cache) {
 				termWidth, termHeight := ui.TerminalDimensions()
 				grid.SetRect(0, 0, termWidth, termHeight)
 			}
-			showHelpWidget(grid, inputPara, suggestionList, helpList, aiResponsePara, keyboardList)
+			showHelpWidget(grid, inputPara, suggestionList, relatedList, helpList, aiResponsePara, keyboardList)
 			ui.Clear()
 			ui.Render(grid)
 		default:
-			if !state.focusOnHelp {
-				if e.Type == ui.KeyboardEvent && len(e.ID) == 1 {
-					state.inputBuffer += e.ID
-					searchDebouncer.Reset(debounceDelay)
+			if e.ID == "?" && state.inputBuffer == "" {
+				state.palette.show()
+			} else if state.focusOnHelp {
+				state.handleHelpPaneKey(e.ID, helpList, inputPara)
+				if state.helpSearch.editing {
+					inputPara.Text = "/"
+					ui.Render(grid)
+					continue
 				}
+			} else if text, ok := typedText(e); ok {
+				state.inputBuffer += text
+				searchDebouncer.Reset(debounceDelay)
 			}
 		}
 
 		inputPara.Text = state.inputBuffer
-		ui.Render(grid)
+		renderUnderPalette(grid, state.palette)
 	}
 }
 
@@ -593,11 +1867,7 @@ func formatFileForDisplay(file RankedFile) string {
 		displayPath = relPath
 	}
 
-	if len(displayPath) > maxPathDisplayLen {
-		displayPath = "..." + displayPath[len(displayPath)-maxPathDisplayLen+3:]
-	}
-
-	return fmt.Sprintf("%s %s", icon, displayPath)
+	return fmt.Sprintf("%s %s", icon, truncateWidthLeft(displayPath, maxPathDisplayLen))
 }
 
 // formatFileSize formats file size in human-readable format
@@ -624,10 +1894,196 @@ type filesystemSearchState struct {
 	focusOnMetadata bool
 	filterMode      int
 	currentFiles    []RankedFile
+	selection       *multiSelection
+	ui              UIConfig
+	fuzzy           bool   // Live match mode, toggled with <C-f>
+	scope           string // Only paths under this directory are searched; "" for all
+	showFullPath    bool   // The footer shows the selected path in full, toggled with <C-l>
+	footer          *widgets.Paragraph
+	palette         *commandPalette // Searchable list of the footer's actions (<C-p>, or ? with an empty input)
+	// indexMu serializes index access between searches and a background
+	// refresh, and guards results
+	indexMu sync.Mutex
+	results *resultsCache[RankedFile] // Recent queries; cleared on refresh and <C-f>
+
+	// A background refresh (<F5>) re-indexes a copy of the index and reports
+	// it to the event loop on refreshDone, which animates refreshSpinner in
+	// the file list title meanwhile
+	refreshing     bool
+	refreshDone    chan refreshResult
+	refreshCancel  context.CancelFunc
+	refreshes      sync.WaitGroup
+	refreshSpinner *time.Ticker
+	refreshFrame   int
+}
+
+// refreshResult is a refreshed copy of the index, swapped in by finishRefresh
+type refreshResult struct {
+	index *FilesystemIndexer
+	err   error
+}
+
+// refreshInputTitle shows the active match mode in the search input title
+func (state *filesystemSearchState) refreshInputTitle(inputPara *widgets.Paragraph) {
+	mode := "Prefix"
+	if state.fuzzy {
+		mode = "Fuzzy"
+	}
+	inputPara.Title = fmt.Sprintf(" Search Files & Directories | Match: %s ", mode)
+}
+
+// refreshFileRows rebuilds the visible rows from the current results
+func (state *filesystemSearchState) refreshFileRows(fileList *widgets.List) {
+	fileList.Rows = fileList.Rows[:0]
+	for _, file := range state.currentFiles {
+		fileList.Rows = append(fileList.Rows, markSelected(formatFileForDisplay(file), state.selection.Contains(file.Path)))
+	}
 }
 
 func (state *filesystemSearchState) updateFileListTitle(fileList *widgets.List) {
 	fileList.Title = fmt.Sprintf(" %s %s ", filterIcons[state.filterMode], filterModes[state.filterMode])
+	if state.scope != "" {
+		fileList.Title += fmt.Sprintf("| 📍 %s ", displayScope(state.scope, os.Getenv("HOME")))
+	}
+}
+
+// maxScopeDisplayLen bounds the scope shown in the file list title
+const maxScopeDisplayLen = 40
+
+// displayScope shortens a search scope for the file list title, writing the
+// home directory as ~
+func displayScope(scope string, home string) string {
+	if home != "" {
+		home = filepath.Clean(home)
+		if home != string(filepath.Separator) && isUnderRoot(scope, home) {
+			scope = "~" + strings.TrimPrefix(scope, home)
+		}
+	}
+	return truncateWidthLeft(scope, maxScopeDisplayLen)
+}
+
+// toggleCwdScope restricts the search to the current directory, or searches
+// the whole index again when a scope is already set
+func (state *filesystemSearchState) toggleCwdScope() {
+	if state.scope != "" {
+		state.scope = ""
+	} else if cwd, err := os.Getwd(); err == nil {
+		state.scope = cwd
+	}
+	state.indexMu.Lock()
+	state.results.Clear()
+	state.indexMu.Unlock()
+	state.lastSearchQuery = ""
+}
+
+var refreshSpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// refreshIndexInBackground re-indexes tracked paths without blocking the UI.
+// The event loop animates a spinner in the file list title on each
+// refreshSpinner tick and calls finishRefresh with the result from
+// refreshDone. Searches keep using fsIndexer while a copy is re-indexed.
+func (state *filesystemSearchState) refreshIndexInBackground(fsIndexer *FilesystemIndexer) {
+	if state.refreshing {
+		return
+	}
+	state.refreshing = true
+	state.refreshFrame = 0
+	state.refreshSpinner = time.NewTicker(100 * time.Millisecond)
+
+	state.indexMu.Lock()
+	refreshed := fsIndexer.Clone()
+	state.indexMu.Unlock()
+	// Indexing logs to stderr, which would corrupt the terminal UI
+	refreshed.SetLogger(log.New(io.Discard, "", 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	state.refreshCancel = cancel
+	state.refreshes.Add(1)
+	go func() {
+		defer state.refreshes.Done()
+		err := refreshed.RefreshIndexContext(ctx, false, false)
+		if ctx.Err() != nil {
+			return
+		}
+		state.refreshDone <- refreshResult{index: refreshed, err: err}
+	}()
+}
+
+// stopRefresh cancels an in-flight refresh and waits for it to return, so
+// the index isn't written after the search exits. A refresh that already
+// finished is still swapped in.
+func (state *filesystemSearchState) stopRefresh(fsIndexer *FilesystemIndexer) {
+	if !state.refreshing {
+		return
+	}
+	state.refreshCancel()
+	state.refreshes.Wait()
+	select {
+	case result := <-state.refreshDone:
+		state.swapRefreshed(fsIndexer, result)
+	default:
+	}
+	state.refreshSpinner.Stop()
+	state.refreshSpinner = nil
+	state.refreshing = false
+}
+
+// swapRefreshed replaces fsIndexer's contents with a successful refresh
+func (state *filesystemSearchState) swapRefreshed(fsIndexer *FilesystemIndexer, result refreshResult) {
+	if result.err != nil {
+		return
+	}
+	state.indexMu.Lock()
+	fsIndexer.ReplaceWith(result.index)
+	state.results.Clear()
+	state.indexMu.Unlock()
+}
+
+// spinRefresh shows the next spinner frame in the file list title
+func (state *filesystemSearchState) spinRefresh(fileList *widgets.List) {
+	fileList.Title = fmt.Sprintf(" %s Refreshing index... ", refreshSpinnerFrames[state.refreshFrame%len(refreshSpinnerFrames)])
+	state.refreshFrame++
+}
+
+// finishRefresh stops the spinner and re-runs the current query once a
+// background refresh ends with result
+func (state *filesystemSearchState) finishRefresh(result refreshResult, fsIndexer *FilesystemIndexer, config *Config, fileList *widgets.List, metadataList *widgets.List, grid *ui.Grid) {
+	state.swapRefreshed(fsIndexer, result)
+	state.refreshSpinner.Stop()
+	state.refreshSpinner = nil
+	state.refreshing = false
+	state.lastSearchQuery = ""
+	state.updateFileResults(fsIndexer, config, fileList, metadataList, grid)
+	if result.err != nil {
+		fileList.Title = fmt.Sprintf(" ❌ Refresh failed: %v ", result.err)
+		renderUnderPalette(grid, state.palette)
+	}
+}
+
+// formatScore renders a ranking score either as a number with the configured
+// precision or, with score labels enabled, as High/Medium/Low relative to the
+// best score in the current results.
+func formatScore(score, best float64, cfg UIConfig) string {
+	if cfg.ScoreLabels {
+		ratio := 1.0
+		if best > 0 {
+			ratio = score / best
+		}
+		switch {
+		case ratio >= 0.66:
+			return "High"
+		case ratio >= 0.33:
+			return "Medium"
+		default:
+			return "Low"
+		}
+	}
+
+	precision := cfg.ScorePrecision
+	if precision < 0 {
+		precision = 0
+	}
+	return strconv.FormatFloat(score, 'f', precision, 64)
 }
 
 func (state *filesystemSearchState) updateMetadataDisplay(metadataList *widgets.List) {
@@ -658,8 +2114,13 @@ func (state *filesystemSearchState) updateMetadataDisplay(metadataList *widgets.
 	} else {
 		metadata = append(metadata, "🕒 Last Accessed: Never")
 	}
+	if len(file.Metadata.Tags) > 0 {
+		metadata = append(metadata, fmt.Sprintf("🏷️  Tags: %s", strings.Join(file.Metadata.Tags, ", ")))
+	}
 	metadata = append(metadata, fmt.Sprintf("📊 Access Count: %d", file.Metadata.AccessCount))
-	metadata = append(metadata, fmt.Sprintf("⭐ Score: %.2f", file.Score))
+	if state.ui.ShowScore {
+		metadata = append(metadata, fmt.Sprintf("⭐ Score: %s", formatScore(file.Score, state.currentFiles[0].Score, state.ui)))
+	}
 
 	if !file.Metadata.IsDirectory && file.Metadata.Size > 0 {
 		size := formatFileSize(file.Metadata.Size)
@@ -670,6 +2131,11 @@ func (state *filesystemSearchState) updateMetadataDisplay(metadataList *widgets.
 		metadata = append(metadata, fmt.Sprintf("✏️  Modified: %s", file.Metadata.LastModified.Format("2006-01-02 15:04:05")))
 	}
 
+	if file.Metadata.Owner >= 0 {
+		metadata = append(metadata, fmt.Sprintf("👤 Owner: %s", ownerName(file.Metadata.Owner)))
+		metadata = append(metadata, fmt.Sprintf("🔐 Permissions: %s", permissionSummary(file.Metadata.Mode, file.Metadata.IsDirectory)))
+	}
+
 	if file.Metadata.IsHidden {
 		metadata = append(metadata, "🔒 Hidden file")
 	}
@@ -679,6 +2145,23 @@ func (state *filesystemSearchState) updateMetadataDisplay(metadataList *widgets.
 
 	metadataList.Rows = metadata
 	metadataList.SelectedRow = 0
+	state.refreshFooter()
+}
+
+// refreshFooter shows the full path of the selected item in the footer while
+// showFullPath is on, since list rows are truncated and basenames can be
+// ambiguous, and the keyboard shortcuts otherwise
+func (state *filesystemSearchState) refreshFooter() {
+	if state.footer == nil {
+		return
+	}
+	state.footer.Title = " Filesystem Search Shortcuts "
+	state.footer.Text = filesystemShortcutsText
+	if !state.showFullPath || state.selectedIndex >= len(state.currentFiles) {
+		return
+	}
+	state.footer.Title = " Full Path "
+	state.footer.Text = "📍 " + state.currentFiles[state.selectedIndex].Path + "  [<ctrl+l>](fg:green) Show shortcuts"
 }
 
 func (state *filesystemSearchState) updateFileResults(fsIndexer *FilesystemIndexer, config *Config, fileList *widgets.List, metadataList *widgets.List, grid *ui.Grid) {
@@ -691,30 +2174,17 @@ func (state *filesystemSearchState) updateFileResults(fsIndexer *FilesystemIndex
 		fileList.Rows = []string{"Type to search files and directories..."}
 		state.currentFiles = []RankedFile{}
 	} else {
-		allFiles := fsIndexer.SearchFiles(state.inputBuffer, config.History.EnableFuzzing)
-		filteredFiles := []RankedFile{}
-
-		for _, file := range allFiles {
-			switch state.filterMode {
-			case filterModeAll:
-				filteredFiles = append(filteredFiles, file)
-			case filterModeDirs:
-				if file.Metadata.IsDirectory {
-					filteredFiles = append(filteredFiles, file)
-				}
-			case filterModeFiles:
-				if !file.Metadata.IsDirectory {
-					filteredFiles = append(filteredFiles, file)
-				}
-			}
+		state.indexMu.Lock()
+		allFiles, ok := state.results.Get(state.inputBuffer)
+		if !ok {
+			allFiles = fsIndexer.SearchFilesIn(state.inputBuffer, state.fuzzy, state.scope)
+			state.results.Put(state.inputBuffer, allFiles)
 		}
+		state.indexMu.Unlock()
+		filteredFiles := filterFilesByMode(allFiles, state.filterMode)
 
 		state.currentFiles = filteredFiles
-		fileList.Rows = fileList.Rows[:0]
-
-		for _, file := range filteredFiles {
-			fileList.Rows = append(fileList.Rows, formatFileForDisplay(file))
-		}
+		state.refreshFileRows(fileList)
 
 		if len(fileList.Rows) == 0 {
 			filterText := filterModes[state.filterMode]
@@ -736,13 +2206,33 @@ func (state *filesystemSearchState) updateFileResults(fsIndexer *FilesystemIndex
 
 	state.updateFileListTitle(fileList)
 	state.updateMetadataDisplay(metadataList)
-	ui.Render(grid)
+	renderUnderPalette(grid, state.palette)
+}
+
+// filesystemShortcuts lists the filesystem UI keys
+var filesystemShortcuts = []shortcut{
+	{"<enter>", "Open file(s)", "<Enter>"},
+	{"<ctrl+space>", "Select", "<C-<Space>>"},
+	{"<ctrl+x>", "Copy path(s)", "<C-x>"},
+	{"<ctrl+f>", "Match mode", "<C-f>"},
+	{"<ctrl+r>", "Reset input", "<C-r>"},
+	{"<up/down>", "Navigate", ""},
+	{"<ctrl+j/k>", "Jump first/last", ""},
+	{"<ctrl+t>", "Toggle filter", "<C-t>"},
+	{"<ctrl+d>", "Scope to cwd", "<C-d>"},
+	{"<ctrl+l>", "Full path", "<C-l>"},
+	{"<F5>", "Refresh index", "<F5>"},
+	{"<tab>", "Switch panels", "<Tab>"},
+	{"<ctrl+p> ?", "Actions", ""},
+	{"<esc>", "Quit", "<Escape>"},
 }
 
+var filesystemShortcutsText = shortcutsText(filesystemShortcuts)
+
 func createFilesystemKeyboardWidget() *widgets.Paragraph {
 	keyboardList := widgets.NewParagraph()
 	keyboardList.Title = " Filesystem Search Shortcuts "
-	keyboardList.Text = `[<enter>](fg:green) Open file  [<ctrl+x>](fg:green) Copy path  [<ctrl+r>](fg:green) Reset input  [<up/down>](fg:green) Navigate  [<ctrl+j/k>](fg:green) Jump first/last  [<ctrl+t>](fg:green) Toggle filter  [<tab>](fg:green) Switch panels  [<esc>](fg:green) Quit`
+	keyboardList.Text = filesystemShortcutsText
 	keyboardList.TextStyle.Fg = ui.ColorWhite
 	keyboardList.BorderStyle.Fg = ui.ColorWhite
 	return keyboardList
@@ -758,6 +2248,44 @@ func createFilesystemInputWidget() *widgets.Paragraph {
 	return inputPara
 }
 
+// openIndexedPaths opens paths with their filesystem.open_with rule or the
+// default application and counts the access in the index. Terminal openers
+// (editors, pagers) need the screen, so their command lines are returned for
+// runTerminalOpeners once the UI closes.
+func openIndexedPaths(fsIndexer *FilesystemIndexer, config *Config, paths []string) []string {
+	var terminalCommands []string
+	for _, filePath := range paths {
+		fsIndexer.AddPath(filePath, time.Now(), true)
+
+		var err error
+		if rule, ok := openWithRule(filePath, config.Filesystem.OpenWith); ok {
+			if rule.Terminal {
+				terminalCommands = append(terminalCommands, openWithCommandLine(rule, filePath))
+				continue
+			}
+			err = runShellCommand(openWithCommandLine(rule, filePath), false)
+		} else {
+			err = openFileWithDefaultApp(filePath)
+		}
+		if err != nil {
+			log.Printf("Failed to open file: %v", err)
+		} else {
+			cliPrintf("🚀 Opened: %s\n", filePath)
+		}
+	}
+	return terminalCommands
+}
+
+// runTerminalOpeners runs the terminal openers returned by openIndexedPaths
+// in this terminal, one after another. The UI must already be closed.
+func runTerminalOpeners(commands []string) {
+	for _, command := range commands {
+		if err := runShellCommand(command, true); err != nil {
+			log.Printf("Failed to open file: %v", err)
+		}
+	}
+}
+
 func createFileListWidget() *widgets.List {
 	fileList := widgets.NewList()
 	fileList.Title = " 📁 Files & Directories "
@@ -778,8 +2306,9 @@ func createMetadataListWidget() *widgets.List {
 	return metadataList
 }
 
-// runFilesystemSearch launches the filesystem search UI
-func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config) {
+// runFilesystemSearch runs the filesystem search UI. A non-empty scope, an
+// absolute directory, starts with the search restricted to it.
+func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config, scope string) {
 	searchDebouncer := time.NewTimer(0)
 	searchDebouncer.Stop()
 
@@ -788,12 +2317,16 @@ func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config) {
 	}
 	DisableMouseInput()
 	defer ui.Close()
+	defer restoreTerminalOnSignal()()
 
 	// Create UI widgets
 	keyboardList := createFilesystemKeyboardWidget()
 	inputPara := createFilesystemInputWidget()
 	fileList := createFileListWidget()
 	metadataList := createMetadataListWidget()
+	borders := newBorderStyles(config.UI)
+	fileList.BorderStyle = borders.focused
+	metadataList.BorderStyle = borders.blurred
 
 	// Setup layout
 	termWidth, termHeight := ui.TerminalDimensions()
@@ -821,7 +2354,17 @@ func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config) {
 		focusOnMetadata: false,
 		filterMode:      filterModeAll,
 		currentFiles:    []RankedFile{},
+		selection:       newMultiSelection(),
+		ui:              config.UI,
+		fuzzy:           config.History.EnableFuzzing,
+		scope:           scope,
+		footer:          keyboardList,
+		palette:         newCommandPalette(filesystemShortcuts),
+		results:         newResultsCache[RankedFile](searchResultsCacheSize),
+		refreshDone:     make(chan refreshResult, 1),
 	}
+	defer state.stopRefresh(fsIndexer)
+	state.refreshInputTitle(inputPara)
 
 	uiEvents := ui.PollEvents()
 	done := make(chan bool)
@@ -843,23 +2386,42 @@ func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config) {
 	state.updateFileResults(fsIndexer, config, fileList, metadataList, grid)
 
 	for {
-		e := <-uiEvents
+		var spinnerTicks <-chan time.Time
+		if state.refreshSpinner != nil {
+			spinnerTicks = state.refreshSpinner.C
+		}
+		var e ui.Event
+		select {
+		case <-spinnerTicks:
+			state.spinRefresh(fileList)
+			renderUnderPalette(grid, state.palette)
+			continue
+		case result := <-state.refreshDone:
+			state.finishRefresh(result, fsIndexer, config, fileList, metadataList, grid)
+			continue
+		case e = <-uiEvents:
+		}
+
+		// The command palette takes every key; a chosen action runs as if its key was pressed
+		if state.palette.open && e.ID != "<Resize>" {
+			key := state.palette.handleKey(e)
+			if key == "" {
+				renderUnderPalette(grid, state.palette)
+				continue
+			}
+			e = ui.Event{Type: ui.KeyboardEvent, ID: key}
+		}
+
 		switch e.ID {
 		case "<C-c>", "<Escape>":
-			done <- true
+			close(done)
 			return
 		case "<Tab>":
 			state.focusOnMetadata = !state.focusOnMetadata
-			if state.focusOnMetadata {
-				fileList.BorderStyle = ui.NewStyle(ui.ColorWhite)
-				metadataList.BorderStyle = ui.NewStyle(ui.ColorCyan)
-			} else {
-				fileList.BorderStyle = ui.NewStyle(ui.ColorCyan)
-				metadataList.BorderStyle = ui.NewStyle(ui.ColorWhite)
-			}
+			toggleBorders(fileList, metadataList, borders)
 		case "<Backspace>":
 			if !state.focusOnMetadata && len(state.inputBuffer) > 0 {
-				state.inputBuffer = state.inputBuffer[:len(state.inputBuffer)-1]
+				state.inputBuffer = dropLastRune(state.inputBuffer)
 				searchDebouncer.Reset(fsDebounceDelay)
 			}
 		case "<Space>":
@@ -872,32 +2434,57 @@ func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config) {
 				searchDebouncer.Reset(fsDebounceDelay)
 			}
 		case "<Enter>":
-			if len(state.currentFiles) > state.selectedIndex && state.selectedIndex >= 0 {
-				filePath := state.currentFiles[state.selectedIndex].Path
-				fsIndexer.AddPath(filePath, time.Now(), true)
-
-				if err := openFileWithDefaultApp(filePath); err != nil {
-					log.Printf("Failed to open file: %v", err)
-				} else {
-					fmt.Printf("🚀 Opened: %s\n", filePath)
-				}
-
-				go func() {
-					if err := fsIndexer.PersistIndex(!config.Quiet); err != nil {
-						log.Printf("Failed to persist index: %v", err)
-					}
-				}()
+			var pathsToOpen []string
+			if state.selection.Len() > 0 {
+				pathsToOpen = state.selection.Items()
+			} else if len(state.currentFiles) > state.selectedIndex && state.selectedIndex >= 0 {
+				pathsToOpen = []string{state.currentFiles[state.selectedIndex].Path}
+			}
+			// Opening records access on fsIndexer, which a refresh finishing
+			// later would overwrite
+			state.stopRefresh(fsIndexer)
+			close(done)
+			var terminalCommands []string
+			if len(pathsToOpen) > 0 {
+				state.indexMu.Lock()
+				terminalCommands = openIndexedPaths(fsIndexer, config, pathsToOpen)
+				state.indexMu.Unlock()
 			}
 			ui.Close()
+			if len(pathsToOpen) > 0 {
+				if err := fsIndexer.PersistIndex(!config.Quiet); err != nil {
+					log.Printf("Failed to persist index: %v", err)
+				}
+			}
+			runTerminalOpeners(terminalCommands)
 			return
+		case "<C-<Space>>":
+			if !state.focusOnMetadata && len(state.currentFiles) > state.selectedIndex && state.selectedIndex >= 0 {
+				state.selection.Toggle(state.currentFiles[state.selectedIndex].Path)
+				state.refreshFileRows(fileList)
+			}
 		case "<C-x>":
+			if state.selection.Len() > 0 {
+				paths := state.selection.Items()
+				text := strings.Join(paths, "\n")
+				err := copyToClipboard(text)
+				ui.Close()
+				if err != nil {
+					reportClipboardFailure(os.Stderr, err, text)
+					return
+				}
+				cliPrintf("📋 Copied %d paths\n", len(paths))
+				return
+			}
 			if len(state.currentFiles) > state.selectedIndex && state.selectedIndex >= 0 {
 				filePath := state.currentFiles[state.selectedIndex].Path
-				if err := clipboard.WriteAll(filePath); err != nil {
-					log.Printf("Failed to copy path: %v", err)
-				}
+				err := copyToClipboard(filePath)
 				ui.Close()
-				fmt.Printf("📋 Copied path: %s\n", filePath)
+				if err != nil {
+					reportClipboardFailure(os.Stderr, err, filePath)
+					return
+				}
+				cliPrintf("📋 Copied path: %s\n", filePath)
 				return
 			}
 		case "<Up>":
@@ -949,10 +2536,28 @@ func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config) {
 			} else {
 				metadataList.SelectedRow = 0
 			}
+		case "<F5>":
+			state.refreshIndexInBackground(fsIndexer)
+		case "<C-f>":
+			state.fuzzy = !state.fuzzy
+			state.refreshInputTitle(inputPara)
+			state.indexMu.Lock()
+			state.results.Clear()
+			state.indexMu.Unlock()
+			state.lastSearchQuery = ""
+			state.updateFileResults(fsIndexer, config, fileList, metadataList, grid)
 		case "<C-t>":
 			state.filterMode = (state.filterMode + 1) % 3
 			state.lastSearchQuery = ""
 			state.updateFileResults(fsIndexer, config, fileList, metadataList, grid)
+		case "<C-d>":
+			state.toggleCwdScope()
+			state.updateFileResults(fsIndexer, config, fileList, metadataList, grid)
+		case "<C-l>":
+			state.showFullPath = !state.showFullPath
+			state.refreshFooter()
+		case "<C-p>":
+			state.palette.show()
 		case "<Resize>":
 			if payload, ok := e.Payload.(ui.Resize); ok {
 				grid.SetRect(0, 0, payload.Width, payload.Height)
@@ -963,13 +2568,15 @@ func runFilesystemSearch(fsIndexer *FilesystemIndexer, config *Config) {
 			ui.Clear()
 			ui.Render(grid)
 		default:
-			if !state.focusOnMetadata && e.Type == ui.KeyboardEvent && len(e.ID) == 1 {
-				state.inputBuffer += e.ID
+			if e.ID == "?" && state.inputBuffer == "" {
+				state.palette.show()
+			} else if text, ok := typedText(e); ok && !state.focusOnMetadata {
+				state.inputBuffer += text
 				searchDebouncer.Reset(fsDebounceDelay)
 			}
 		}
 
 		inputPara.Text = state.inputBuffer
-		ui.Render(grid)
+		renderUnderPalette(grid, state.palette)
 	}
 }
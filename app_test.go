@@ -0,0 +1,588 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/mattn/go-runewidth"
+)
+
+func TestFormatScore(t *testing.T) {
+	tests := []struct {
+		score float64
+		best  float64
+		cfg   UIConfig
+		want  string
+	}{
+		{3.14159, 3.14159, UIConfig{ScorePrecision: 2}, "3.14"},
+		{3.14159, 3.14159, UIConfig{ScorePrecision: 0}, "3"},
+		{3.14159, 3.14159, UIConfig{ScorePrecision: -1}, "3"},
+		{10, 10, UIConfig{ScoreLabels: true}, "High"},
+		{5, 10, UIConfig{ScoreLabels: true}, "Medium"},
+		{1, 10, UIConfig{ScoreLabels: true}, "Low"},
+		{0, 0, UIConfig{ScoreLabels: true}, "High"},
+	}
+
+	for _, tt := range tests {
+		if got := formatScore(tt.score, tt.best, tt.cfg); got != tt.want {
+			t.Errorf("formatScore(%v, %v, %+v) = %q; want %q", tt.score, tt.best, tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestClipboardRegisters(t *testing.T) {
+	var r clipboardRegisters
+
+	if r.Dump() != "" || len(r.Filled()) != 0 {
+		t.Fatal("new registers should be empty")
+	}
+
+	r.Set(3, "make test")
+	r.Set(1, "make build")
+	r.Set(3, "make deploy")
+	if r.Set(0, "ignored") || r.Set(10, "ignored") {
+		t.Error("out-of-range slots should be rejected")
+	}
+
+	if got, want := r.Dump(), "make build\nmake deploy"; got != want {
+		t.Errorf("Dump() = %q; want %q", got, want)
+	}
+	if got := r.inputTitle(false); got != " Type Command | Registers: 1 3 " {
+		t.Errorf("inputTitle(false) = %q", got)
+	}
+}
+
+func TestRegisterSlot(t *testing.T) {
+	for id, want := range map[string]int{"1": 1, "9": 9, "0": 0, "a": 0, "<C-y>": 0} {
+		slot, ok := registerSlot(id)
+		if slot != want || ok != (want != 0) {
+			t.Errorf("registerSlot(%q) = %d, %t; want %d", id, slot, ok, want)
+		}
+	}
+}
+
+func TestRenderSparkline(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   string
+	}{
+		{[]int{0, 0, 0}, "▁▁▁"},
+		{[]int{0, 1, 6}, "▁▃█"},
+		{[]int{1, 12}, "▂█"},
+		{[]int{3, 6, 1, 0}, "▅█▃▁"},
+	}
+
+	for _, tt := range tests {
+		if got := renderSparkline(tt.counts); got != tt.want {
+			t.Errorf("renderSparkline(%v) = %q; want %q", tt.counts, got, tt.want)
+		}
+	}
+}
+
+func TestMultiplexerCommand(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want []string
+	}{
+		{map[string]string{"TMUX": "/tmp/tmux-1000/default,123,0"}, []string{"tmux", "new-window", "bash", "-lc", "ls"}},
+		{map[string]string{"STY": "4242.pts-0.host"}, []string{"screen", "-S", "4242.pts-0.host", "-X", "screen", "bash", "-lc", "ls"}},
+		{map[string]string{"TMUX": "x", "STY": "y"}, []string{"tmux", "new-window", "bash", "-lc", "ls"}},
+		{map[string]string{}, nil},
+	}
+
+	for _, tt := range tests {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := multiplexerCommand("ls", getenv); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("multiplexerCommand with env %v = %q; want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestOpenWithRule(t *testing.T) {
+	rules := map[string]OpenWithRule{
+		".md": {Command: "glow -p", Terminal: true},
+		"PNG": {Command: "feh {} &"},
+		"txt": {},
+	}
+
+	tests := []struct {
+		path    string
+		want    string
+		matched bool
+	}{
+		{"/notes/README.md", "glow -p '/notes/README.md'", true},
+		{"/pics/cat.png", "feh '/pics/cat.png' &", true},
+		{"/docs/it's.md", `glow -p '/docs/it'\''s.md'`, true},
+		{"/docs/plain.txt", "", false},
+		{"/src/Makefile", "", false},
+	}
+
+	for _, tt := range tests {
+		rule, ok := openWithRule(tt.path, rules)
+		if ok != tt.matched {
+			t.Errorf("openWithRule(%q) matched = %t; want %t", tt.path, ok, tt.matched)
+			continue
+		}
+		if ok {
+			if got := openWithCommandLine(rule, tt.path); got != tt.want {
+				t.Errorf("openWithCommandLine(%q) = %q; want %q", tt.path, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestRecencyBadge(t *testing.T) {
+	now := time.Now()
+	cfg := cloneDefaultConfig().UI
+	at := func(ago time.Duration) *time.Time {
+		ts := now.Add(-ago)
+		return &ts
+	}
+
+	tests := []struct {
+		ts   *time.Time
+		cfg  UIConfig
+		want string
+	}{
+		{at(2 * time.Hour), cfg, "[ 2h](fg:green) "},
+		{at(3 * 24 * time.Hour), cfg, "[ 3d](fg:yellow) "},
+		{at(60 * 24 * time.Hour), cfg, "[2mo](fg:gray) "},
+		{nil, cfg, ""},
+		{at(2 * time.Hour), UIConfig{}, ""},
+		{at(2 * time.Hour), UIConfig{RecencyBadges: true, BadgeFreshHours: 1, BadgeRecentHours: 3}, "[ 2h](fg:yellow) "},
+	}
+
+	for _, tt := range tests {
+		if got := recencyBadge(tt.ts, now, tt.cfg); got != tt.want {
+			t.Errorf("recencyBadge = %q; want %q", got, tt.want)
+		}
+	}
+
+	// Old badges are gray, not the black that vanishes on dark terminals
+	if cells := ui.ParseStyles("[2mo](fg:gray)", ui.StyleClear); cells[0].Style.Fg != colorGray {
+		t.Errorf("fg:gray parsed as color %d; want %d", cells[0].Style.Fg, colorGray)
+	}
+}
+
+func TestLinuxTerminalLaunchers(t *testing.T) {
+	command := `echo "it's $HOME"; exec bash`
+	launchers := func(env map[string]string) map[string][]string {
+		byName := map[string][]string{}
+		for _, l := range linuxTerminalLaunchers(command, func(key string) string { return env[key] }) {
+			byName[l.name] = l.cmd
+			if last := l.cmd[len(l.cmd)-1]; last != command {
+				t.Errorf("%s: command must be passed as one argv element, got %q", l.name, last)
+			}
+		}
+		return byName
+	}
+
+	outside := launchers(map[string]string{})
+	if got := fmt.Sprint(outside["wezterm"][:3]); got != "[wezterm start --]" {
+		t.Errorf("wezterm outside WezTerm = %v; want a new window via start", outside["wezterm"])
+	}
+	if got := fmt.Sprint(outside["foot"][:3]); got != "[foot bash -lc]" {
+		t.Errorf("foot = %v", outside["foot"])
+	}
+
+	inside := launchers(map[string]string{"WEZTERM_PANE": "0"})
+	if got := fmt.Sprint(inside["wezterm"][:4]); got != "[wezterm cli spawn --]" {
+		t.Errorf("wezterm inside WezTerm = %v; want a new tab via cli spawn", inside["wezterm"])
+	}
+}
+
+func TestHelpLinesFallsBackOnBlankHelp(t *testing.T) {
+	for _, blank := range []string{"", "\n\n", "  \t\n  "} {
+		got := helpLines(blank, "mytool")
+		if len(got) != 1 || got[0] != "No help text available for mytool" {
+			t.Errorf("helpLines(%q) = %q; want a fallback notice", blank, got)
+		}
+	}
+
+	got := helpLines("usage: mytool\n\n\nflags", "mytool")
+	if fmt.Sprint(got) != fmt.Sprint([]string{"usage: mytool", "", "flags"}) {
+		t.Errorf("helpLines = %q", got)
+	}
+}
+
+func TestNextMatchModeCycles(t *testing.T) {
+	cfg := HistoryConfig{EnableFuzzing: true, SkipComments: true}
+	var modes []string
+	for range 6 {
+		modes = append(modes, matchModeName(cfg))
+		cfg = nextMatchMode(cfg)
+	}
+
+	want := []string{"Fuzzy", "Words", "Word start", "Prefix", "Shell words", "Fuzzy"}
+	if fmt.Sprint(modes) != fmt.Sprint(want) {
+		t.Errorf("match modes = %v; want %v", modes, want)
+	}
+	if !cfg.SkipComments {
+		t.Error("nextMatchMode must leave unrelated history settings alone")
+	}
+}
+
+func TestGetSuggestionsLimit(t *testing.T) {
+	tree := NewAVLTree()
+	for _, cmd := range []string{"ls", "ls -la", "ls -lh", "pwd"} {
+		tree.Insert(cmd, CommandMetadata{Frequency: 1})
+	}
+	config := cloneDefaultConfig().History
+
+	if got := getSuggestions("ls", tree, config, 2, historyOrder{}); len(got) != 2 {
+		t.Errorf("limit 2 returned %d results: %v", len(got), got)
+	}
+	if got := getSuggestions("ls", tree, config, 0, historyOrder{}); len(got) != 3 {
+		t.Errorf("limit 0 returned %d results; want all 3: %v", len(got), got)
+	}
+}
+
+func TestGetSuggestionsOrder(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+	hoursAgo := func(h int) *time.Time {
+		at := now.Add(-time.Duration(h) * time.Hour)
+		return &at
+	}
+
+	tree := NewAVLTree()
+	tree.Insert("make test", CommandMetadata{Frequency: 9, Timestamp: hoursAgo(48)})
+	tree.Insert("go build", CommandMetadata{Frequency: 3, Timestamp: hoursAgo(1)})
+	tree.Insert("ls -la", CommandMetadata{Frequency: 5, Timestamp: hoursAgo(200)})
+	tree.Insert("cd src", CommandMetadata{Frequency: 1})
+	config := cloneDefaultConfig().History
+
+	tests := []struct {
+		order historyOrder
+		limit int
+		want  []string
+	}{
+		{historyOrder{}, 0, []string{"make test", "ls -la", "go build", "cd src"}},
+		{historyOrder{sortBy: "score", reverse: true}, 0, []string{"cd src", "go build", "ls -la", "make test"}},
+		{historyOrder{sortBy: "recency"}, 0, []string{"go build", "make test", "ls -la", "cd src"}},
+		{historyOrder{sortBy: "frequency"}, 0, []string{"make test", "ls -la", "go build", "cd src"}},
+		{historyOrder{sortBy: "alpha"}, 0, []string{"cd src", "go build", "ls -la", "make test"}},
+		// The limit keeps the most recent commands, then prints them oldest first
+		{historyOrder{sortBy: "recency", reverse: true}, 2, []string{"make test", "go build"}},
+	}
+	for _, tc := range tests {
+		got := getSuggestions("", tree, config, tc.limit, tc.order)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("getSuggestions with %+v, limit %d = %q; want %q", tc.order, tc.limit, got, tc.want)
+		}
+	}
+}
+
+func TestOnTerminationSignalRestoresTerminal(t *testing.T) {
+	cleaned := make(chan bool, 1)
+	exitCode := make(chan int, 1)
+	stop := onTerminationSignal(func() { cleaned <- true }, func(code int) { exitCode <- code })
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case code := <-exitCode:
+		select {
+		case <-cleaned:
+		default:
+			t.Error("cleanup did not run before exit")
+		}
+		if code != 128+int(syscall.SIGHUP) {
+			t.Errorf("exit code = %d; want %d", code, 128+int(syscall.SIGHUP))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("signal was not handled")
+	}
+}
+
+func TestNeighborCommandsNearestFirst(t *testing.T) {
+	var commands []RankedCommand
+	for _, c := range []string{"a", "b", "c", "d", "e", "f"} {
+		commands = append(commands, RankedCommand{Command: c})
+	}
+
+	testCases := []struct {
+		selected int
+		n        int
+		want     []string
+	}{
+		{2, 2, []string{"d", "b", "e", "a"}},
+		{0, 2, []string{"b", "c"}},
+		{5, 1, []string{"e"}},
+		{3, 0, nil},
+	}
+	for _, tc := range testCases {
+		if got := neighborCommands(commands, tc.selected, tc.n); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("neighborCommands(selected=%d, n=%d) = %v; want %v", tc.selected, tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestPrefetchHelpStopsWhenCancelled(t *testing.T) {
+	hc := NewOptimizedHelpCache()
+	CacheHelpPage(hc, "git status", "cached help")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	prefetchHelp(ctx, hc, []string{"git status", "kubectl get pods"})
+
+	if GetHelpPage(hc, "kubectl get pods") != "" {
+		t.Error("a cancelled prefetch should not fetch anything")
+	}
+	if GetHelpPage(hc, "git status") != "cached help" {
+		t.Error("prefetching must not replace cached pages")
+	}
+}
+
+func TestOutputCommandStripsEnvPrefix(t *testing.T) {
+	state := &historySearchState{}
+	if got := state.outputCommand("FOO=bar go build"); got != "FOO=bar go build" {
+		t.Errorf("verbatim copy = %q; want the full command", got)
+	}
+
+	state.matching.CopyStripEnvPrefix = true
+	if got := state.outputCommand("FOO=bar go build"); got != "go build" {
+		t.Errorf("stripped copy = %q; want %q", got, "go build")
+	}
+	if got := state.outputCommand("go build"); got != "go build" {
+		t.Errorf("command without prefix = %q; want it unchanged", got)
+	}
+}
+
+func TestEmptyHistoryLinesQuoteReadError(t *testing.T) {
+	err := &historyNotFoundError{"zsh history file not found"}
+	lines := emptyHistoryLines(err)
+	if len(lines) < 3 || lines[2] != err.Error() {
+		t.Errorf("emptyHistoryLines = %q; want the read error after the heading", lines)
+	}
+	if got := emptyHistoryLines(nil); got[2] == err.Error() {
+		t.Errorf("emptyHistoryLines(nil) = %q; want no error line", got)
+	}
+}
+
+func TestHelpCopyText(t *testing.T) {
+	helpTxt := "\x1b[1mNAME\x1b[0m\nN\bNA\bAM\bME\bE ls - list\n# tldr **markdown**"
+
+	if got := helpCopyText(helpTxt, "raw"); got != helpTxt {
+		t.Errorf("raw copy = %q; want the help unchanged", got)
+	}
+	want := "NAME\nNAME ls - list\n# tldr **markdown**"
+	if got := helpCopyText(helpTxt, "plain"); got != want {
+		t.Errorf("plain copy = %q; want %q", got, want)
+	}
+}
+
+func TestNewBorderStyles(t *testing.T) {
+	defaults := newBorderStyles(cloneDefaultConfig().UI)
+	if defaults.focused.Fg != ui.ColorCyan || defaults.blurred.Fg != ui.ColorWhite {
+		t.Errorf("default borders = %+v", defaults)
+	}
+
+	contrast := newBorderStyles(UIConfig{BorderTheme: "high-contrast"})
+	if contrast.focused == contrast.blurred || contrast.focused.Modifier != ui.ModifierBold {
+		t.Errorf("high-contrast borders = %+v", contrast)
+	}
+
+	custom := newBorderStyles(UIConfig{BorderTheme: "high-contrast", FocusedBorderColor: "Yellow", BlurredBorderColor: "240"})
+	if custom.focused.Fg != ui.ColorYellow || custom.blurred.Fg != ui.Color(240) {
+		t.Errorf("overridden borders = %+v", custom)
+	}
+
+	invalid := newBorderStyles(UIConfig{BorderTheme: "neon", FocusedBorderColor: "256"})
+	if invalid != defaults {
+		t.Errorf("invalid settings gave %+v; want the defaults", invalid)
+	}
+}
+
+func TestToggleBorders(t *testing.T) {
+	styles := newBorderStyles(UIConfig{BorderTheme: "high-contrast"})
+	list, pane := widgets.NewList(), widgets.NewList()
+	list.BorderStyle, pane.BorderStyle = styles.focused, styles.blurred
+
+	toggleBorders(list, pane, styles)
+	if list.BorderStyle != styles.blurred || pane.BorderStyle != styles.focused {
+		t.Error("focus did not move to the second panel")
+	}
+	toggleBorders(list, pane, styles)
+	if list.BorderStyle != styles.focused || pane.BorderStyle != styles.blurred {
+		t.Error("focus did not move back to the first panel")
+	}
+}
+
+func TestStreamingHelpLines(t *testing.T) {
+	if got := streamingHelpLines("", "aws s3"); len(got) != 1 || !strings.Contains(got[0], "Loading help for aws s3") {
+		t.Errorf("streamingHelpLines with no output = %q; want only the loading banner", got)
+	}
+
+	got := streamingHelpLines("\x1b[1mN\bNA\bAM\bME\bE\x1b[0m\n  aws s3\n", "aws s3")
+	want := []string{"[⏳ Loading help for aws s3 ...](fg:yellow)", "", "NAME", "  aws s3", ""}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("streamingHelpLines = %q; want %q", got, want)
+	}
+}
+
+func TestDisplayScope(t *testing.T) {
+	tests := []struct {
+		scope, home, want string
+	}{
+		{"/home/ana/src/api", "/home/ana", "~/src/api"},
+		{"/home/ana", "/home/ana/", "~"},
+		{"/home/anabel/src", "/home/ana", "/home/anabel/src"},
+		{"/srv/app", "", "/srv/app"},
+		{"/srv/" + strings.Repeat("x", 60), "", "..." + strings.Repeat("x", maxScopeDisplayLen-3)},
+	}
+	for _, tt := range tests {
+		if got := displayScope(tt.scope, tt.home); got != tt.want {
+			t.Errorf("displayScope(%q, %q) = %q; want %q", tt.scope, tt.home, got, tt.want)
+		}
+	}
+}
+
+func TestCommandKeyActions(t *testing.T) {
+	tests := []struct {
+		enterAction string
+		want        [3]string
+	}{
+		{"copy", [3]string{"copy", "send", "run"}},
+		{"send", [3]string{"send", "copy", "run"}},
+		{"run", [3]string{"run", "copy", "send"}},
+		{"", [3]string{"copy", "send", "run"}},
+		{"execute", [3]string{"copy", "send", "run"}},
+	}
+	for _, tt := range tests {
+		if got := commandKeyActions(tt.enterAction); got != tt.want {
+			t.Errorf("commandKeyActions(%q) = %v; want %v", tt.enterAction, got, tt.want)
+		}
+	}
+
+	footer := createKeyboardShortcutsWidget(commandKeyActions("run")).Text
+	for _, shortcut := range []string{"[<enter>](fg:green) Run here", "[<ctrl+e>](fg:green) Copy command(s)", "[<ctrl+o>](fg:green) Send to terminal"} {
+		if !strings.Contains(footer, shortcut) {
+			t.Errorf("footer is missing %q:\n%s", shortcut, footer)
+		}
+	}
+}
+
+func TestMultiByteInputAccumulates(t *testing.T) {
+	events := []ui.Event{
+		{Type: ui.KeyboardEvent, ID: "c"},
+		{Type: ui.KeyboardEvent, ID: "a"},
+		{Type: ui.KeyboardEvent, ID: "f"},
+		{Type: ui.KeyboardEvent, ID: "é"},
+		{Type: ui.KeyboardEvent, ID: "<Up>"},
+		{Type: ui.KeyboardEvent, ID: "日"},
+		{Type: ui.KeyboardEvent, ID: "☕"},
+		{Type: ui.MouseEvent, ID: "x"},
+	}
+	var buffer string
+	for _, e := range events {
+		if text, ok := typedText(e); ok {
+			buffer += text
+		}
+	}
+	if buffer != "café日☕" {
+		t.Fatalf("typed buffer = %q; want %q", buffer, "café日☕")
+	}
+
+	var erased []string
+	for buffer != "" {
+		buffer = dropLastRune(buffer)
+		erased = append(erased, buffer)
+	}
+	want := []string{"café日", "café", "caf", "ca", "c", ""}
+	if fmt.Sprint(erased) != fmt.Sprint(want) {
+		t.Errorf("backspacing gave %q; want %q", erased, want)
+	}
+	if dropLastRune("") != "" {
+		t.Error("dropLastRune on an empty buffer must stay empty")
+	}
+}
+
+func TestSearchMultiByteCommands(t *testing.T) {
+	tree := NewAVLTree()
+	for _, cmd := range []string{"git commit -m 'café ☕'", "echo 日本語のテスト", "ls -la"} {
+		tree.Insert(cmd, CommandMetadata{Frequency: 1})
+	}
+	config := cloneDefaultConfig().History
+
+	for query, want := range map[string]string{"café": "git commit -m 'café ☕'", "☕": "git commit -m 'café ☕'", "日本": "echo 日本語のテスト"} {
+		got := getSuggestions(query, tree, config, 0, historyOrder{})
+		if len(got) != 1 || got[0] != want {
+			t.Errorf("search for %q = %v; want [%q]", query, got, want)
+		}
+	}
+}
+
+func TestFormatFileForDisplayKeepsWideCharacters(t *testing.T) {
+	dir := "/" + strings.Repeat("写真", 30) + "/🎉party.txt"
+	got := formatFileForDisplay(RankedFile{Path: dir})
+
+	display := strings.TrimPrefix(got, "📄 ")
+	if !utf8.ValidString(display) {
+		t.Fatalf("display path %q is not valid UTF-8", display)
+	}
+	if width := runewidth.StringWidth(display); width != maxPathDisplayLen {
+		t.Errorf("display path is %d cells wide; want %d", width, maxPathDisplayLen)
+	}
+	if !strings.HasPrefix(display, "...") || !strings.HasSuffix(display, "/🎉party.txt") {
+		t.Errorf("display path = %q; want the head replaced by ... and the file name kept", display)
+	}
+}
+
+func TestFullPathFooter(t *testing.T) {
+	long := "/home/ana/projects/recaller/internal/very/deeply/nested/config/settings.yaml"
+	footer := widgets.NewParagraph()
+	state := &filesystemSearchState{
+		currentFiles: []RankedFile{{Path: "/etc/settings.yaml"}, {Path: long}},
+		footer:       footer,
+	}
+
+	state.refreshFooter()
+	if footer.Text != filesystemShortcutsText {
+		t.Errorf("footer = %q; want the shortcuts while full paths are off", footer.Text)
+	}
+
+	state.showFullPath = true
+	state.selectedIndex = 1
+	state.refreshFooter()
+	if !strings.Contains(footer.Text, long) {
+		t.Errorf("footer = %q; want the full path %s", footer.Text, long)
+	}
+
+	// Moving the selection follows along
+	state.selectedIndex = 0
+	state.updateMetadataDisplay(widgets.NewList())
+	if !strings.HasPrefix(footer.Text, "📍 /etc/settings.yaml ") {
+		t.Errorf("footer after moving up = %q; want the first path", footer.Text)
+	}
+
+	state.currentFiles = nil
+	state.refreshFooter()
+	if footer.Text != filesystemShortcutsText {
+		t.Errorf("footer with no results = %q; want the shortcuts", footer.Text)
+	}
+}
@@ -15,15 +15,26 @@
 package main
 
 import (
+	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type CommandMetadata struct {
 	Command   string
-	Timestamp *time.Time // Unix timestamp for recency (updated on each use)
-	Frequency int        // Incremented on each command execution
+	Timestamp *time.Time     // Unix timestamp for recency (updated on each use)
+	Frequency int            // Incremented on each command execution
+	Related   map[string]int // Commands run immediately before or after this one, with counts
+	// AgedFrequency weights each use by its age when history.frequency_decay_days
+	// is set, so old habits count for less; without it plain counts are used
+	AgedFrequency float64
+	// DailyCounts holds uses per day, oldest first, ending today. Only filled
+	// when history.usage_sparkline is enabled.
+	DailyCounts []int
 }
 
 type RankedCommand struct {
@@ -249,6 +260,20 @@ func searchNode(node *AVLNode, key string) (interface{}, bool) {
 	}
 }
 
+// findNode returns the node holding key, or nil, so its metadata can be
+// updated in place
+func (tree *AVLTree) findNode(key string) *AVLNode {
+	node := tree.Root
+	for node != nil && node.Key != key {
+		if key < node.Key {
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+	return node
+}
+
 // rangeSearch traverses the subtree rooted at 'node' and appends to 'results'
 // every node whose Key satisfies low <= Key < high, in ascending (lexicographical) order.
 func rangeSearch(node *AVLNode, low, high string, results *[]*AVLNode) {
@@ -310,19 +335,100 @@ func (tree *AVLTree) SearchPrefixMostRecent(prefix string) []*AVLNode {
 	return matches
 }
 
-func calculateScore(metadata CommandMetadata) float64 {
-	frequencyScore := float64(metadata.Frequency)
+// TopRelatedCommands returns up to n commands most often run right before or
+// after the given command, most frequent first.
+func TopRelatedCommands(metadata CommandMetadata, n int) []string {
+	related := make([]string, 0, len(metadata.Related))
+	for cmd := range metadata.Related {
+		related = append(related, cmd)
+	}
 
-	var recencyScore float64
-	if metadata.Timestamp != nil && !metadata.Timestamp.IsZero() {
-		timeDelta := time.Since(*metadata.Timestamp).Hours()
-		if timeDelta < 0 {
-			timeDelta = 0
+	sort.Slice(related, func(i, j int) bool {
+		ci, cj := metadata.Related[related[i]], metadata.Related[related[j]]
+		if ci != cj {
+			return ci > cj
 		}
-		recencyScore = 1 / (timeDelta + 1) // Add 1 to avoid division by zero
+		return related[i] < related[j]
+	})
+
+	if len(related) > n {
+		related = related[:n]
 	}
+	return related
+}
+
+// timeNow is the clock recency scoring reads; tests replace it to freeze time
+var timeNow = time.Now
 
-	return (0.6 * frequencyScore) + (0.4 * recencyScore)
+// scoring holds the history settings commands are ranked with
+type scoring struct {
+	formula *ScoreFormula // history.score_formula; nil for the built-in weighting
+	aged    bool          // Rank by AgedFrequency, as history.frequency_decay_days is set
+}
+
+// historyScoring reads the ranking settings from config
+func historyScoring(config HistoryConfig) scoring {
+	return scoring{
+		formula: compiledScoreFormula(config.ScoreFormula),
+		aged:    config.FrequencyDecayDays > 0,
+	}
+}
+
+// calculateScore is the built-in ranking. matchOffset is where the query
+// matched in the command, in characters: matches near the start get a bonus
+// that decides between commands with similar history.
+func calculateScore(metadata CommandMetadata, aged bool, now time.Time, matchOffset int) float64 {
+	return (0.6 * rankingFrequency(metadata, aged)) + (0.4 * recencyScore(metadata, now)) + (0.5 * positionScore(matchOffset))
+}
+
+// positionScore is 1 / (matchOffset + 1), so 1 for a match at the start of
+// the command
+func positionScore(matchOffset int) float64 {
+	if matchOffset < 0 {
+		matchOffset = 0
+	}
+	return 1 / float64(matchOffset+1)
+}
+
+// rankingFrequency is the frequency used for scoring: the aged frequency when
+// frequency decay is on, even if it has decayed to 0, the raw count otherwise
+func rankingFrequency(metadata CommandMetadata, aged bool) float64 {
+	if aged {
+		return metadata.AgedFrequency
+	}
+	return float64(metadata.Frequency)
+}
+
+// recencyScore is 1 / (hours since last run + 1) as of now, or 0 without a timestamp
+func recencyScore(metadata CommandMetadata, now time.Time) float64 {
+	if metadata.Timestamp == nil || metadata.Timestamp.IsZero() {
+		return 0
+	}
+	timeDelta := now.Sub(*metadata.Timestamp).Hours()
+	if timeDelta < 0 {
+		timeDelta = 0
+	}
+	return 1 / (timeDelta + 1) // Add 1 to avoid division by zero
+}
+
+// scoreCommand ranks a matching command with the user's score formula, or
+// the built-in weighting when there is none. now is shared by every command
+// ranked together, so commands with the same history score exactly the same.
+func scoreCommand(command, query string, metadata CommandMetadata, scores scoring, now time.Time, matchOffset int) float64 {
+	if scores.formula == nil {
+		return calculateScore(metadata, scores.aged, now, matchOffset)
+	}
+
+	vars := scoreVars{
+		frequency: rankingFrequency(metadata, scores.aged),
+		recency:   recencyScore(metadata, now),
+		position:  positionScore(matchOffset),
+		length:    float64(utf8.RuneCountInString(command)),
+	}
+	if q := strings.TrimSpace(query); q != "" && command == q {
+		vars.exact = 1
+	}
+	return scores.formula.Eval(vars)
 }
 
 // fuzzySearch performs in-order traversal and finds commands containing the query as substring
@@ -350,6 +456,10 @@ func (tree *AVLTree) SearchFuzzy(query string) []*AVLNode {
 }
 
 func SearchWithRanking(tree *AVLTree, query string, enableFuzzing bool) []RankedCommand {
+	return searchWithRanking(tree, query, enableFuzzing, scoring{})
+}
+
+func searchWithRanking(tree *AVLTree, query string, enableFuzzing bool, scores scoring) []RankedCommand {
 	var nodes []*AVLNode
 
 	if enableFuzzing {
@@ -358,17 +468,428 @@ func SearchWithRanking(tree *AVLTree, query string, enableFuzzing bool) []Ranked
 		nodes = tree.SearchPrefix(query)
 	}
 
+	var offset func(string) int
+	if enableFuzzing {
+		offset = func(command string) int { return fuzzyMatchOffset(command, query) }
+	}
+	return rankNodes(nodes, query, scores, offset)
+}
+
+// fuzzyMatchOffset returns where query first appears in command, ignoring
+// case, in characters
+func fuzzyMatchOffset(command, query string) int {
+	lowerCommand := strings.ToLower(command)
+	i := strings.Index(lowerCommand, strings.ToLower(query))
+	if i <= 0 {
+		return 0
+	}
+	return utf8.RuneCountInString(lowerCommand[:i])
+}
+
+// History match modes, as named by history.match_mode
+const (
+	matchModeSubstring  = "substring"   // The query anywhere in the command
+	matchModeWord       = "word"        // Each query word starts a command word, in any order
+	matchModeWordStart  = "word_start"  // The query at the start of a command word
+	matchModePrefix     = "prefix"      // Commands starting with the query
+	matchModeShellWords = "shell_words" // Word by word after shell-style parsing
+)
+
+// matchModes lists the match modes in the order <C-f> cycles through them
+var matchModes = []string{matchModeSubstring, matchModeWord, matchModeWordStart, matchModePrefix, matchModeShellWords}
+
+// activeMatchMode returns match_mode when it names a known mode, otherwise
+// the mode the shell_word_match, word_boundary_match and enable_fuzzing
+// settings select
+func (c HistoryConfig) activeMatchMode() string {
+	if slices.Contains(matchModes, c.MatchMode) {
+		return c.MatchMode
+	}
+	switch {
+	case c.ShellWordMatch:
+		return matchModeShellWords
+	case c.WordBoundaryMatch:
+		return matchModeWordStart
+	case c.EnableFuzzing:
+		return matchModeSubstring
+	default:
+		return matchModePrefix
+	}
+}
+
+// checkMatchMode reports a match_mode that names no known mode, which
+// activeMatchMode would silently ignore
+func (c HistoryConfig) checkMatchMode() error {
+	if c.MatchMode != "" && !slices.Contains(matchModes, c.MatchMode) {
+		return fmt.Errorf("history.match_mode %q is not one of %s", c.MatchMode, strings.Join(matchModes, ", "))
+	}
+	return nil
+}
+
+// SearchHistory searches the tree using the matching mode selected in config.
+// Commands of the boost_commands tools are ranked higher. With typo_tolerance
+// on, near misses follow the matches.
+func SearchHistory(tree *AVLTree, query string, config HistoryConfig) []RankedCommand {
+	scores := historyScoring(config)
+	var results []RankedCommand
+	switch config.activeMatchMode() {
+	case matchModeShellWords:
+		results = searchShellWords(tree, query, scores)
+	case matchModeWordStart:
+		results = searchWordBoundary(tree, query, scores)
+	case matchModeWord:
+		results = searchWords(tree, query, scores)
+	default:
+		results = searchWithRanking(tree, query, config.activeMatchMode() == matchModeSubstring, scores)
+	}
+	boostCommands(results, config.BoostCommands)
+	if config.TypoTolerance {
+		typos := searchTypos(tree, query, scores, results)
+		boostCommands(typos, config.BoostCommands)
+		results = append(results, typos...)
+	}
+	return results
+}
+
+// boostFactor multiplies the scores of boost_commands commands
+const boostFactor = 2
+
+// boostCommands multiplies the scores of the ranked commands whose base
+// command, after any VAR=value prefix, is in boosted by boostFactor, and
+// sorts them again. A negative score (possible with a score formula) is
+// divided instead, so boosting always raises it.
+func boostCommands(ranked []RankedCommand, boosted []string) {
+	if len(boosted) == 0 {
+		return
+	}
+	for i := range ranked {
+		_, command := splitEnvPrefix(ranked[i].Command)
+		if !isExcludedCommand(command, boosted) {
+			continue
+		}
+		if ranked[i].Score < 0 {
+			ranked[i].Score /= boostFactor
+		} else {
+			ranked[i].Score *= boostFactor
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return rankedCommandLess(ranked[i], ranked[j])
+	})
+}
+
+// searchTypos finds the commands not already in matched whose words match
+// the query's words, in order, within typo distance: "gti status" finds
+// `git status`. The last query word may be a prefix, as it is typed.
+func searchTypos(tree *AVLTree, query string, scores scoring, matched []RankedCommand) []RankedCommand {
+	queryWords := typoWords(query)
+	tolerant := false
+	for _, word := range queryWords {
+		tolerant = tolerant || maxTypoEdits(word) > 0
+	}
+	if !tolerant {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(matched))
+	for _, ranked := range matched {
+		seen[ranked.Command] = true
+	}
+
+	var nodes []*AVLNode
+	collectNodes(tree.Root, func(node *AVLNode) bool {
+		return !seen[node.Key] && matchTypoWords(typoWords(node.Key), queryWords) >= 0
+	}, &nodes)
+
+	return rankNodes(nodes, query, scores, func(command string) int {
+		words := typoWords(command)
+		offset := 0
+		for _, word := range words[:matchTypoWords(words, queryWords)] {
+			offset += len(word) + 1
+		}
+		return offset
+	})
+}
+
+// typoWords lowercases text and splits it on whitespace into runes
+func typoWords(text string) [][]rune {
+	var words [][]rune
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		words = append(words, []rune(field))
+	}
+	return words
+}
+
+// maxTypoEdits is how many edits a query word may be away from the word it
+// matches: none below three characters, where everything is a near miss, and
+// two from eight characters on
+func maxTypoEdits(word []rune) int {
+	switch {
+	case len(word) < 3:
+		return 0
+	case len(word) < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchTypoWords returns the index in words where query appears as a
+// consecutive run of words within typo distance, with the final query word
+// also matched against the start of its word, or -1 when it does not appear
+func matchTypoWords(words, query [][]rune) int {
+	last := len(query) - 1
+	for start := 0; start+len(query) <= len(words); start++ {
+		matched := true
+		for i, q := range query {
+			w := words[start+i]
+			limit := maxTypoEdits(q)
+			matched = typoDistance(q, w, limit) <= limit
+			if !matched && i == last && len(w) > len(q) {
+				matched = typoDistance(q, w[:len(q)], limit) <= limit
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			return start
+		}
+	}
+	return -1
+}
+
+// typoDistance is the Damerau-Levenshtein distance between a and b, counting
+// insertions, deletions, substitutions and swaps of adjacent characters
+// (optimal string alignment). It gives up once the distance exceeds limit and
+// returns limit+1.
+func typoDistance(a, b []rune, limit int) int {
+	if len(a)-len(b) > limit || len(b)-len(a) > limit {
+		return limit + 1
+	}
+
+	// Rolling rows of the distance matrix: two rows back, previous and current
+	before, previous, current := make([]int, len(b)+1), make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		rowMin := i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], before[j-2]+1)
+			}
+			rowMin = min(rowMin, current[j])
+		}
+		// No later row can get below this one's minimum
+		if rowMin > limit {
+			return limit + 1
+		}
+		before, previous, current = previous, current, before
+	}
+	return min(previous[len(b)], limit+1)
+}
+
+// SearchWordBoundary matches the query, ignoring case, only where it starts
+// a whitespace-delimited word of the command: "test" matches `go test` and
+// `test.sh` but not `latest`.
+func SearchWordBoundary(tree *AVLTree, query string) []RankedCommand {
+	return searchWordBoundary(tree, query, scoring{})
+}
+
+func searchWordBoundary(tree *AVLTree, query string, scores scoring) []RankedCommand {
+	var nodes []*AVLNode
+	collectNodes(tree.Root, func(node *AVLNode) bool {
+		return wordBoundaryOffset(node.Key, query) >= 0
+	}, &nodes)
+
+	return rankNodes(nodes, query, scores, func(command string) int {
+		return wordBoundaryOffset(command, query)
+	})
+}
+
+// wordBoundaryOffset returns where query first matches command at the start
+// of a word, ignoring case, in characters, or -1 when it does not
+func wordBoundaryOffset(command, query string) int {
+	lowerCommand, lowerQuery := strings.ToLower(command), strings.ToLower(query)
+	offset := 0
+	atWordStart := true
+	for i, r := range lowerCommand {
+		if atWordStart && strings.HasPrefix(lowerCommand[i:], lowerQuery) {
+			return offset
+		}
+		atWordStart = unicode.IsSpace(r)
+		offset++
+	}
+	if lowerQuery == "" {
+		return 0
+	}
+	return -1
+}
+
+// SearchWords matches commands in which every word of the query starts some
+// whitespace-delimited word, ignoring case and order, so "go build" finds
+// `GOOS=linux go build -o app` and "build go" finds it too.
+func SearchWords(tree *AVLTree, query string) []RankedCommand {
+	return searchWords(tree, query, scoring{})
+}
+
+func searchWords(tree *AVLTree, query string, scores scoring) []RankedCommand {
+	queryWords := strings.Fields(strings.ToLower(query))
+	if len(queryWords) == 0 {
+		return searchWithRanking(tree, "", false, scores)
+	}
+
+	var nodes []*AVLNode
+	collectNodes(tree.Root, func(node *AVLNode) bool {
+		return wordsOffset(node.Key, queryWords) >= 0
+	}, &nodes)
+
+	return rankNodes(nodes, query, scores, func(command string) int {
+		return wordsOffset(command, queryWords)
+	})
+}
+
+// wordsOffset returns where the first of the lowercase queryWords starts a
+// word of command, in characters, or -1 when any query word starts none
+func wordsOffset(command string, queryWords []string) int {
+	type word struct {
+		text   string
+		offset int
+	}
+	var words []word
+	start, offset := -1, 0
+	lowerCommand := strings.ToLower(command)
+	for i, r := range lowerCommand {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			words = append(words, word{lowerCommand[start:i], offset})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+			offset = utf8.RuneCountInString(lowerCommand[:i])
+		}
+	}
+	if start >= 0 {
+		words = append(words, word{lowerCommand[start:], offset})
+	}
+
+	first := -1
+	for n, query := range queryWords {
+		i := slices.IndexFunc(words, func(w word) bool { return strings.HasPrefix(w.text, query) })
+		if i < 0 {
+			return -1
+		}
+		if n == 0 {
+			first = words[i].offset
+		}
+	}
+	return first
+}
+
+// SearchShellWords matches the query against commands word by word after
+// shell-style tokenizing both, so quoting differences such as
+// git commit -m 'fix bug' vs git commit -m "fix bug" do not prevent a match.
+// The query's words must appear consecutively in the command; the last one may
+// be a prefix so results update while typing.
+func SearchShellWords(tree *AVLTree, query string) []RankedCommand {
+	return searchShellWords(tree, query, scoring{})
+}
+
+func searchShellWords(tree *AVLTree, query string, scores scoring) []RankedCommand {
+	queryWords := shellWords(query)
+	if len(queryWords) == 0 {
+		return searchWithRanking(tree, "", false, scores)
+	}
+
+	var nodes []*AVLNode
+	collectNodes(tree.Root, func(node *AVLNode) bool {
+		return matchShellWords(shellWords(node.Key), queryWords) >= 0
+	}, &nodes)
+
+	return rankNodes(nodes, query, scores, func(command string) int {
+		words := shellWords(command)
+		offset := 0
+		for _, word := range words[:matchShellWords(words, queryWords)] {
+			offset += utf8.RuneCountInString(word) + 1
+		}
+		return offset
+	})
+}
+
+// shellWords tokenizes a command like the shell would. Input that does not
+// parse (e.g. an unterminated quote while typing) is split on whitespace with
+// quote characters removed.
+func shellWords(command string) []string {
+	if words, err := splitCommand(command); err == nil {
+		return words
+	}
+	return strings.Fields(strings.NewReplacer(`"`, "", "'", "").Replace(command))
+}
+
+// matchShellWords returns the index in words where query appears as a
+// consecutive run of words, with the final query word matched as a prefix,
+// or -1 when it does not appear
+func matchShellWords(words, query []string) int {
+	last := len(query) - 1
+	for start := 0; start+len(query) <= len(words); start++ {
+		matched := true
+		for i, q := range query {
+			w := words[start+i]
+			if i == last {
+				matched = strings.HasPrefix(w, q)
+			} else {
+				matched = w == q
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			return start
+		}
+	}
+	return -1
+}
+
+// collectNodes appends every node accepted by match in key order
+func collectNodes(node *AVLNode, match func(*AVLNode) bool, results *[]*AVLNode) {
+	if node == nil {
+		return
+	}
+	collectNodes(node.Left, match, results)
+	if match(node) {
+		*results = append(*results, node)
+	}
+	collectNodes(node.Right, match, results)
+}
+
+// rankNodes scores matching nodes and sorts them best first. matchOffset
+// gives where the query matched in a command; nil means at the start, as for
+// prefix matches.
+func rankNodes(nodes []*AVLNode, query string, scores scoring, matchOffset func(string) int) []RankedCommand {
 	// Pre-allocate slice with estimated capacity to reduce allocations
 	rankedCommands := make([]RankedCommand, 0, len(nodes))
+	now := timeNow()
 
 	// Traverse the tree to find matching commands
 	for _, node := range nodes {
 		command := node.Key
 		metadata := node.Value
 
+		offset := 0
+		if matchOffset != nil {
+			offset = matchOffset(command)
+		}
 		rankedCommand := RankedCommand{
 			Command:  command,
-			Score:    calculateScore(metadata),
+			Score:    scoreCommand(command, query, metadata, scores, now, offset),
 			Metadata: metadata, // Reuse existing metadata to avoid copying
 		}
 
@@ -377,8 +898,39 @@ func SearchWithRanking(tree *AVLTree, query string, enableFuzzing bool) []Ranked
 
 	// Sort the commands based on their scores (Descending order for highest score first)
 	sort.SliceStable(rankedCommands, func(i, j int) bool {
-		return rankedCommands[i].Score > rankedCommands[j].Score
+		return rankedCommandLess(rankedCommands[i], rankedCommands[j])
 	})
 
 	return rankedCommands
 }
+
+// rankedCommandLess orders commands by descending score. Equal scores go to
+// the most recently used command, then alphabetically, so results never
+// reorder between runs.
+func rankedCommandLess(a, b RankedCommand) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if c := compareRecency(a.Metadata.Timestamp, b.Metadata.Timestamp); c != 0 {
+		return c > 0
+	}
+	return a.Command < b.Command
+}
+
+// compareRecency returns 1 when a is more recent than b, -1 when b is, and 0
+// when they are equal. A missing timestamp is older than any other.
+func compareRecency(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case b == nil:
+		return 1
+	case a == nil:
+		return -1
+	case a.After(*b):
+		return 1
+	case b.After(*a):
+		return -1
+	}
+	return 0
+}
@@ -15,7 +15,12 @@
 package main
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 )
 
 type AVLTestCase struct {
@@ -103,3 +108,343 @@ func inOrderTraversal(node *AVLNode, result *[]string) {
 	*result = append(*result, node.Key)
 	inOrderTraversal(node.Right, result)
 }
+
+func TestSearchShellWords(t *testing.T) {
+	tree := NewAVLTree()
+	for _, cmd := range []string{
+		`git commit -m "fix bug"`,
+		`git commit -m fix`,
+		`grep 'hello world' notes.txt`,
+		`ls -la`,
+	} {
+		tree.Insert(cmd, CommandMetadata{})
+	}
+
+	testCases := []struct {
+		query    string
+		expected []string
+	}{
+		{`git commit -m 'fix bug'`, []string{`git commit -m "fix bug"`}},
+		{`commit -m fi`, []string{`git commit -m "fix bug"`, `git commit -m fix`}},
+		{`"hello world"`, []string{`grep 'hello world' notes.txt`}},
+		{`grep 'hello`, []string{`grep 'hello world' notes.txt`}},
+		{`hello notes.txt`, []string{}},
+		{`-la`, []string{`ls -la`}},
+	}
+
+	for _, tc := range testCases {
+		got := rankedCommandNames(SearchShellWords(tree, tc.query))
+		if fmt.Sprint(got) != fmt.Sprint(tc.expected) {
+			t.Errorf("SearchShellWords(%q) = %q; want %q", tc.query, got, tc.expected)
+		}
+	}
+
+	if got := SearchShellWords(tree, "  "); len(got) != 4 {
+		t.Errorf("SearchShellWords with empty query returned %d commands; want 4", len(got))
+	}
+}
+
+func TestRankingBreaksTiesDeterministically(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	var history []HistoryEntry
+	for _, command := range []string{"git status", "git stash", "git switch main", "git show", "git stage ."} {
+		history = append(history, HistoryEntry{Command: command, Timestamp: &now})
+	}
+	history = append(history, HistoryEntry{Command: "git add .", Timestamp: &earlier})
+
+	var first []string
+	for run := 0; run < 20; run++ {
+		tree := NewAVLTree()
+		populateTreeFromHistory(tree, history, HistoryConfig{})
+		var order []string
+		for _, ranked := range SearchHistory(tree, "git", HistoryConfig{EnableFuzzing: true}) {
+			order = append(order, ranked.Command)
+		}
+		if run == 0 {
+			first = order
+			continue
+		}
+		if fmt.Sprint(order) != fmt.Sprint(first) {
+			t.Fatalf("run %d ordered %v; first run ordered %v", run, order, first)
+		}
+	}
+
+	// Equal scores fall back to alphabetical order
+	want := []string{"git show", "git stage .", "git stash", "git status", "git switch main"}
+	if fmt.Sprint(first[:5]) != fmt.Sprint(want) {
+		t.Errorf("tied commands ordered %v; want %v", first[:5], want)
+	}
+
+	// Recency decides between equal scores before the name does
+	a := RankedCommand{Command: "a", Score: 1, Metadata: CommandMetadata{Timestamp: &earlier}}
+	b := RankedCommand{Command: "b", Score: 1, Metadata: CommandMetadata{Timestamp: &now}}
+	if !rankedCommandLess(b, a) || rankedCommandLess(a, b) {
+		t.Error("the more recent of two equally scored commands should rank first")
+	}
+}
+
+// freezeTime makes timeNow return at for the rest of the test
+func freezeTime(t *testing.T, at time.Time) {
+	t.Helper()
+	timeNow = func() time.Time { return at }
+	t.Cleanup(func() { timeNow = time.Now })
+}
+
+func TestRecencyScoringWithFrozenClock(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	justNow := now
+	hourAgo := now.Add(-time.Hour)
+	dayAgo := now.Add(-23 * time.Hour)
+	tree := NewAVLTree()
+	tree.Insert("make test", CommandMetadata{Frequency: 1, Timestamp: &hourAgo})
+	tree.Insert("make build", CommandMetadata{Frequency: 1, Timestamp: &justNow})
+	tree.Insert("make lint", CommandMetadata{Frequency: 2, Timestamp: &dayAgo})
+	tree.Insert("make docs", CommandMetadata{Frequency: 1})
+
+	// 0.6*frequency + 0.4/(hours since last run + 1) + 0.5 for matching at
+	// the start
+	want := []RankedCommand{
+		{Command: "make lint", Score: 0.6*2 + 0.4/24 + 0.5},
+		{Command: "make build", Score: 0.6 + 0.4 + 0.5},
+		{Command: "make test", Score: 0.6 + 0.4/2 + 0.5},
+		{Command: "make docs", Score: 0.6 + 0.5},
+	}
+	got := SearchHistory(tree, "make", HistoryConfig{EnableFuzzing: true})
+	if len(got) != len(want) {
+		t.Fatalf("SearchHistory returned %d commands; want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Command != want[i].Command || math.Abs(got[i].Score-want[i].Score) > 1e-12 {
+			t.Errorf("rank %d = %s (%.6f); want %s (%.6f)", i, got[i].Command, got[i].Score, want[i].Command, want[i].Score)
+		}
+	}
+
+	// A week later recency has decayed and frequency alone decides
+	freezeTime(t, now.Add(7*24*time.Hour))
+	got = SearchHistory(tree, "make", HistoryConfig{EnableFuzzing: true})
+	if got[0].Command != "make lint" || got[1].Command != "make build" {
+		t.Errorf("a week later ranked %s, %s first", got[0].Command, got[1].Command)
+	}
+	if score := got[1].Score; math.Abs(score-(0.6+0.4/(7*24+1)+0.5)) > 1e-12 {
+		t.Errorf("make build scored %.6f a week later", score)
+	}
+}
+
+func TestEarlierMatchesRankHigher(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	tree := NewAVLTree()
+	tree.Insert("some-wrapper git log --foo", CommandMetadata{Frequency: 3, Timestamp: &now})
+	tree.Insert("git log --oneline", CommandMetadata{Frequency: 3, Timestamp: &now})
+	tree.Insert("sudo git log", CommandMetadata{Frequency: 3, Timestamp: &now})
+
+	for _, config := range []HistoryConfig{{EnableFuzzing: true}, {ShellWordMatch: true}} {
+		got := SearchHistory(tree, "git log", config)
+		if len(got) != 3 {
+			t.Fatalf("SearchHistory(%+v) returned %d commands; want 3", config, len(got))
+		}
+		order := []string{got[0].Command, got[1].Command, got[2].Command}
+		want := []string{"git log --oneline", "sudo git log", "some-wrapper git log --foo"}
+		if fmt.Sprint(order) != fmt.Sprint(want) {
+			t.Errorf("SearchHistory(%+v) ranked %q; want %q", config, order, want)
+		}
+	}
+
+	// Frequency still outweighs a late match
+	tree = NewAVLTree()
+	tree.Insert("some-wrapper git log --foo", CommandMetadata{Frequency: 5, Timestamp: &now})
+	tree.Insert("git log --oneline", CommandMetadata{Frequency: 3, Timestamp: &now})
+	if got := SearchHistory(tree, "git log", HistoryConfig{EnableFuzzing: true}); got[0].Command != "some-wrapper git log --foo" {
+		t.Errorf("ranked %s first; want the most frequent command", got[0].Command)
+	}
+}
+
+func TestSearchWordBoundary(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	tree := NewAVLTree()
+	for _, command := range []string{"go test ./...", "pytest -x", "docker pull nginx:latest", "contest", "./run test", "Test.sh -v", "make\ttest"} {
+		tree.Insert(command, CommandMetadata{Frequency: 1, Timestamp: &now})
+	}
+
+	var got []string
+	for _, ranked := range SearchHistory(tree, "test", HistoryConfig{WordBoundaryMatch: true, EnableFuzzing: true}) {
+		got = append(got, ranked.Command)
+	}
+	want := []string{"Test.sh -v", "go test ./...", "make\ttest", "./run test"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("word boundary search for test = %q; want %q", got, want)
+	}
+
+	// Substring matching still finds them all
+	if fuzzy := SearchHistory(tree, "test", HistoryConfig{EnableFuzzing: true}); len(fuzzy) != 7 {
+		t.Errorf("fuzzy search found %d commands; want 7", len(fuzzy))
+	}
+
+	testCases := []struct {
+		command, query string
+		expected       int
+	}{
+		{"go test", "test", 3},
+		{"latest", "test", -1},
+		{"latest test", "test", 7},
+		{"GO TEST", "go t", 0},
+		{"anything", "", 0},
+	}
+	for _, tc := range testCases {
+		if got := wordBoundaryOffset(tc.command, tc.query); got != tc.expected {
+			t.Errorf("wordBoundaryOffset(%q, %q) = %d; want %d", tc.command, tc.query, got, tc.expected)
+		}
+	}
+}
+
+func TestMatchModeWord(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	tree := NewAVLTree()
+	for _, command := range []string{"GOOS=linux go build -o app", "go build ./...", "go test && go build", "gobuild.sh", "go vet"} {
+		tree.Insert(command, CommandMetadata{Frequency: 1, Timestamp: &now})
+	}
+	search := func(query string, config HistoryConfig) []string {
+		var got []string
+		for _, ranked := range SearchHistory(tree, query, config) {
+			got = append(got, ranked.Command)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	// match_mode wins over the boolean switches
+	word := HistoryConfig{MatchMode: "word", ShellWordMatch: true}
+	want := []string{"GOOS=linux go build -o app", "go build ./...", "go test && go build"}
+	if got := search("go build", word); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("word search for go build = %q; want %q", got, want)
+	}
+	if got := search("BUILD go", word); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("word search for BUILD go = %q; want %q regardless of order and case", got, want)
+	}
+	if got := search("go build", HistoryConfig{MatchMode: "prefix", EnableFuzzing: true}); fmt.Sprint(got) != "[go build ./...]" {
+		t.Errorf("prefix search for go build = %q; want only the command starting with it", got)
+	}
+	if got := search("o build", HistoryConfig{MatchMode: "substring"}); len(got) != 3 {
+		t.Errorf("substring search for o build = %q; want 3 commands", got)
+	}
+	// An unknown mode falls back to the boolean switches
+	unknown := HistoryConfig{MatchMode: "regex", EnableFuzzing: true}
+	if got := unknown.activeMatchMode(); got != matchModeSubstring {
+		t.Errorf("unknown match_mode gave %q; want %q", got, matchModeSubstring)
+	}
+
+	testCases := []struct {
+		command, query string
+		expected       int
+	}{
+		{"GOOS=linux go build", "build", 14},
+		{"GOOS=linux go build", "go build", 0},
+		{"go test && go build", "build go", 14},
+		{"go vet", "go build", -1},
+		{"café  go", "go", 6},
+	}
+	for _, tc := range testCases {
+		if got := wordsOffset(tc.command, strings.Fields(tc.query)); got != tc.expected {
+			t.Errorf("wordsOffset(%q, %q) = %d; want %d", tc.command, tc.query, got, tc.expected)
+		}
+	}
+}
+
+func TestSearchToleratesTypos(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	tree := NewAVLTree()
+	tree.Insert("git status", CommandMetadata{Frequency: 50, Timestamp: &now})
+	tree.Insert("git stash pop", CommandMetadata{Frequency: 5, Timestamp: &now})
+	tree.Insert("echo gti status", CommandMetadata{Frequency: 1, Timestamp: &now})
+	tree.Insert("go test ./...", CommandMetadata{Frequency: 20, Timestamp: &now})
+
+	config := HistoryConfig{EnableFuzzing: true, TypoTolerance: true}
+	search := func(query string) []string {
+		var got []string
+		for _, ranked := range SearchHistory(tree, query, config) {
+			got = append(got, ranked.Command)
+		}
+		return got
+	}
+
+	// Transposition: the exact match comes first despite being used less
+	if got, want := search("gti status"), []string{"echo gti status", "git status"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("search for transposed gti status = %q; want %q", got, want)
+	}
+	// Substitution, with the last word matched as a prefix while typing
+	if got, want := search("git stetus"), []string{"git status", "echo gti status"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("search for git stetus = %q; want %q", got, want)
+	}
+	if got, want := search("gti sta"), []string{"echo gti status", "git status", "git stash pop"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("search for gti sta = %q; want %q", got, want)
+	}
+	// Two-letter words must match exactly
+	if got := search("og"); len(got) != 0 {
+		t.Errorf("search for og = %q; want no near misses for short words", got)
+	}
+
+	config.TypoTolerance = false
+	if got := search("gti status"); fmt.Sprint(got) != fmt.Sprint([]string{"echo gti status"}) {
+		t.Errorf("search without typo_tolerance = %q; want only the exact match", got)
+	}
+
+	testCases := []struct {
+		a, b     string
+		limit    int
+		expected int
+	}{
+		{"gti", "git", 1, 1},
+		{"status", "stetus", 1, 1},
+		{"status", "status", 1, 0},
+		{"ca", "abc", 3, 3},
+		{"kubectl", "kubetcl", 2, 1},
+		{"docker", "dokcre", 1, 2},
+		{"ls", "docker", 2, 3},
+		{"", "go", 2, 2},
+	}
+	for _, tc := range testCases {
+		if got := typoDistance([]rune(tc.a), []rune(tc.b), tc.limit); got != tc.expected {
+			t.Errorf("typoDistance(%q, %q, %d) = %d; want %d", tc.a, tc.b, tc.limit, got, tc.expected)
+		}
+	}
+}
+
+func TestBoostCommandsOutrankEqualScores(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	tree := NewAVLTree()
+	for _, command := range []string{"compose logs web", "kubectl logs web", "KUBECONFIG=dev kubectl logs web"} {
+		tree.Insert(command, CommandMetadata{Frequency: 3, Timestamp: &now})
+	}
+
+	config := HistoryConfig{EnableFuzzing: true}
+	plain := SearchHistory(tree, "logs web", config)
+	if plain[0].Command != "compose logs web" || plain[0].Score != plain[1].Score {
+		t.Fatalf("without boost = %+v; want compose and kubectl tied, compose first", plain)
+	}
+
+	config.BoostCommands = []string{"kubectl"}
+	got := rankedCommandNames(SearchHistory(tree, "logs web", config))
+	want := []string{"kubectl logs web", "KUBECONFIG=dev kubectl logs web", "compose logs web"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("with kubectl boosted = %q; want %q", got, want)
+	}
+
+	// Boosting raises negative scores from a score formula too
+	ranked := []RankedCommand{{Command: "ls", Score: -1}, {Command: "kubectl get pods", Score: -1.5}}
+	boostCommands(ranked, []string{"kubectl"})
+	if ranked[0].Command != "kubectl get pods" || ranked[0].Score != -0.75 {
+		t.Errorf("boosted negative scores = %+v; want kubectl first at -0.75", ranked)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// benchQuery is a representative search timed by `recaller bench`
+type benchQuery struct {
+	Name  string
+	Query string
+	Fuzzy bool
+}
+
+// benchResult holds latency percentiles for one benchmark query
+type benchResult struct {
+	benchQuery
+	Matches int
+	P50     time.Duration
+	P95     time.Duration
+}
+
+// Size returns the number of commands stored in the tree
+func (tree *AVLTree) Size() int {
+	return countNodes(tree.Root)
+}
+
+func countNodes(node *AVLNode) int {
+	if node == nil {
+		return 0
+	}
+	return 1 + countNodes(node.Left) + countNodes(node.Right)
+}
+
+// representativeQueries derives short-prefix, long-prefix and fuzzy-substring
+// queries from the most highly ranked command so that every query has matches
+func representativeQueries(tree *AVLTree) []benchQuery {
+	top := SearchWithRanking(tree, "", false)
+	if len(top) == 0 {
+		return nil
+	}
+	cmd := []rune(top[0].Command)
+
+	// Slice by runes so a multi-byte character is never split
+	short := string(cmd[:min(len(cmd), 2)])
+	long := string(cmd[:min(len(cmd), 12)])
+
+	// Use the longest word of the command as a substring query
+	var substring []rune
+	for _, field := range strings.Fields(string(cmd)) {
+		if word := []rune(field); len(word) > len(substring) {
+			substring = word
+		}
+	}
+	if len(substring) > 4 {
+		substring = substring[1 : len(substring)-1]
+	}
+
+	return []benchQuery{
+		{Name: "empty (all commands)", Query: "", Fuzzy: false},
+		{Name: "short prefix", Query: short, Fuzzy: false},
+		{Name: "long prefix", Query: long, Fuzzy: false},
+		{Name: "fuzzy substring", Query: string(substring), Fuzzy: true},
+	}
+}
+
+// runSearchBenchmark times each query the given number of iterations
+func runSearchBenchmark(tree *AVLTree, queries []benchQuery, iterations int) []benchResult {
+	if iterations < 1 {
+		iterations = 1
+	}
+
+	results := make([]benchResult, 0, len(queries))
+	for _, q := range queries {
+		samples := make([]time.Duration, iterations)
+		matches := 0
+		for i := 0; i < iterations; i++ {
+			start := time.Now()
+			matches = len(SearchWithRanking(tree, q.Query, q.Fuzzy))
+			samples[i] = time.Since(start)
+		}
+		results = append(results, benchResult{
+			benchQuery: q,
+			Matches:    matches,
+			P50:        percentile(samples, 50),
+			P95:        percentile(samples, 95),
+		})
+	}
+	return results
+}
+
+// percentile returns the p-th percentile (nearest rank) of samples
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration{}, samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestPercentile(t *testing.T) {
+	samples := make([]time.Duration, 100)
+	for i := range samples {
+		samples[i] = time.Duration(100-i) * time.Millisecond
+	}
+
+	if got := percentile(samples, 50); got != 50*time.Millisecond {
+		t.Errorf("p50 = %v; want 50ms", got)
+	}
+	if got := percentile(samples, 95); got != 95*time.Millisecond {
+		t.Errorf("p95 = %v; want 95ms", got)
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile of no samples = %v; want 0", got)
+	}
+}
+
+func TestRunSearchBenchmark(t *testing.T) {
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, []HistoryEntry{
+		{Command: "kubectl get pods"},
+		{Command: "kubectl get pods"},
+		{Command: "kubectl describe pod web"},
+		{Command: "ls"},
+	}, HistoryConfig{})
+
+	if tree.Size() != 3 {
+		t.Errorf("Size() = %d; want 3", tree.Size())
+	}
+
+	queries := representativeQueries(tree)
+	if len(queries) != 4 {
+		t.Fatalf("representativeQueries returned %d queries; want 4", len(queries))
+	}
+
+	for _, r := range runSearchBenchmark(tree, queries, 5) {
+		if r.Matches == 0 {
+			t.Errorf("query %s (%q) had no matches", r.Name, r.Query)
+		}
+		if r.P95 < r.P50 {
+			t.Errorf("query %s: p95 %v < p50 %v", r.Name, r.P95, r.P50)
+		}
+	}
+}
+
+func TestRepresentativeQueriesKeepRunesWhole(t *testing.T) {
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, []HistoryEntry{{Command: "ééé çafé-résumé"}}, HistoryConfig{})
+
+	queries := representativeQueries(tree)
+	want := []string{"", "éé", "ééé çafé-rés", "afé-résum"}
+	for i, q := range queries {
+		if !utf8.ValidString(q.Query) || q.Query != want[i] {
+			t.Errorf("query %s = %q; want %q", q.Name, q.Query, want[i])
+		}
+	}
+}
+
+// benchmarkHistoryTree holds n distinct commands sharing common prefixes
+func benchmarkHistoryTree(n int) *AVLTree {
+	history := make([]HistoryEntry, n)
+	for i := range history {
+		history[i] = HistoryEntry{Command: fmt.Sprintf("kubectl get pods -n team-%d --selector app=web-%d", i%50, i)}
+	}
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, history, HistoryConfig{})
+	return tree
+}
+
+// BenchmarkSearchHistoryUncached and BenchmarkSearchHistoryCached compare
+// ranking a query from scratch with revisiting it through the results cache
+// the search UI keeps, e.g. after typing a character and deleting it again
+func BenchmarkSearchHistoryUncached(b *testing.B) {
+	tree := benchmarkHistoryTree(10000)
+	config := HistoryConfig{EnableFuzzing: true}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		SearchHistory(tree, "web-1", config)
+	}
+}
+
+func BenchmarkSearchHistoryCached(b *testing.B) {
+	tree := benchmarkHistoryTree(10000)
+	config := HistoryConfig{EnableFuzzing: true}
+	rc := newResultsCache[RankedCommand](searchResultsCacheSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := rc.Get("web-1"); !ok {
+			rc.Put("web-1", SearchHistory(tree, "web-1", config))
+		}
+	}
+}
@@ -15,8 +15,20 @@
 package main
 
 import (
-	"github.com/patrickmn/go-cache"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/mattn/go-shellwords"
+	"github.com/patrickmn/go-cache"
 )
 
 const (
@@ -26,20 +38,397 @@ const (
 	helpCacheCleanup = 5 * time.Minute
 )
 
+// helpCacheBySubcommand keys help pages by the command and its subcommands
+// instead of the full command line, so commands that differ only in their
+// arguments share one entry (help.cache_by_subcommand)
+var helpCacheBySubcommand bool
+
+// subcommandWord matches words that look like subcommands rather than
+// arguments: lowercase, no flags, paths, quotes or assignments
+var subcommandWord = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// helpCommandParts keeps the base command and the subcommand words that
+// follow it, dropping everything from the first flag or argument on,
+// e.g. `git commit -m "fix"` becomes git commit
+func helpCommandParts(parts []string) []string {
+	if len(parts) == 0 {
+		return parts
+	}
+	n := 1
+	for n < len(parts) && subcommandWord.MatchString(parts[n]) {
+		n++
+	}
+	return parts[:n]
+}
+
+// helpCacheKey returns the key cmd's help page is cached under
+func helpCacheKey(cmd string) string {
+	if !helpCacheBySubcommand {
+		return cmd
+	}
+	parts, err := shellwords.Parse(cmd)
+	if err != nil || len(parts) == 0 {
+		return cmd
+	}
+	return strings.Join(helpCommandParts(parts), " ")
+}
+
+// HelpCache stores help pages with time-based expiration. When a byte limit
+// is set (help.cache_max_mb), the least recently used pages are evicted once
+// the cached text exceeds it.
+type HelpCache struct {
+	*cache.Cache
+
+	mu        sync.Mutex
+	maxBytes  int64
+	usedBytes int64
+	lru       *list.List               // Keys, most recently used first
+	entries   map[string]*list.Element // Key to its lru element
+	sizes     map[string]int64         // Key to the bytes its page holds
+}
+
 // NewOptimizedHelpCache creates a cache optimized for help text storage
-func NewOptimizedHelpCache() *cache.Cache {
-	return cache.New(helpCacheExpiration, helpCacheCleanup)
+func NewOptimizedHelpCache() *HelpCache {
+	return newHelpCache(helpCacheExpiration, helpCacheCleanup)
+}
+
+func newHelpCache(expiration, cleanup time.Duration) *HelpCache {
+	hc := &HelpCache{
+		Cache:   cache.New(expiration, cleanup),
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+		sizes:   make(map[string]int64),
+	}
+	// Expired and evicted pages release their bytes
+	hc.Cache.OnEvicted(func(key string, _ interface{}) {
+		hc.mu.Lock()
+		defer hc.mu.Unlock()
+		hc.forget(key)
+	})
+	return hc
+}
+
+// SetMaxBytes caps the total size of cached pages, evicting the least
+// recently used ones as needed. Zero or less removes the cap.
+func (hc *HelpCache) SetMaxBytes(maxBytes int64) {
+	hc.mu.Lock()
+	hc.maxBytes = maxBytes
+	hc.mu.Unlock()
+	hc.evict("")
+}
+
+// store caches page under key and evicts older pages beyond the byte limit
+func (hc *HelpCache) store(key string, page helpPage) {
+	size := int64(len(key) + len(page.Text) + len(page.Invocation))
+
+	hc.mu.Lock()
+	hc.forget(key)
+	hc.entries[key] = hc.lru.PushFront(key)
+	hc.sizes[key] = size
+	hc.usedBytes += size
+	hc.mu.Unlock()
+
+	hc.Cache.Set(key, page, helpCacheExpiration)
+	hc.evict(key)
+}
+
+// load returns the page cached under key, marking it recently used
+func (hc *HelpCache) load(key string) (interface{}, bool) {
+	val, ok := hc.Cache.Get(key)
+	if ok {
+		hc.mu.Lock()
+		if elem, tracked := hc.entries[key]; tracked {
+			hc.lru.MoveToFront(elem)
+		}
+		hc.mu.Unlock()
+	}
+	return val, ok
+}
+
+// evict removes least recently used pages until the cache fits its byte
+// limit. keep (the page just stored) is never evicted, so a single page
+// larger than the limit is still cached.
+func (hc *HelpCache) evict(keep string) {
+	for {
+		hc.mu.Lock()
+		back := hc.lru.Back()
+		if hc.maxBytes <= 0 || hc.usedBytes <= hc.maxBytes || back == nil || back.Value.(string) == keep {
+			hc.mu.Unlock()
+			return
+		}
+		victim := back.Value.(string)
+		hc.mu.Unlock()
+
+		// Delete runs the OnEvicted hook, which must not be called with mu held
+		hc.Cache.Delete(victim)
+
+		hc.mu.Lock()
+		hc.forget(victim) // In case the page had already left go-cache
+		hc.mu.Unlock()
+	}
+}
+
+// forget drops key's size bookkeeping; the caller holds mu
+func (hc *HelpCache) forget(key string) {
+	if elem, ok := hc.entries[key]; ok {
+		hc.lru.Remove(elem)
+		delete(hc.entries, key)
+		hc.usedBytes -= hc.sizes[key]
+		delete(hc.sizes, key)
+	}
+}
+
+// helpPage is a cached help text together with the invocation that produced it
+type helpPage struct {
+	Text       string
+	Invocation string
+	Fetched    time.Time // When the page was looked up; kept by the disk cache, zero for failures
+}
+
+func CacheHelpPage(c *HelpCache, cmd string, helpTxt string) {
+	CacheHelpPageWithSource(c, cmd, helpTxt, "")
+}
+
+// CacheHelpPageWithSource stores a help page along with the command that produced it
+func CacheHelpPageWithSource(c *HelpCache, cmd string, helpTxt string, invocation string) {
+	// Overwrites any existing page (more efficient for repeated commands)
+	c.store(helpCacheKey(cmd), helpPage{Text: helpTxt, Invocation: invocation, Fetched: timeNow()})
+}
+
+// cacheHelpFailure stores the text explaining why help for cmd couldn't be
+// looked up. It has no Fetched time, so it stays out of the disk cache.
+func cacheHelpFailure(c *HelpCache, cmd string, text string) {
+	c.store(helpCacheKey(cmd), helpPage{Text: text})
+}
+
+func GetHelpPage(c *HelpCache, cmd string) string {
+	return getCachedHelpPage(c, cmd).Text
 }
 
-func CacheHelpPage(c *cache.Cache, cmd string, helpTxt string) {
-	// Use Set instead of Add to allow overwriting (more efficient for repeated commands)
-	c.Set(cmd, helpTxt, helpCacheExpiration)
+// GetHelpSource returns the invocation that produced the cached help page for cmd,
+// or "" if the page is not cached or its source is unknown
+func GetHelpSource(c *HelpCache, cmd string) string {
+	return getCachedHelpPage(c, cmd).Invocation
 }
 
-func GetHelpPage(c *cache.Cache, cmd string) string {
-	val, ok := c.Get(cmd)
+func getCachedHelpPage(c *HelpCache, cmd string) helpPage {
+	val, ok := c.load(helpCacheKey(cmd))
 	if !ok {
-		return ""
+		return helpPage{}
+	}
+	switch v := val.(type) {
+	case helpPage:
+		return v
+	case string:
+		return helpPage{Text: v}
+	}
+	return helpPage{}
+}
+
+// persistedHelpPage is one page in the disk cache file
+type persistedHelpPage struct {
+	Key        string    `json:"key"`
+	Text       string    `json:"text"`
+	Invocation string    `json:"invocation,omitempty"`
+	Fetched    time.Time `json:"fetched"`
+}
+
+// GetHelpCachePath returns where the help cache is persisted between runs
+// (help.disk_cache): $XDG_CACHE_HOME/recaller/help_cache.json (under
+// ~/.cache by default), or an existing ~/.recaller_help_cache.json
+func GetHelpCachePath() string {
+	path, err := recallerFilePath(os.Getenv, ".recaller_help_cache.json", "XDG_CACHE_HOME", "help_cache.json", false)
+	if err != nil {
+		return ".recaller_help_cache.json"
 	}
-	return val.(string)
+	return path
+}
+
+// readHelpCacheFile returns the pages in the disk cache at path fetched
+// within maxAge. A missing file holds no pages.
+func readHelpCacheFile(path string, maxAge time.Duration) ([]persistedHelpPage, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var pages []persistedHelpPage
+	if err := json.Unmarshal(data, &pages); err != nil {
+		return nil, fmt.Errorf("failed to parse help cache %s: %v", path, err)
+	}
+	cutoff := timeNow().Add(-maxAge)
+	fresh := pages[:0]
+	for _, page := range pages {
+		if page.Fetched.After(cutoff) {
+			fresh = append(fresh, page)
+		}
+	}
+	return fresh, nil
+}
+
+// LoadFile adds the pages persisted at path that are younger than maxAge,
+// returning how many were loaded
+func (hc *HelpCache) LoadFile(path string, maxAge time.Duration) (int, error) {
+	pages, err := readHelpCacheFile(path, maxAge)
+	if err != nil {
+		return 0, err
+	}
+	for _, page := range pages {
+		hc.store(page.Key, helpPage{Text: page.Text, Invocation: page.Invocation, Fetched: page.Fetched})
+	}
+	return len(pages), nil
+}
+
+// SaveFile persists the cached pages to path, keeping pages already on disk
+// that have since left memory as long as they are younger than maxAge
+func (hc *HelpCache) SaveFile(path string, maxAge time.Duration) error {
+	pages, err := readHelpCacheFile(path, maxAge)
+	if err != nil {
+		pages = nil // Replace a corrupt file
+	}
+	byKey := make(map[string]int, len(pages))
+	for i, page := range pages {
+		byKey[page.Key] = i
+	}
+	for key, item := range hc.Cache.Items() {
+		page, ok := item.Object.(helpPage)
+		if !ok || page.Fetched.IsZero() {
+			continue
+		}
+		persisted := persistedHelpPage{Key: key, Text: page.Text, Invocation: page.Invocation, Fetched: page.Fetched}
+		if i, seen := byKey[key]; seen {
+			pages[i] = persisted
+		} else {
+			byKey[key] = len(pages)
+			pages = append(pages, persisted)
+		}
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Key < pages[j].Key })
+
+	data, err := json.Marshal(pages)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create help cache directory: %v", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write help cache: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace help cache: %v", err)
+	}
+	return nil
+}
+
+// helpDiskCacheMaxAge is how long persisted help pages are reused
+func helpDiskCacheMaxAge(config HelpConfig) time.Duration {
+	days := config.DiskCacheDays
+	if days <= 0 {
+		days = defaultConfig.Help.DiskCacheDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// loadHelpDiskCache fills hc from the disk cache when help.disk_cache is on
+func loadHelpDiskCache(hc *HelpCache, config HelpConfig) {
+	if !config.DiskCache {
+		return
+	}
+	if _, err := hc.LoadFile(GetHelpCachePath(), helpDiskCacheMaxAge(config)); err != nil {
+		log.Printf("Failed to load help cache: %v", err)
+	}
+}
+
+// saveHelpDiskCache persists hc when help.disk_cache is on
+func saveHelpDiskCache(hc *HelpCache, config HelpConfig) {
+	if !config.DiskCache {
+		return
+	}
+	if err := hc.SaveFile(GetHelpCachePath(), helpDiskCacheMaxAge(config)); err != nil {
+		log.Printf("Failed to save help cache: %v", err)
+	}
+}
+
+var (
+	sharedHelpCacheOnce sync.Once
+	sharedHelpCacheInst *HelpCache
+)
+
+// sharedHelpCache returns the process-wide help cache, configured from config
+// and filled from the disk cache on first use. The history UI, 'recaller help'
+// and 'recaller help warm' all look help up here, so a page fetched by one is
+// reused by the others and, with help.disk_cache on, by later runs once
+// saveHelpDiskCache writes it back. With help.disk_cache off nothing is read
+// from or written to disk.
+func sharedHelpCache(config HelpConfig) *HelpCache {
+	sharedHelpCacheOnce.Do(func() {
+		helpCacheBySubcommand = config.CacheBySubcommand
+		sharedHelpCacheInst = NewOptimizedHelpCache()
+		sharedHelpCacheInst.SetMaxBytes(int64(config.CacheMaxMB) << 20)
+		loadHelpDiskCache(sharedHelpCacheInst, config)
+	})
+	return sharedHelpCacheInst
+}
+
+// searchResultsCacheSize is how many recent queries each search UI keeps
+// results for
+const searchResultsCacheSize = 32
+
+// resultsCache remembers the ranked results of recent queries, so editing
+// back to a query that was just searched (e.g. with backspace) skips the
+// search. Callers clear it when the searched data or search mode changes.
+type resultsCache[T any] struct {
+	capacity int
+	lru      *list.List // Keys, most recently used first
+	entries  map[string]*list.Element
+	results  map[string][]T
+}
+
+func newResultsCache[T any](capacity int) *resultsCache[T] {
+	return &resultsCache[T]{
+		capacity: capacity,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		results:  make(map[string][]T),
+	}
+}
+
+// Get returns the results cached for key, marking them recently used
+func (rc *resultsCache[T]) Get(key string) ([]T, bool) {
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	rc.lru.MoveToFront(elem)
+	return rc.results[key], true
+}
+
+// Put caches results for key, dropping the least recently used query once
+// the cache is full
+func (rc *resultsCache[T]) Put(key string, results []T) {
+	if elem, ok := rc.entries[key]; ok {
+		rc.lru.MoveToFront(elem)
+		rc.results[key] = results
+		return
+	}
+	rc.entries[key] = rc.lru.PushFront(key)
+	rc.results[key] = results
+	for rc.lru.Len() > rc.capacity {
+		oldest := rc.lru.Remove(rc.lru.Back()).(string)
+		delete(rc.entries, oldest)
+		delete(rc.results, oldest)
+	}
+}
+
+// Clear drops every cached query
+func (rc *resultsCache[T]) Clear() {
+	rc.lru.Init()
+	clear(rc.entries)
+	clear(rc.results)
 }
@@ -15,10 +15,11 @@
 package main
 
 import (
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
-
-	"github.com/patrickmn/go-cache"
 )
 
 func TestCacheHelpPageAndGetHelpPage(t *testing.T) {
@@ -43,7 +44,7 @@ func TestCacheHelpPageAndGetHelpPage(t *testing.T) {
 
 func TestCacheExpiration(t *testing.T) {
 	// Create a cache with a very short expiration time to test expiry behavior.
-	c := cache.New(100*time.Millisecond, 50*time.Millisecond)
+	c := newHelpCache(100*time.Millisecond, 50*time.Millisecond)
 	cmd := "expiringCommand"
 	helpText := "This help text should expire soon."
 
@@ -63,3 +64,201 @@ func TestCacheExpiration(t *testing.T) {
 		t.Errorf("After expiration, GetHelpPage(%q) = %q; want empty string", cmd, got)
 	}
 }
+
+func TestCacheHelpPageWithSource(t *testing.T) {
+	c := NewOptimizedHelpCache()
+	cmd := "git status"
+
+	if got := GetHelpSource(c, cmd); got != "" {
+		t.Errorf("GetHelpSource(%q) = %q; want empty string", cmd, got)
+	}
+
+	CacheHelpPageWithSource(c, cmd, "git-status - Show the working tree status", "GIT_PAGER=cat git help status")
+
+	if got := GetHelpPage(c, cmd); got != "git-status - Show the working tree status" {
+		t.Errorf("GetHelpPage(%q) = %q", cmd, got)
+	}
+	if got := GetHelpSource(c, cmd); got != "GIT_PAGER=cat git help status" {
+		t.Errorf("GetHelpSource(%q) = %q; want %q", cmd, got, "GIT_PAGER=cat git help status")
+	}
+}
+
+func TestHelpCommandParts(t *testing.T) {
+	tests := map[string]string{
+		`git commit -m "fix bug"`:    "git commit",
+		`aws s3api list-buckets`:     "aws s3api list-buckets",
+		`kubectl -n prod get pods`:   "kubectl",
+		`docker run ubuntu:22.04 sh`: "docker run",
+		`ls ./src`:                   "ls",
+	}
+	for cmd, want := range tests {
+		parts, _ := splitCommand(cmd)
+		if got := strings.Join(helpCommandParts(parts), " "); got != want {
+			t.Errorf("helpCommandParts(%q) = %q; want %q", cmd, got, want)
+		}
+	}
+}
+
+func TestHelpCacheBySubcommand(t *testing.T) {
+	c := NewOptimizedHelpCache()
+	defer func(saved bool) { helpCacheBySubcommand = saved }(helpCacheBySubcommand)
+
+	helpCacheBySubcommand = false
+	CacheHelpPage(c, `git commit -m "a"`, "commit help")
+	if page := GetHelpPage(c, `git commit -m "b"`); page != "" {
+		t.Errorf("full-command keys should not share help, got %q", page)
+	}
+
+	helpCacheBySubcommand = true
+	CacheHelpPage(c, `git commit -m "a"`, "commit help")
+	if page := GetHelpPage(c, `git commit -m "b"`); page != "commit help" {
+		t.Errorf("GetHelpPage = %q; want help shared by git commit", page)
+	}
+	if page := GetHelpPage(c, `git log -1`); page != "" {
+		t.Errorf("other subcommands should not share help, got %q", page)
+	}
+}
+
+func TestHelpCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewOptimizedHelpCache()
+	page := strings.Repeat("x", 100)
+	// Each entry holds its key (4 bytes) plus the page
+	c.SetMaxBytes(3 * 104)
+
+	for _, cmd := range []string{"cmd1", "cmd2", "cmd3"} {
+		CacheHelpPage(c, cmd, page)
+	}
+	// Viewing cmd1 makes cmd2 the least recently used
+	GetHelpPage(c, "cmd1")
+	CacheHelpPage(c, "cmd4", page)
+
+	if got := GetHelpPage(c, "cmd2"); got != "" {
+		t.Error("least recently used page should have been evicted")
+	}
+	for _, cmd := range []string{"cmd1", "cmd3", "cmd4"} {
+		if got := GetHelpPage(c, cmd); got != page {
+			t.Errorf("page for %s should still be cached", cmd)
+		}
+	}
+
+	// A page larger than the cap is still cached, replacing everything else
+	big := strings.Repeat("y", 1000)
+	CacheHelpPage(c, "huge", big)
+	if got := GetHelpPage(c, "huge"); got != big {
+		t.Error("oversized page should be kept")
+	}
+	if c.ItemCount() != 1 {
+		t.Errorf("cache holds %d pages; want only the oversized one", c.ItemCount())
+	}
+}
+
+func TestHelpCacheDiskRoundTrip(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "help_cache.json")
+	week := 7 * 24 * time.Hour
+
+	freezeTime(t, now.Add(-10*24*time.Hour))
+	old := NewOptimizedHelpCache()
+	CacheHelpPageWithSource(old, "tar", "tar help", "man tar")
+	if err := old.SaveFile(path, 30*24*time.Hour); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	freezeTime(t, now)
+	c := NewOptimizedHelpCache()
+	CacheHelpPageWithSource(c, "git status", "git status help", "git status --help")
+	if err := c.SaveFile(path, 30*24*time.Hour); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+
+	// Pages already on disk are kept when they are not in memory
+	loaded := NewOptimizedHelpCache()
+	if n, err := loaded.LoadFile(path, 30*24*time.Hour); err != nil || n != 2 {
+		t.Fatalf("LoadFile = %d, %v; want 2 pages", n, err)
+	}
+	if got := GetHelpSource(loaded, "git status"); got != "git status --help" {
+		t.Errorf("source = %q; want %q", got, "git status --help")
+	}
+
+	// Pages older than the max age are looked up again
+	fresh := NewOptimizedHelpCache()
+	if n, err := fresh.LoadFile(path, week); err != nil || n != 1 {
+		t.Fatalf("LoadFile = %d, %v; want 1 page", n, err)
+	}
+	if got := GetHelpPage(fresh, "tar"); got != "" {
+		t.Errorf("stale page loaded: %q", got)
+	}
+	if got := GetHelpPage(fresh, "git status"); got != "git status help" {
+		t.Errorf("GetHelpPage = %q; want %q", got, "git status help")
+	}
+}
+
+// TestHelpWarmSkipsFailures checks that a failed lookup is counted as failed
+// and never reaches the disk cache.
+func TestHelpWarmSkipsFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "help_cache.json")
+	c := NewOptimizedHelpCache()
+	CacheHelpPageWithSource(c, "tar", "tar help", "man tar")
+
+	fetched, cached, failed := warmHelpCache(context.Background(), c, []string{"tar", "nonexistent_command"}, 2, func() {})
+	if fetched != 0 || cached != 1 || failed != 1 {
+		t.Errorf("warmHelpCache = %d fetched, %d cached, %d failed; want 0, 1, 1", fetched, cached, failed)
+	}
+	if GetHelpPage(c, "nonexistent_command") == "" {
+		t.Error("failure text not cached in memory")
+	}
+
+	if err := c.SaveFile(path, time.Hour); err != nil {
+		t.Fatalf("SaveFile: %v", err)
+	}
+	loaded := NewOptimizedHelpCache()
+	if n, err := loaded.LoadFile(path, time.Hour); err != nil || n != 1 {
+		t.Errorf("LoadFile = %d, %v; want only the tar page", n, err)
+	}
+}
+
+func TestHelpCacheLoadMissingFile(t *testing.T) {
+	c := NewOptimizedHelpCache()
+	if n, err := c.LoadFile(filepath.Join(t.TempDir(), "missing.json"), time.Hour); err != nil || n != 0 {
+		t.Errorf("LoadFile = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestTopCommandsByFrequency(t *testing.T) {
+	older := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	tree := NewAVLTree()
+	tree.Insert("ls", CommandMetadata{Frequency: 3, Timestamp: &older})
+	tree.Insert("git status", CommandMetadata{Frequency: 9, Timestamp: &older})
+	tree.Insert("make", CommandMetadata{Frequency: 3, Timestamp: &newer})
+	tree.Insert("vim", CommandMetadata{Frequency: 1, Timestamp: &newer})
+
+	got := topCommandsByFrequency(tree, 3)
+	want := []string{"git status", "make", "ls"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("topCommandsByFrequency = %v; want %v", got, want)
+	}
+	if got := topCommandsByFrequency(tree, 0); len(got) != 4 {
+		t.Errorf("top 0 returned %d commands; want all 4", len(got))
+	}
+}
+
+func TestResultsCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	rc := newResultsCache[RankedCommand](2)
+	rc.Put("g", []RankedCommand{{Command: "git status"}})
+	rc.Put("gi", []RankedCommand{{Command: "git"}})
+	rc.Get("g") // "gi" is now the least recently used
+	rc.Put("git", []RankedCommand{{Command: "git log"}})
+
+	if _, ok := rc.Get("gi"); ok {
+		t.Error("least recently used query was not evicted")
+	}
+	if got, ok := rc.Get("g"); !ok || got[0].Command != "git status" {
+		t.Errorf("Get(g) = %v, %t; want the cached results", got, ok)
+	}
+
+	rc.Clear()
+	if _, ok := rc.Get("git"); ok {
+		t.Error("Clear kept cached results")
+	}
+}
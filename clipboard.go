@@ -0,0 +1,174 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/atotto/clipboard"
+)
+
+// clipboardTools are the commands recaller can copy through, in the order
+// they are looked for
+var clipboardTools = []struct {
+	Command  string
+	Platform string
+}{
+	{"pbcopy", "macOS"},
+	{"wl-copy", "Wayland"},
+	{"xclip", "X11"},
+	{"xsel", "X11"},
+	{"clip.exe", "WSL"},
+}
+
+// linuxPackageManagers maps a package manager to its install command
+var linuxPackageManagers = []struct {
+	Command string
+	Install string
+}{
+	{"apt-get", "sudo apt install"},
+	{"dnf", "sudo dnf install"},
+	{"yum", "sudo yum install"},
+	{"pacman", "sudo pacman -S"},
+	{"zypper", "sudo zypper install"},
+	{"apk", "sudo apk add"},
+	{"nix-env", "nix-env -iA nixpkgs."},
+}
+
+// isWSL reports whether recaller runs under the Windows Subsystem for Linux,
+// where X11 and Wayland clipboards are not connected to Windows
+func isWSL(getenv func(string) string, readFile func(string) ([]byte, error)) bool {
+	if getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	version, err := readFile("/proc/version")
+	return err == nil && strings.Contains(strings.ToLower(string(version)), "microsoft")
+}
+
+var runningInWSL = sync.OnceValue(func() bool {
+	return runtime.GOOS == "linux" && isWSL(os.Getenv, os.ReadFile)
+})
+
+// utf16LE encodes text as UTF-16LE with a byte order mark, which is what
+// clip.exe expects; it reads plain bytes in the console code page otherwise
+func utf16LE(text string) []byte {
+	units := utf16.Encode([]rune(text))
+	buf := make([]byte, 2, 2+2*len(units))
+	binary.LittleEndian.PutUint16(buf, 0xFEFF)
+	for _, u := range units {
+		buf = binary.LittleEndian.AppendUint16(buf, u)
+	}
+	return buf
+}
+
+// copyWithClipExe copies text to the Windows clipboard through clip.exe
+func copyWithClipExe(text string) error {
+	cmd := exec.Command("clip.exe")
+	cmd.Stdin = bytes.NewReader(utf16LE(text))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("clip.exe: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// detectClipboardTool returns the first clipboard command found on PATH and
+// the platform it belongs to
+func detectClipboardTool(lookPath func(string) (string, error)) (command, platform string, found bool) {
+	for _, tool := range clipboardTools {
+		if _, err := lookPath(tool.Command); err == nil {
+			return tool.Command, tool.Platform, true
+		}
+	}
+	return "", "", false
+}
+
+// clipboardInstallHint returns the exact command that installs a clipboard
+// tool on this platform, or advice when there is no single command
+func clipboardInstallHint(goos string, wsl bool, getenv func(string) string, lookPath func(string) (string, error)) string {
+	switch goos {
+	case "darwin":
+		return "pbcopy ships with macOS; make sure /usr/bin is on your PATH"
+	case "linux":
+		if wsl {
+			return "clip.exe ships with Windows; enable appendWindowsPath in /etc/wsl.conf so it is on your PATH"
+		}
+		pkg := "xclip"
+		if getenv("WAYLAND_DISPLAY") != "" {
+			pkg = "wl-clipboard"
+		}
+		for _, pm := range linuxPackageManagers {
+			if _, err := lookPath(pm.Command); err != nil {
+				continue
+			}
+			if strings.HasSuffix(pm.Install, ".") {
+				return pm.Install + pkg
+			}
+			return pm.Install + " " + pkg
+		}
+		return fmt.Sprintf("install %s with your package manager", pkg)
+	}
+	return fmt.Sprintf("clipboard tools are not supported on %s", goos)
+}
+
+// checkClipboard reports the clipboard tool in use, or why copying will fail
+// and how to fix it
+func checkClipboard() (string, bool) {
+	if runningInWSL() {
+		if _, err := exec.LookPath("clip.exe"); err == nil {
+			return "clip.exe (WSL)", true
+		}
+	}
+	if command, platform, found := detectClipboardTool(exec.LookPath); found {
+		return fmt.Sprintf("%s (%s)", command, platform), true
+	}
+	return fmt.Sprintf("no clipboard tool found (pbcopy, wl-copy, xclip, xsel or clip.exe). Install one with: %s",
+		clipboardInstallHint(runtime.GOOS, runningInWSL(), os.Getenv, exec.LookPath)), false
+}
+
+// clipboardHintOnce makes sure the install hint is shown only for the first
+// failed copy
+var clipboardHintOnce sync.Once
+
+// copyToClipboard writes text to the system clipboard. Under WSL it goes
+// through clip.exe so it lands on the Windows clipboard.
+func copyToClipboard(text string) error {
+	if runningInWSL() {
+		return copyWithClipExe(text)
+	}
+	return clipboard.WriteAll(text)
+}
+
+// reportClipboardFailure tells the user a copy failed. The first failure also
+// explains how to get a working clipboard, so the command is not silently lost.
+func reportClipboardFailure(w io.Writer, err error, text string) {
+	cliFprintf(w, "❌ Could not copy to clipboard: %v\n", err)
+	if text != "" {
+		cliFprintf(w, "%s\n", text)
+	}
+	clipboardHintOnce.Do(func() {
+		if status, ok := checkClipboard(); !ok {
+			cliFprintf(w, "💡 %s\n", status)
+		}
+	})
+}
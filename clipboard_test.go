@@ -0,0 +1,115 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeLookPath finds only the given commands
+func fakeLookPath(available ...string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		for _, a := range available {
+			if a == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestDetectClipboardTool(t *testing.T) {
+	testCases := []struct {
+		available []string
+		expected  string
+	}{
+		{[]string{"xsel", "xclip"}, "xclip"},
+		{[]string{"xsel"}, "xsel"},
+		{[]string{"clip.exe"}, "clip.exe"},
+		{[]string{"wl-copy", "xclip"}, "wl-copy"},
+		{nil, ""},
+	}
+
+	for _, tc := range testCases {
+		command, _, found := detectClipboardTool(fakeLookPath(tc.available...))
+		if command != tc.expected || found != (tc.expected != "") {
+			t.Errorf("detectClipboardTool(%v) = %q, %t; want %q", tc.available, command, found, tc.expected)
+		}
+	}
+}
+
+func TestClipboardInstallHint(t *testing.T) {
+	testCases := []struct {
+		goos     string
+		wsl      bool
+		env      map[string]string
+		managers []string
+		expected string
+	}{
+		{"linux", false, nil, []string{"apt-get"}, "sudo apt install xclip"},
+		{"linux", false, map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, []string{"pacman"}, "sudo pacman -S wl-clipboard"},
+		{"linux", false, nil, []string{"nix-env"}, "nix-env -iA nixpkgs.xclip"},
+		{"linux", false, nil, nil, "install xclip with your package manager"},
+		{"linux", true, nil, []string{"apt-get"}, "appendWindowsPath"},
+		{"darwin", false, nil, nil, "pbcopy ships with macOS"},
+	}
+
+	for _, tc := range testCases {
+		getenv := func(name string) string { return tc.env[name] }
+		hint := clipboardInstallHint(tc.goos, tc.wsl, getenv, fakeLookPath(tc.managers...))
+		if !strings.Contains(hint, tc.expected) {
+			t.Errorf("clipboardInstallHint(%s, %v, %v) = %q; want it to contain %q", tc.goos, tc.env, tc.managers, hint, tc.expected)
+		}
+	}
+}
+
+func TestIsWSL(t *testing.T) {
+	noEnv := func(string) string { return "" }
+	procVersion := func(content string) func(string) ([]byte, error) {
+		return func(string) ([]byte, error) { return []byte(content), nil }
+	}
+
+	if !isWSL(func(string) string { return "Ubuntu" }, procVersion("")) {
+		t.Error("WSL_DISTRO_NAME should mark WSL")
+	}
+	if !isWSL(noEnv, procVersion("Linux version 5.15.153.1-microsoft-standard-WSL2")) {
+		t.Error("a Microsoft kernel in /proc/version should mark WSL")
+	}
+	if isWSL(noEnv, procVersion("Linux version 6.8.0-45-generic")) {
+		t.Error("a plain Linux kernel is not WSL")
+	}
+	if isWSL(noEnv, func(string) ([]byte, error) { return nil, errors.New("missing") }) {
+		t.Error("an unreadable /proc/version is not WSL")
+	}
+}
+
+func TestUTF16LE(t *testing.T) {
+	got := utf16LE("é€😀")
+	want := []byte{0xFF, 0xFE, 0xE9, 0x00, 0xAC, 0x20, 0x3D, 0xD8, 0x00, 0xDE}
+	if !bytes.Equal(got, want) {
+		t.Errorf("utf16LE = % x; want % x", got, want)
+	}
+}
+
+func TestReportClipboardFailureKeepsText(t *testing.T) {
+	var out bytes.Buffer
+	reportClipboardFailure(&out, errors.New("exit status 1"), "make deploy")
+	if !strings.Contains(out.String(), "make deploy") {
+		t.Errorf("failed copy should print the text instead: %q", out.String())
+	}
+}
@@ -15,7 +15,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/cybrota/recaller/strategies"
 	"github.com/mattn/go-shellwords"
@@ -25,10 +29,53 @@ import (
 // GLOBAL HELP MANAGER
 // ============================================================================
 
-var globalHelpManager *strategies.HelpStrategyManager
+var (
+	globalHelpManager *strategies.HelpStrategyManager
+	helpManagerOnce   sync.Once
+	helpManagerConfig *HelpConfig // Applied when the manager is built
+)
+
+// helpManager returns globalHelpManager, building it on the first help
+// lookup. Building it detects the environment, which runs man, so commands
+// that never show help don't pay for it.
+func helpManager() *strategies.HelpStrategyManager {
+	helpManagerOnce.Do(func() {
+		globalHelpManager = strategies.NewHelpStrategyManager()
+		if helpManagerConfig != nil {
+			globalHelpManager.SetMaxConcurrentFetches(helpManagerConfig.MaxConcurrentFetches)
+			globalHelpManager.SetGenericMaxDepth(helpManagerConfig.GenericMaxDepth)
+		}
+	})
+	return globalHelpManager
+}
+
+// configureHelpManager sets the help settings that helpManager applies. It
+// must be called before the first lookup.
+func configureHelpManager(config HelpConfig) {
+	helpManagerConfig = &config
+}
 
-func init() {
-	globalHelpManager = strategies.NewHelpStrategyManager()
+// checkHelpSources reports which help sources suit this system. It never
+// fails: TLDR and --help flags work everywhere.
+func checkHelpSources() (string, bool) {
+	return helpSourcesStatus(strategies.DetectEnvironment()), true
+}
+
+// helpSourcesStatus describes the help sources used in env
+func helpSourcesStatus(env strategies.Environment) string {
+	sources := []string{"tldr"}
+	if env.Busybox {
+		sources = append(sources, "busybox applet usage")
+	}
+	if !env.ManMinimal {
+		sources = append(sources, "man pages")
+	}
+	sources = append(sources, "--help flags")
+	status := strings.Join(sources, ", ")
+	if env.ManMinimal {
+		status += " (man pages are missing or minimized)"
+	}
+	return status
 }
 
 // ============================================================================
@@ -37,7 +84,103 @@ func init() {
 
 // getCommandHelp is the main entry point for getting command help
 func getCommandHelp(cmdParts []string) (string, error) {
-	return globalHelpManager.GetHelp(cmdParts)
+	return helpManager().GetHelp(cmdParts)
+}
+
+// resolveCommandHelp is like getCommandHelp but also reports the invocation
+// (command line or URL) that produced the help text
+func resolveCommandHelp(cmdParts []string) (*strategies.HelpResult, error) {
+	return helpManager().Resolve(cmdParts)
+}
+
+// resolveCommandHelpContext is like resolveCommandHelp but can be cancelled
+func resolveCommandHelpContext(ctx context.Context, cmdParts []string) (*strategies.HelpResult, error) {
+	return helpManager().ResolveContext(ctx, cmdParts)
+}
+
+// traceCommandHelp resolves help for cmdParts, reporting every strategy considered
+func traceCommandHelp(ctx context.Context, cmdParts []string) ([]strategies.StrategyAttempt, *strategies.HelpResult, error) {
+	return helpManager().TraceContext(ctx, cmdParts)
+}
+
+// cachedCommandHelp returns the help 'recaller help' can print for cmdParts
+// from hc. Only pages from a successful lookup count: those record the
+// invocation that produced them, while failure messages cached by the UI do not.
+func cachedCommandHelp(hc *HelpCache, cmdParts []string) (string, bool) {
+	page := getCachedHelpPage(hc, strings.Join(cmdParts, " "))
+	if page.Text == "" || page.Invocation == "" {
+		return "", false
+	}
+	return page.Text, true
+}
+
+// formatHelpTrace renders the outcome of each help strategy for 'recaller help --debug'
+func formatHelpTrace(command string, attempts []strategies.StrategyAttempt, result *strategies.HelpResult, err error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔎 Help resolution for %q:\n", command)
+
+	winner := ""
+	for i, attempt := range attempts {
+		var outcome string
+		switch {
+		case attempt.Tried && attempt.Err == nil:
+			winner = attempt.Strategy
+			outcome = fmt.Sprintf("✅ succeeded: %s", attempt.Result.Invocation)
+		case attempt.Tried:
+			outcome = fmt.Sprintf("❌ failed: %v", attempt.Err)
+		case attempt.Supported:
+			outcome = "⏸️  not tried: an earlier strategy answered"
+		default:
+			outcome = "⏭️  skipped: does not support this command"
+		}
+		fmt.Fprintf(&b, "  %2d. %-22s %s\n", i+1, attempt.Strategy, outcome)
+	}
+
+	if err != nil {
+		fmt.Fprintf(&b, "\n💥 No strategy produced help: %v\n", err)
+	} else if result != nil {
+		fmt.Fprintf(&b, "\n🏆 Winner: %s (%s)\n", winner, result.Invocation)
+	}
+	return b.String()
+}
+
+// helpFailureText is shown in the help pane when no documentation could be
+// fetched, with a hint matching why the help command failed
+func helpFailureText(err error) string {
+	text := fmt.Sprintf("Relax and take a deep breath.\n%s", err.Error())
+
+	var runErr *strategies.RunError
+	if !errors.As(err, &runErr) {
+		return text
+	}
+	switch runErr.Kind {
+	case strategies.RunNotInstalled:
+		text += fmt.Sprintf("\n\nInstall %s (or add it to PATH) to see its documentation.", runErr.Name)
+	case strategies.RunTimedOut:
+		text += "\n\nThe help command was too slow; select the command again to retry."
+	case strategies.RunExitStatus:
+		text += fmt.Sprintf("\n\nRun `%s` in a shell to see the full output.", runErr.Invocation)
+	}
+	return text
+}
+
+// commandHelpFailureText is helpFailureText for cmdParts, suggesting the
+// closest known command when the base command looks mistyped
+func commandHelpFailureText(cmdParts []string, err error) string {
+	text := helpFailureText(err)
+	if len(cmdParts) > 0 {
+		if hint := didYouMean(cmdParts[0]); hint != "" {
+			text += "\n\n" + hint
+		}
+	}
+	return text
+}
+
+// helpErrorIsTransient reports whether a help failure may succeed on retry
+// and so should not be cached
+func helpErrorIsTransient(err error) bool {
+	var runErr *strategies.RunError
+	return errors.As(err, &runErr) && runErr.Kind == strategies.RunTimedOut
 }
 
 // splitCommand splits a full command string into parts
@@ -15,7 +15,12 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/cybrota/recaller/strategies"
 )
 
 // TestGetCommandHelpEmpty verifies that calling getCommandHelp with an empty slice returns an error.
@@ -65,3 +70,55 @@ func TestSplitCommand(t *testing.T) {
 		}
 	}
 }
+
+// TestHelpFailureTextHints verifies the help pane explains why help is missing.
+func TestHelpFailureTextHints(t *testing.T) {
+	notInstalled := fmt.Errorf("failed to get help: %w", &strategies.RunError{Kind: strategies.RunNotInstalled, Name: "terraform"})
+	if text := helpFailureText(notInstalled); !strings.Contains(text, "Install terraform") {
+		t.Errorf("missing install hint: %q", text)
+	}
+	if helpErrorIsTransient(notInstalled) {
+		t.Error("a missing command is not transient")
+	}
+
+	timedOut := &strategies.RunError{Kind: strategies.RunTimedOut, Invocation: "man foo"}
+	if !helpErrorIsTransient(timedOut) {
+		t.Error("a timeout should not be cached")
+	}
+
+	plain := errors.New("no help strategy found")
+	if text := helpFailureText(plain); text != "Relax and take a deep breath.\nno help strategy found" {
+		t.Errorf("helpFailureText = %q", text)
+	}
+}
+
+func TestCachedCommandHelp(t *testing.T) {
+	hc := NewOptimizedHelpCache()
+	CacheHelpPageWithSource(hc, "git status", "git-status - Show the working tree status", "git help status")
+	CacheHelpPage(hc, "nosuchtool", "No help found for nosuchtool")
+
+	if text, ok := cachedCommandHelp(hc, []string{"git", "status"}); !ok || !strings.HasPrefix(text, "git-status") {
+		t.Errorf("cachedCommandHelp(git status) = %q, %t; want the cached page", text, ok)
+	}
+	if text, ok := cachedCommandHelp(hc, []string{"nosuchtool"}); ok {
+		t.Errorf("cachedCommandHelp(nosuchtool) = %q; a cached failure should be looked up again", text)
+	}
+	if _, ok := cachedCommandHelp(hc, []string{"ls"}); ok {
+		t.Error("cachedCommandHelp(ls) found a page that was never cached")
+	}
+}
+
+func TestHelpSourcesStatus(t *testing.T) {
+	tests := []struct {
+		env  strategies.Environment
+		want string
+	}{
+		{strategies.Environment{}, "tldr, man pages, --help flags"},
+		{strategies.Environment{Busybox: true, ManMinimal: true}, "tldr, busybox applet usage, --help flags (man pages are missing or minimized)"},
+	}
+	for _, tt := range tests {
+		if got := helpSourcesStatus(tt.env); got != tt.want {
+			t.Errorf("helpSourcesStatus(%+v) = %q; want %q", tt.env, got, tt.want)
+		}
+	}
+}
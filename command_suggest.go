@@ -0,0 +1,128 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// commonTools are suggested for mistyped commands ahead of other executables
+// on PATH, so a typo still gets a suggestion when the tool is not installed
+var commonTools = []string{
+	"kubectl", "docker", "git", "helm", "terraform", "aws", "gcloud", "az",
+	"npm", "yarn", "node", "python", "python3", "pip", "go", "cargo", "make",
+	"curl", "wget", "ssh", "scp", "rsync", "grep", "find", "sed", "awk", "tar",
+	"systemctl", "journalctl", "brew", "apt", "podman", "ansible", "vim",
+}
+
+var (
+	pathExecutablesOnce sync.Once
+	pathExecutablesList []string
+)
+
+// didYouMean returns a hint naming the known command closest to base, or ""
+// when base is installed or nothing is close enough to be a likely typo
+func didYouMean(base string) string {
+	if base == "" || strings.ContainsRune(base, filepath.Separator) {
+		return ""
+	}
+	if _, err := exec.LookPath(base); err == nil {
+		return ""
+	}
+
+	pathExecutablesOnce.Do(func() {
+		pathExecutablesList = executablesInPath(os.Getenv("PATH"))
+	})
+	suggestion := suggestCommand(base, append(commonTools[:len(commonTools):len(commonTools)], pathExecutablesList...))
+	if suggestion == "" {
+		return ""
+	}
+	return "Did you mean " + suggestion + "?"
+}
+
+// suggestCommand returns the candidate with the smallest edit distance to
+// name, preferring earlier candidates on ties. Only one edit is allowed for
+// names of up to four characters and two for longer ones.
+func suggestCommand(name string, candidates []string) string {
+	maxDistance := 2
+	if len(name) <= 4 {
+		maxDistance = 1
+	}
+
+	best, bestDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		if candidate == name {
+			continue
+		}
+		if d := levenshtein(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the number of single-character insertions, deletions
+// and substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// executablesInPath lists the names of the executables in the directories of
+// pathList, sorted and without duplicates
+func executablesInPath(pathList string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, dir := range filepath.SplitList(pathList) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || seen[entry.Name()] {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || info.Mode()&0111 == 0 {
+				continue
+			}
+			seen[entry.Name()] = true
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
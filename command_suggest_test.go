@@ -0,0 +1,89 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"git", "", 3},
+		{"kubctl", "kubectl", 1},
+		{"dokcer", "docker", 2},
+		{"gti", "git", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, tc := range testCases {
+		if got := levenshtein(tc.a, tc.b); got != tc.expected {
+			t.Errorf("levenshtein(%q, %q) = %d; want %d", tc.a, tc.b, got, tc.expected)
+		}
+	}
+}
+
+func TestSuggestCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{"kubctl", "kubectl"},
+		{"dokcer", "docker"},
+		{"terrafrom", "terraform"},
+		{"gi", "git"},
+		{"gti", ""}, // Two edits is too many for a short name
+		{"kubectl", ""},
+		{"frobnicate", ""},
+	}
+	for _, tc := range testCases {
+		if got := suggestCommand(tc.name, commonTools); got != tc.expected {
+			t.Errorf("suggestCommand(%q) = %q; want %q", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestExecutablesInPath(t *testing.T) {
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"mytool": 0755, "notes.txt": 0644} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got := executablesInPath(dir + string(os.PathListSeparator) + filepath.Join(dir, "missing"))
+	if len(got) != 1 || got[0] != "mytool" {
+		t.Errorf("executablesInPath = %v; want [mytool]", got)
+	}
+}
+
+func TestCommandHelpFailureTextSuggestsCommand(t *testing.T) {
+	err := errors.New("no help found")
+	if text := commandHelpFailureText([]string{"kubctl", "get", "pods"}, err); !strings.HasSuffix(text, "Did you mean kubectl?") {
+		t.Errorf("missing suggestion in %q", text)
+	}
+	if text := commandHelpFailureText([]string{"./kubctl"}, err); strings.Contains(text, "Did you mean") {
+		t.Errorf("paths should not get a suggestion: %q", text)
+	}
+}
@@ -15,15 +15,76 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/cybrota/recaller/strategies"
 	"gopkg.in/yaml.v3"
 )
 
 type HistoryConfig struct {
 	EnableFuzzing bool `yaml:"enable_fuzzing"`
+	SkipComments  bool `yaml:"skip_comments"`
+	// ShellWordMatch tokenizes query and commands like the shell and matches
+	// word by word, ignoring quoting differences. Overrides enable_fuzzing.
+	ShellWordMatch bool `yaml:"shell_word_match"`
+	// WordBoundaryMatch only matches the query at the start of a
+	// whitespace-delimited word, so "test" finds `go test` but not `latest`.
+	// Overrides enable_fuzzing.
+	WordBoundaryMatch bool `yaml:"word_boundary_match"`
+	// MatchMode picks the matching strategy by name: prefix, substring, word,
+	// word_start or shell_words. Empty keeps the boolean settings above.
+	MatchMode string `yaml:"match_mode"`
+	// Base commands (e.g. "recaller") whose invocations are never indexed
+	ExcludeCommands []string `yaml:"exclude_commands"`
+	// BoostCommands are base commands (e.g. "kubectl") whose invocations
+	// score twice as high, so a whole tool ranks above other matches
+	BoostCommands []string `yaml:"boost_commands"`
+	// IncludeRotated also reads rotated/archived history files (plain or .gz)
+	// next to the main history file
+	IncludeRotated bool `yaml:"include_rotated"`
+	// RotatedGlob overrides the pattern used to find rotated files, relative to
+	// the history file's directory (default: "<name>.*" and "<name>-*")
+	RotatedGlob string `yaml:"rotated_glob"`
+	// UsageSparkline tracks per-day usage counts while parsing history and shows
+	// them as a sparkline above the help pane
+	UsageSparkline bool `yaml:"usage_sparkline"`
+	// SparklineDays is the number of days covered by the sparkline
+	SparklineDays int `yaml:"sparkline_days"`
+	// ScoreFormula replaces the built-in ranking with an expression over
+	// frequency, recency, exact and length; empty or invalid uses the default
+	ScoreFormula string `yaml:"score_formula"`
+	// DefaultTop caps how many commands 'recaller history' prints; 0 prints all
+	DefaultTop int `yaml:"default_top"`
+	// CollapseConsecutive counts a run of identical consecutive commands as a
+	// single use, like HISTCONTROL=ignoredups
+	CollapseConsecutive bool `yaml:"collapse_consecutive"`
+	// FrequencyDecayDays ages frequencies for ranking: each use counts half as
+	// much every this many days. 0 ranks by plain counts.
+	FrequencyDecayDays int `yaml:"frequency_decay_days"`
+	// CopyStripEnvPrefix drops leading VAR=value assignments from commands
+	// copied or sent to a terminal, so `FOO=bar go build` becomes `go build`
+	CopyStripEnvPrefix bool `yaml:"copy_strip_env_prefix"`
+	// LiveReload adds commands run in other terminals to the open history UI
+	// by following the history file
+	LiveReload bool `yaml:"live_reload"`
+	// EnterAction is what Enter does with the selected command: "copy" it to
+	// the clipboard, "send" it to a new terminal or "run" it in place. <C-e>
+	// and <C-o> perform the other two.
+	EnterAction string `yaml:"enter_action"`
+	// DiffOutput captures the output of commands run here, keeps it for the
+	// next run of the same command and shows what changed since the last one
+	DiffOutput bool `yaml:"diff_output"`
+	// TypoTolerance also lists commands whose words are a typo away from the
+	// query's, such as `git status` for "gti status", below the real matches.
+	// It compares every command word by word, so it is slower.
+	TypoTolerance bool `yaml:"typo_tolerance"`
 }
 
 type FilesystemConfig struct {
@@ -36,25 +97,146 @@ type FilesystemConfig struct {
 	SketchWidth        int      `yaml:"sketch_width"`
 	SketchDepth        int      `yaml:"sketch_depth"`
 	AutoIndexOnStartup bool     `yaml:"auto_index_on_startup"`
-	IndexCacheDuration int      `yaml:"index_cache_duration_hours"`
+	// IndexCacheDuration is how many hours `recaller fs` trusts the index before
+	// re-indexing the tracked roots on launch; 0 re-indexes on every launch
+	IndexCacheDuration int `yaml:"index_cache_duration_hours"`
+	// HashContents stores a content hash per file for `recaller fs duplicates`
+	HashContents bool `yaml:"hash_contents"`
+	// HashMaxFileSizeMB skips hashing files larger than this (0 = no limit)
+	HashMaxFileSizeMB int64 `yaml:"hash_max_file_size_mb"`
+	// IndexModifiedWithinDays skips files not modified in this many days while
+	// indexing (directories are still walked). 0 indexes everything.
+	IndexModifiedWithinDays int `yaml:"index_modified_within_days"`
+	// GitTrackedOnly indexes only the files `git ls-files` lists (and their
+	// directories) for roots inside a git repository; other roots are walked
+	// in full
+	GitTrackedOnly bool `yaml:"git_tracked_only"`
+	// IndexShowETA counts entries before indexing so the progress bar can show
+	// a percentage, speed and ETA
+	IndexShowETA bool `yaml:"index_show_eta"`
+	// ETACountLimit skips the pre-count (no ETA) for trees with more entries
+	// than this; 0 always counts
+	ETACountLimit int `yaml:"eta_count_limit"`
+	// IndexTags stores Finder tags (macOS) or the TagsXattr attribute (Linux)
+	// so files can be searched with "#tag"
+	IndexTags bool `yaml:"index_tags"`
+	// TagsXattr is the comma-separated tags attribute read on Linux
+	// (default "user.xdg.tags"); empty on macOS means Finder tags
+	TagsXattr string `yaml:"tags_xattr"`
+	// PriorityPaths are path prefixes (e.g. current projects) whose files and
+	// directories get a score boost in search results
+	PriorityPaths []string `yaml:"priority_paths"`
+	// NoAutoRefreshPaths are index roots (e.g. occasionally attached drives)
+	// that stay searchable but are skipped when tracked roots are re-indexed
+	NoAutoRefreshPaths []string `yaml:"no_auto_refresh_paths"`
+	// StatTimeoutSeconds abandons a directory tree when reading a single entry
+	// takes longer than this, e.g. on an unresponsive network mount. 0 waits forever.
+	StatTimeoutSeconds int `yaml:"stat_timeout_seconds"`
+	// IndexTimeoutSeconds stops indexing after this long, keeping what was
+	// indexed so far. 0 means no deadline.
+	IndexTimeoutSeconds int `yaml:"index_timeout_seconds"`
+	// IndexPath is where the index is stored; empty uses
+	// $XDG_STATE_HOME/recaller/fs_index.bin or an existing ~/.recaller_fs_index.bin
+	IndexPath string `yaml:"index_path"`
+	// OpenWith maps a file extension (e.g. "md") to the command that opens it
+	// from the fs UI instead of the system default application
+	OpenWith map[string]OpenWithRule `yaml:"open_with"`
+}
+
+// OpenWithRule is the command used to open files with one extension
+type OpenWithRule struct {
+	// Command runs with the quoted file path appended, or substituted for {}
+	Command string `yaml:"command"`
+	// Terminal runs the command in this terminal once the UI closes (editors,
+	// pagers) instead of launching it in the background
+	Terminal bool `yaml:"terminal"`
+}
+
+type HelpConfig struct {
+	// MaxConcurrentFetches bounds simultaneous help subprocesses/HTTP requests
+	MaxConcurrentFetches int `yaml:"max_concurrent_fetches"`
+	// DebounceMs delays fetching help until the selection has settled
+	DebounceMs int `yaml:"debounce_ms"`
+	// CacheBySubcommand shares help between commands that differ only in their
+	// arguments by looking up and caching help for the base command and its
+	// subcommands, e.g. "git commit" for `git commit -m "fix"`
+	CacheBySubcommand bool `yaml:"cache_by_subcommand"`
+	// CacheMaxMB caps the memory used by cached help pages; the least recently
+	// viewed pages are evicted beyond it. 0 means no limit.
+	CacheMaxMB int `yaml:"cache_max_mb"`
+	// PrefetchNeighbors fetches help in the background for this many
+	// suggestions above and below the selection. 0 turns prefetching off.
+	PrefetchNeighbors int `yaml:"prefetch_neighbors"`
+	// GenericMaxDepth is how many sub-command words are kept when appending
+	// --help to commands without a dedicated strategy; shorter lists are
+	// tried when the full one fails
+	GenericMaxDepth int `yaml:"generic_max_depth"`
+	// DiskCache keeps fetched help pages in the help cache file (see
+	// GetHelpCachePath) so they are shown at once in later runs (see
+	// `recaller help warm`)
+	DiskCache bool `yaml:"disk_cache"`
+	// DiskCacheDays is how long a persisted page is reused before it is
+	// looked up again
+	DiskCacheDays int `yaml:"disk_cache_days"`
+}
+
+type UIConfig struct {
+	// ShowScore toggles the ranking score line in the file info pane
+	ShowScore bool `yaml:"show_score"`
+	// ScorePrecision is the number of decimals used for the raw score
+	ScorePrecision int `yaml:"score_precision"`
+	// ScoreLabels shows High/Medium/Low relative to the top result instead of a number
+	ScoreLabels bool `yaml:"score_labels"`
+	// ShowBanner prints the ASCII logo in command help; off shows a one-line title
+	ShowBanner bool `yaml:"show_banner"`
+	// RecencyBadges prefixes history results with how long ago they were last used
+	RecencyBadges bool `yaml:"recency_badges"`
+	// BadgeFreshHours colors badges green below this age
+	BadgeFreshHours int `yaml:"badge_fresh_hours"`
+	// BadgeRecentHours colors badges yellow below this age; older ones are dimmed
+	BadgeRecentHours int `yaml:"badge_recent_hours"`
+	// HelpCopyFormat is how <C-x> copies help: "plain" strips terminal
+	// escapes and overstrikes, "raw" keeps the text as fetched (e.g. tldr markdown)
+	HelpCopyFormat string `yaml:"help_copy_format"`
+	// BorderTheme sets the border colors marking the focused panel:
+	// "default" (cyan, others white) or "high-contrast" (bold magenta, others
+	// gray, readable on light and dark terminals)
+	BorderTheme string `yaml:"border_theme"`
+	// FocusedBorderColor and BlurredBorderColor override the theme's colors
+	// with a color name (e.g. "yellow") or a 256-color index (e.g. "208")
+	FocusedBorderColor string `yaml:"focused_border_color"`
+	BlurredBorderColor string `yaml:"blurred_border_color"`
 }
 
 type Config struct {
 	History    HistoryConfig    `yaml:"history"`
 	Filesystem FilesystemConfig `yaml:"filesystem"`
+	Help       HelpConfig       `yaml:"help"`
+	UI         UIConfig         `yaml:"ui"`
+	Safety     SafetyConfig     `yaml:"safety"`
+	Search     SearchConfig     `yaml:"search"`
 	Quiet      bool             `yaml:"quiet"`
 }
 
 func cloneDefaultConfig() *Config {
 	cfg := defaultConfig
+	cfg.History.ExcludeCommands = append([]string{}, defaultConfig.History.ExcludeCommands...)
 	cfg.Filesystem.IndexDirectories = append([]string{}, defaultConfig.Filesystem.IndexDirectories...)
 	cfg.Filesystem.IgnorePatterns = append([]string{}, defaultConfig.Filesystem.IgnorePatterns...)
+	cfg.Safety.DangerousPatterns = append([]string{}, defaultConfig.Safety.DangerousPatterns...)
 	return &cfg
 }
 
 var defaultConfig = Config{
 	History: HistoryConfig{
-		EnableFuzzing: true,
+		EnableFuzzing:   true,
+		SkipComments:    true,
+		ExcludeCommands: []string{"recaller"},
+		UsageSparkline:  false,
+		SparklineDays:   30,
+		DefaultTop:      20,
+		LiveReload:      true,
+		EnterAction:     "copy",
 	},
 	Filesystem: FilesystemConfig{
 		Enabled:            false,
@@ -67,19 +249,47 @@ var defaultConfig = Config{
 		SketchDepth:        4,
 		AutoIndexOnStartup: false,
 		IndexCacheDuration: 24,
+		HashContents:       false,
+		HashMaxFileSizeMB:  1024,
+		ETACountLimit:      200000,
+		StatTimeoutSeconds: 30,
+	},
+	Help: HelpConfig{
+		MaxConcurrentFetches: 2,
+		DebounceMs:           150,
+		CacheMaxMB:           64,
+		PrefetchNeighbors:    2,
+		GenericMaxDepth:      strategies.DefaultGenericMaxDepth,
+		DiskCacheDays:        7,
+	},
+	UI: UIConfig{
+		ShowScore:        true,
+		ScorePrecision:   2,
+		ScoreLabels:      false,
+		ShowBanner:       true,
+		RecencyBadges:    true,
+		BadgeFreshHours:  24,
+		BadgeRecentHours: 24 * 7,
+		HelpCopyFormat:   "plain",
+		BorderTheme:      "default",
+	},
+	Safety: SafetyConfig{
+		DangerousPatterns: defaultDangerousPatterns,
+	},
+	Search: SearchConfig{
+		CommandSigil: ">",
+		FileSigil:    "@",
 	},
 }
 
 func LoadConfig() (*Config, error) {
 	defaultCfg := cloneDefaultConfig()
 
-	homeDir, err := os.UserHomeDir()
+	configPath, err := getConfigPath()
 	if err != nil {
 		return defaultCfg, fmt.Errorf("failed to determine home directory: %w", err)
 	}
 
-	configPath := filepath.Join(homeDir, ".recaller.yaml")
-
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return defaultCfg, nil
 	} else if err != nil {
@@ -91,33 +301,67 @@ func LoadConfig() (*Config, error) {
 		return defaultCfg, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	// Decode on top of the defaults so settings missing from the file keep their default values
+	config := cloneDefaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
 		return defaultCfg, fmt.Errorf("failed to parse config file: %w", err)
 	}
+	if err := config.History.checkMatchMode(); err != nil {
+		log.Printf("%v; using %s matching", err, config.History.activeMatchMode())
+	}
+	if err := config.History.checkScoreFormula(); err != nil {
+		log.Printf("%v; using the default ranking", err)
+	}
+	if err := config.Filesystem.checkSketchSize(); err != nil {
+		log.Printf("%v; using a smaller sketch", err)
+	}
 
-	return &config, nil
+	return config, nil
 }
 
+// getConfigPath returns $XDG_CONFIG_HOME/recaller/config.yaml (under
+// ~/.config by default), moving an existing ~/.recaller.yaml there first
 func getConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
+	return recallerFilePath(os.Getenv, ".recaller.yaml", "XDG_CONFIG_HOME", "config.yaml", true)
+}
+
+// displayConfigPath is the config file location to show in messages
+func displayConfigPath() string {
+	if configPath, err := getConfigPath(); err == nil {
+		return configPath
+	}
+	return "~/.recaller.yaml"
+}
+
+// configFileExists reports whether the config file has been written
+func configFileExists() bool {
+	configPath, err := getConfigPath()
 	if err != nil {
-		return "", err
+		return false
 	}
-	return filepath.Join(homeDir, ".recaller.yaml"), nil
+	_, err = os.Stat(configPath)
+	return err == nil
 }
 
 func createDefaultConfigFile() error {
+	return writeConfigFile(&defaultConfig)
+}
+
+// writeConfigFile saves config to the config file, replacing any existing one
+func writeConfigFile(config *Config) error {
 	configPath, err := getConfigPath()
 	if err != nil {
 		return fmt.Errorf("failed to get config path: %v", err)
 	}
 
-	data, err := yaml.Marshal(&defaultConfig)
+	data, err := yaml.Marshal(config)
 	if err != nil {
-		return fmt.Errorf("failed to marshal default config: %v", err)
+		return fmt.Errorf("failed to marshal config: %v", err)
 	}
 
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
 	err = os.WriteFile(configPath, data, 0644)
 	if err != nil {
 		return fmt.Errorf("failed to write config file: %v", err)
@@ -126,16 +370,87 @@ func createDefaultConfigFile() error {
 	return nil
 }
 
+// editorCommand returns the user's editor: $VISUAL, then $EDITOR, then vi
+func editorCommand(getenv func(string) string) string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if editor := strings.TrimSpace(getenv(name)); editor != "" {
+			return editor
+		}
+	}
+	return "vi"
+}
+
+// validateConfigData checks that data parses as a recaller configuration,
+// rejecting unknown keys so typos are reported rather than ignored
+func validateConfigData(data []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	config := cloneDefaultConfig()
+	if err := decoder.Decode(config); err != nil && err != io.EOF {
+		return err
+	}
+	if err := config.History.checkMatchMode(); err != nil {
+		return err
+	}
+	if err := config.History.checkScoreFormula(); err != nil {
+		return err
+	}
+	return config.Filesystem.checkSketchSize()
+}
+
+// editConfigFile opens the config file in the user's editor, creating it
+// with the defaults first if needed, and validates it once the editor exits.
+// While the file is invalid it offers to edit it again.
+func editConfigFile(in io.Reader, out io.Writer) error {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return fmt.Errorf("failed to get config path: %w", err)
+	}
+
+	if !configFileExists() {
+		if err := createDefaultConfigFile(); err != nil {
+			return err
+		}
+		cliFprintf(out, "✅ Created default configuration at: %s\n", configPath)
+	}
+
+	editor := editorCommand(os.Getenv)
+	reader := bufio.NewReader(in)
+	for {
+		if err := runShellCommand(openWithCommandLine(OpenWithRule{Command: editor}, configPath), true); err != nil {
+			return fmt.Errorf("editor %q failed: %w", editor, err)
+		}
+
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		err = validateConfigData(data)
+		if err == nil {
+			cliFprintf(out, "✅ %s is valid.\n", configPath)
+			return nil
+		}
+
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("%s is invalid: %w", configPath, err)
+		}
+		cliFprintf(out, "❌ %s is invalid: %v\n", configPath, err)
+		if !promptYesNo(reader, out, "✏️  Edit it again?", false) {
+			return fmt.Errorf("%s is still invalid", configPath)
+		}
+	}
+}
+
 func displaySettings() {
 	configPath, err := getConfigPath()
 	if err != nil {
-		fmt.Printf("❌ Failed to get config path: %v\n", err)
+		cliPrintf("❌ Failed to get config path: %v\n", err)
 		return
 	}
 
 	config, err := LoadConfig()
 	if err != nil {
-		fmt.Printf("❌ Failed to load configuration: %v\n", err)
+		cliPrintf("❌ Failed to load configuration: %v\n", err)
 		return
 	}
 
@@ -147,46 +462,78 @@ func displaySettings() {
 	configExists := true
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		configExists = false
-		fmt.Printf("📝 Configuration file not found. Creating default configuration...\n\n")
+		cliPrintf("📝 Configuration file not found. Creating default configuration...\n\n")
 
 		if err := createDefaultConfigFile(); err != nil {
-			fmt.Printf("❌ Failed to create default config file: %v\n", err)
+			cliPrintf("❌ Failed to create default config file: %v\n", err)
 			return
 		}
-		fmt.Printf("✅ Created default configuration at: %s\n\n", configPath)
+		cliPrintf("✅ Created default configuration at: %s\n\n", configPath)
 	}
 
-	fmt.Printf("🔧 Recaller Configuration Settings\n")
-	fmt.Printf("═══════════════════════════════════\n\n")
+	cliPrintf("🔧 Recaller Configuration Settings\n")
+	cliPrintf("═══════════════════════════════════\n\n")
 
 	if configExists {
-		fmt.Printf("📍 Config file: %s\n", configPath)
+		cliPrintf("📍 Config file: %s\n", configPath)
 	} else {
-		fmt.Printf("📍 Config file: %s (newly created)\n", configPath)
+		cliPrintf("📍 Config file: %s (newly created)\n", configPath)
 	}
 
-	fmt.Printf("Current settings:\n\n")
+	cliPrintf("Current settings:\n\n")
 
-	fmt.Printf("🔘 %sVerbosity:%s\n", Green, Reset)
+	cliPrintf("🔘 %sVerbosity:%s\n", Green, Reset)
 
 	quietValue := "true"
 	if !config.Quiet {
 		quietValue = "false"
 	}
-	fmt.Printf("  • %squiet%s: %s\n\n", Green, Reset, quietValue)
+	cliPrintf("  • %squiet%s: %s\n\n", Green, Reset, quietValue)
 
-	fmt.Printf("🔍 %sHistory Search:%s\n", Green, Reset)
+	cliPrintf("🔍 %sHistory Search:%s\n", Green, Reset)
 	fuzzyValue := "true"
 	fuzzyDesc := "Fuzzy search (substring matching anywhere)"
 	if !config.History.EnableFuzzing {
 		fuzzyValue = "false"
 		fuzzyDesc = "Prefix-based search (commands starting with query)"
 	}
+	activeMode := config.History.activeMatchMode()
+	if activeMode != (HistoryConfig{EnableFuzzing: config.History.EnableFuzzing}).activeMatchMode() {
+		fuzzyDesc = fmt.Sprintf("Overridden by the active %s match mode", activeMode)
+	}
 
-	fmt.Printf("  • %senable_fuzzing%s: %s\n", Green, Reset, fuzzyValue)
-	fmt.Printf("    %s\n\n", fuzzyDesc)
+	cliPrintf("  • %senable_fuzzing%s: %s\n", Green, Reset, fuzzyValue)
+	cliPrintf("    %s\n", fuzzyDesc)
+	cliPrintf("  • %sskip_comments%s: %t\n", Green, Reset, config.History.SkipComments)
+	cliPrintf("  • %sshell_word_match%s: %t\n", Green, Reset, config.History.ShellWordMatch)
+	cliPrintf("  • %sword_boundary_match%s: %t\n", Green, Reset, config.History.WordBoundaryMatch)
+	matchModeValue := config.History.MatchMode
+	if matchModeValue == "" {
+		matchModeValue = "(unset)"
+	}
+	cliPrintf("  • %smatch_mode%s: %s (active: %s)\n", Green, Reset, matchModeValue, activeMode)
+	if err := config.History.checkMatchMode(); err != nil {
+		cliPrintf("    ⚠️  %v\n", err)
+	}
+	cliPrintf("  • %stypo_tolerance%s: %t\n", Green, Reset, config.History.TypoTolerance)
+	cliPrintf("  • %sexclude_commands%s: %v\n", Green, Reset, config.History.ExcludeCommands)
+	cliPrintf("  • %sboost_commands%s: %v\n", Green, Reset, config.History.BoostCommands)
+	cliPrintf("  • %sinclude_rotated%s: %t\n", Green, Reset, config.History.IncludeRotated)
+	cliPrintf("  • %scollapse_consecutive%s: %t\n", Green, Reset, config.History.CollapseConsecutive)
+	cliPrintf("  • %susage_sparkline%s: %t (%d days)\n", Green, Reset, config.History.UsageSparkline, config.History.SparklineDays)
+	cliPrintf("  • %sdefault_top%s: %d\n", Green, Reset, config.History.DefaultTop)
+	if config.History.FrequencyDecayDays > 0 {
+		cliPrintf("  • %sfrequency_decay_days%s: %d (uses count half as much every %d days)\n", Green, Reset, config.History.FrequencyDecayDays, config.History.FrequencyDecayDays)
+	} else {
+		cliPrintf("  • %sfrequency_decay_days%s: 0 (plain usage counts)\n", Green, Reset)
+	}
+	cliPrintf("  • %scopy_strip_env_prefix%s: %t\n", Green, Reset, config.History.CopyStripEnvPrefix)
+	cliPrintf("  • %slive_reload%s: %t\n", Green, Reset, config.History.LiveReload)
+	cliPrintf("  • %senter_action%s: %s\n", Green, Reset, config.History.EnterAction)
+	cliPrintf("  • %sdiff_output%s: %t\n", Green, Reset, config.History.DiffOutput)
+	cliPrintf("  • %sscore_formula%s: %s\n\n", Green, Reset, scoreFormulaDescription(config.History.ScoreFormula))
 
-	fmt.Printf("📁 %sFilesystem Search:%s\n", Green, Reset)
+	cliPrintf("📁 %sFilesystem Search:%s\n", Green, Reset)
 
 	fsEnabledValue := "false"
 	fsDesc := "Disabled - filesystem indexing is off"
@@ -195,24 +542,63 @@ func displaySettings() {
 		fsDesc = fmt.Sprintf("Enabled - indexing up to %d files", config.Filesystem.MaxIndexedFiles)
 	}
 
-	fmt.Printf("  • %senabled%s: %s\n", Green, Reset, fsEnabledValue)
-	fmt.Printf("    %s\n", fsDesc)
-	fmt.Printf("  • %sindex_directories%s: %v\n", Green, Reset, config.Filesystem.IndexDirectories)
-	fmt.Printf("  • %smax_indexed_files%s: %d\n", Green, Reset, config.Filesystem.MaxIndexedFiles)
-	fmt.Printf("  • %sauto_index_on_startup%s: %t\n\n", Green, Reset, config.Filesystem.AutoIndexOnStartup)
+	cliPrintf("  • %senabled%s: %s\n", Green, Reset, fsEnabledValue)
+	cliPrintf("    %s\n", fsDesc)
+	cliPrintf("  • %sindex_directories%s: %v\n", Green, Reset, config.Filesystem.IndexDirectories)
+	cliPrintf("  • %smax_indexed_files%s: %d\n", Green, Reset, config.Filesystem.MaxIndexedFiles)
+	cliPrintf("  • %sauto_index_on_startup%s: %t\n", Green, Reset, config.Filesystem.AutoIndexOnStartup)
+	cliPrintf("  • %sindex_cache_duration_hours%s: %d\n", Green, Reset, config.Filesystem.IndexCacheDuration)
+	cliPrintf("  • %shash_contents%s: %t\n", Green, Reset, config.Filesystem.HashContents)
+	cliPrintf("  • %spriority_paths%s: %v\n", Green, Reset, config.Filesystem.PriorityPaths)
+	cliPrintf("  • %sgit_tracked_only%s: %t\n", Green, Reset, config.Filesystem.GitTrackedOnly)
+	cliPrintf("  • %sno_auto_refresh_paths%s: %v\n", Green, Reset, config.Filesystem.NoAutoRefreshPaths)
+	cliPrintf("  • %sstat_timeout_seconds%s: %d\n", Green, Reset, config.Filesystem.StatTimeoutSeconds)
+	cliPrintf("  • %sindex_timeout_seconds%s: %d\n", Green, Reset, config.Filesystem.IndexTimeoutSeconds)
+	cliPrintf("  • %sindex_path%s: %s\n\n", Green, Reset, resolveIndexPath(config.Filesystem.IndexPath, os.Getenv))
+
+	cliPrintf("📖 %sHelp Docs:%s\n", Green, Reset)
+	cliPrintf("  • %smax_concurrent_fetches%s: %d\n", Green, Reset, config.Help.MaxConcurrentFetches)
+	cliPrintf("  • %sdebounce_ms%s: %d\n", Green, Reset, config.Help.DebounceMs)
+	cliPrintf("  • %scache_by_subcommand%s: %t\n", Green, Reset, config.Help.CacheBySubcommand)
+	cliPrintf("  • %scache_max_mb%s: %d\n", Green, Reset, config.Help.CacheMaxMB)
+	cliPrintf("  • %sprefetch_neighbors%s: %d\n", Green, Reset, config.Help.PrefetchNeighbors)
+	cliPrintf("  • %sgeneric_max_depth%s: %d\n", Green, Reset, config.Help.GenericMaxDepth)
+	cliPrintf("  • %sdisk_cache%s: %t\n", Green, Reset, config.Help.DiskCache)
+	cliPrintf("  • %sdisk_cache_days%s: %d\n\n", Green, Reset, config.Help.DiskCacheDays)
+
+	cliPrintf("🖥️  %sInterface:%s\n", Green, Reset)
+	cliPrintf("  • %sshow_score%s: %t\n", Green, Reset, config.UI.ShowScore)
+	cliPrintf("  • %sscore_precision%s: %d\n", Green, Reset, config.UI.ScorePrecision)
+	cliPrintf("  • %sscore_labels%s: %t\n", Green, Reset, config.UI.ScoreLabels)
+	cliPrintf("  • %sshow_banner%s: %t\n", Green, Reset, config.UI.ShowBanner)
+	cliPrintf("  • %srecency_badges%s: %t (green < %dh, yellow < %dh)\n", Green, Reset, config.UI.RecencyBadges, config.UI.BadgeFreshHours, config.UI.BadgeRecentHours)
+	cliPrintf("  • %shelp_copy_format%s: %s\n", Green, Reset, config.UI.HelpCopyFormat)
+	cliPrintf("  • %sborder_theme%s: %s\n", Green, Reset, config.UI.BorderTheme)
+	if config.UI.FocusedBorderColor != "" || config.UI.BlurredBorderColor != "" {
+		cliPrintf("  • %sfocused_border_color%s: %s\n", Green, Reset, config.UI.FocusedBorderColor)
+		cliPrintf("  • %sblurred_border_color%s: %s\n", Green, Reset, config.UI.BlurredBorderColor)
+	}
+	cliPrintf("\n")
 
-	if !config.History.EnableFuzzing {
-		fmt.Printf("💡 Fuzzy search is disabled. To enable it, edit %s:\n", configPath)
-		fmt.Printf("   history:\n     enable_fuzzing: true\n\n")
+	cliPrintf("🛡️  %sSafety:%s\n", Green, Reset)
+	cliPrintf("  • %sdangerous_patterns%s: %d patterns (confirm before sending to a terminal)\n\n", Green, Reset, len(config.Safety.DangerousPatterns))
+
+	cliPrintf("🔎 %sCombined Search:%s\n", Green, Reset)
+	cliPrintf("  • %scommand_sigil%s: %q (commands only)\n", Green, Reset, config.Search.CommandSigil)
+	cliPrintf("  • %sfile_sigil%s: %q (files and directories only)\n\n", Green, Reset, config.Search.FileSigil)
+
+	if activeMode == matchModePrefix {
+		cliPrintf("💡 Only commands starting with the query match. To match words anywhere, edit %s:\n", configPath)
+		cliPrintf("   history:\n     match_mode: word\n\n")
 	} else {
-		fmt.Printf("💡 To use prefix-only search, edit %s:\n", configPath)
-		fmt.Printf("   history:\n     enable_fuzzing: false\n\n")
+		cliPrintf("💡 To use prefix-only search, edit %s:\n", configPath)
+		cliPrintf("   history:\n     match_mode: prefix\n\n")
 	}
 
 	if !config.Filesystem.Enabled {
-		fmt.Printf("💡 To enable filesystem search, edit %s:\n", configPath)
-		fmt.Printf("   filesystem:\n     enabled: true\n\n")
+		cliPrintf("💡 To enable filesystem search, edit %s:\n", configPath)
+		cliPrintf("   filesystem:\n     enabled: true\n\n")
 	}
 
-	fmt.Printf("📚 For more information, see: https://github.com/cybrota/recaller#search-modes\n")
+	cliPrintf("📚 For more information, see: https://github.com/cybrota/recaller#search-modes\n")
 }
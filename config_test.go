@@ -0,0 +1,168 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	testCases := []struct {
+		env      map[string]string
+		expected string
+	}{
+		{map[string]string{"VISUAL": "code -w", "EDITOR": "nano"}, "code -w"},
+		{map[string]string{"EDITOR": "nano"}, "nano"},
+		{map[string]string{"VISUAL": "  ", "EDITOR": "nano"}, "nano"},
+		{map[string]string{}, "vi"},
+	}
+
+	for _, tc := range testCases {
+		getenv := func(name string) string { return tc.env[name] }
+		if got := editorCommand(getenv); got != tc.expected {
+			t.Errorf("editorCommand(%v) = %q; want %q", tc.env, got, tc.expected)
+		}
+	}
+}
+
+func TestValidateConfigData(t *testing.T) {
+	testCases := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"empty", "", true},
+		{"valid", "history:\n  enable_fuzzing: false\nquiet: true\n", true},
+		{"syntax error", "history:\n  enable_fuzzing: [\n", false},
+		{"wrong type", "filesystem:\n  max_indexed_files: lots\n", false},
+		{"unknown key", "history:\n  enable_fuzing: false\n", false},
+		{"known match mode", "history:\n  match_mode: word\n", true},
+		{"unknown match mode", "history:\n  match_mode: fuzzy\n", false},
+		{"valid score formula", "history:\n  score_formula: \"frequency * 2\"\n", true},
+		{"invalid score formula", "history:\n  score_formula: \"frequency *\"\n", false},
+		{"sketch at limit", "filesystem:\n  sketch_width: 16777216\n  sketch_depth: 4\n", true},
+		{"sketch over limit", "filesystem:\n  sketch_width: 16777217\n  sketch_depth: 4\n", false},
+	}
+
+	for _, tc := range testCases {
+		err := validateConfigData([]byte(tc.data))
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: validateConfigData error = %v; want valid=%t", tc.name, err, tc.valid)
+		}
+	}
+}
+
+// setTestHome points HOME and the XDG base directories at fresh temporary
+// directories, so a test never reads or writes the developer's own files
+func setTestHome(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{"HOME", "XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME"} {
+		t.Setenv(key, t.TempDir())
+	}
+}
+
+func TestEditConfigFileCreatesAndValidates(t *testing.T) {
+	setTestHome(t)
+	t.Setenv("SHELL", "/bin/sh")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "true")
+
+	var out bytes.Buffer
+	if err := editConfigFile(strings.NewReader(""), &out); err != nil {
+		t.Fatalf("editConfigFile: %v", err)
+	}
+	if !configFileExists() {
+		t.Fatal("editConfigFile should create the default config file")
+	}
+	if !strings.Contains(out.String(), "is valid") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	// An editor that leaves a typo behind is reported
+	t.Setenv("EDITOR", "echo 'quiett: true' >>")
+	out.Reset()
+	err := editConfigFile(strings.NewReader("n\n"), &out)
+	if err == nil {
+		t.Fatal("editConfigFile should fail when the file is left invalid")
+	}
+	if report := out.String() + err.Error(); !strings.Contains(report, "quiett") {
+		t.Errorf("unknown key not reported: %q", report)
+	}
+
+	configPath, _ := getConfigPath()
+	if data, _ := os.ReadFile(configPath); !strings.Contains(string(data), "quiett") {
+		t.Errorf("editor change not written: %q", data)
+	}
+}
+
+func TestGetConfigPathMigratesToXDG(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	legacy := filepath.Join(home, ".recaller.yaml")
+
+	// Without XDG_CONFIG_HOME the spec's ~/.config is used
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if got, _ := getConfigPath(); got != filepath.Join(home, ".config", "recaller", "config.yaml") {
+		t.Errorf("getConfigPath() = %q; want it under ~/.config", got)
+	}
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	want := filepath.Join(xdg, "recaller", "config.yaml")
+	if got, _ := getConfigPath(); got != want {
+		t.Errorf("getConfigPath() = %q; want %q", got, want)
+	}
+
+	// A legacy file is moved on first use and its settings kept
+	if err := os.WriteFile(legacy, []byte("quiet: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !config.Quiet {
+		t.Error("settings from the legacy config file were lost")
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy config file should have been moved, stat err = %v", err)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("config file not moved to %s: %v", want, err)
+	}
+}
+
+func TestRecallerFilePathKeepsLegacyCaches(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cache := t.TempDir()
+	getenv := func(string) string { return cache }
+	legacy := filepath.Join(home, ".recaller_help_cache.json")
+	if err := os.WriteFile(legacy, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := recallerFilePath(getenv, ".recaller_help_cache.json", "XDG_CACHE_HOME", "help_cache.json", false)
+	if err != nil || got != legacy {
+		t.Errorf("recallerFilePath() = %q, %v; want %q", got, err, legacy)
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		t.Errorf("legacy cache should stay in place: %v", err)
+	}
+}
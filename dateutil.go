@@ -18,6 +18,7 @@
 package main
 
 import (
+	"fmt"
 	"strings"
 	"time"
 )
@@ -78,6 +79,14 @@ func Parse(format string, value string) (time.Time, error) {
 	return time.Parse(replace(format), value)
 }
 
+// ParseInLocation is like Parse but interprets values without a time zone in loc
+func ParseInLocation(format string, value string, loc *time.Location) (time.Time, error) {
+	if format == "" {
+		format = DefaultDateTimeFormat
+	}
+	return time.ParseInLocation(replace(format), value, loc)
+}
+
 type p struct{ find, subst string }
 
 var Placeholder = []p{
@@ -142,6 +151,30 @@ func Translate(fmt string) string {
 	return replace(fmt)
 }
 
+// RelativeTime formats the time elapsed between t and now compactly, e.g.
+// "now", "5m", "3h", "2d", "4w", "6mo" or "1y". Future times read as "now".
+func RelativeTime(t time.Time, now time.Time) string {
+	elapsed := now.Sub(t)
+	day := 24 * time.Hour
+
+	switch {
+	case elapsed < time.Minute:
+		return "now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm", int(elapsed/time.Minute))
+	case elapsed < day:
+		return fmt.Sprintf("%dh", int(elapsed/time.Hour))
+	case elapsed < 7*day:
+		return fmt.Sprintf("%dd", int(elapsed/day))
+	case elapsed < 30*day:
+		return fmt.Sprintf("%dw", int(elapsed/(7*day)))
+	case elapsed < 365*day:
+		return fmt.Sprintf("%dmo", int(elapsed/(30*day)))
+	default:
+		return fmt.Sprintf("%dy", int(elapsed/(365*day)))
+	}
+}
+
 // daysToWeekend calculates how many days remain until Saturday.
 // If today's Saturday or Sunday, returns 0.
 func DaysToWeekend() int {
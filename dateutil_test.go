@@ -134,3 +134,26 @@ func TestDaysToWeekend(t *testing.T) {
 		t.Errorf("DaysToWeekend: expected %d, got %d", expected, result)
 	}
 }
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		ago  time.Duration
+		want string
+	}{
+		{-time.Hour, "now"},
+		{30 * time.Second, "now"},
+		{5 * time.Minute, "5m"},
+		{3 * time.Hour, "3h"},
+		{50 * time.Hour, "2d"},
+		{15 * 24 * time.Hour, "2w"},
+		{100 * 24 * time.Hour, "3mo"},
+		{800 * 24 * time.Hour, "2y"},
+	}
+
+	for _, tt := range tests {
+		if got := RelativeTime(now.Add(-tt.ago), now); got != tt.want {
+			t.Errorf("RelativeTime(%v ago) = %q; want %q", tt.ago, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// doctorCheck is one environment check run by `recaller doctor`
+type doctorCheck struct {
+	Name string
+	Run  func() (string, bool)
+}
+
+var doctorChecks = []doctorCheck{
+	{"Clipboard", checkClipboard},
+	{"Configuration", checkConfigFile},
+	{"Help sources", checkHelpSources},
+}
+
+// checkConfigFile reports whether the config file (see getConfigPath), if
+// present, is valid
+func checkConfigFile() (string, bool) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return err.Error(), false
+	}
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return "no config file, using defaults", true
+	} else if err != nil {
+		return err.Error(), false
+	}
+	if err := validateConfigData(data); err != nil {
+		return fmt.Sprintf("%s is invalid: %v", configPath, err), false
+	}
+	return configPath, true
+}
+
+// runDoctor runs every check, printing one line per check, and reports
+// whether they all passed
+func runDoctor(w io.Writer) bool {
+	healthy := true
+	for _, check := range doctorChecks {
+		status, ok := check.Run()
+		mark := "✅"
+		if !ok {
+			mark = "❌"
+			healthy = false
+		}
+		cliFprintf(w, "%s %s%s%s: %s\n", mark, Green, check.Name, Reset, status)
+	}
+	return healthy
+}
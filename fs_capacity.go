@@ -0,0 +1,115 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// indexHeaderSize is the fixed header of an index file: magic, version,
+// record and root counts, reserved bytes and the last indexed time
+const indexHeaderSize = 8 + 4 + 4 + 4 + 12 + 8
+
+// indexDiskWarnRatio is the share of the free space that growing the index
+// to max_indexed_files may take before `recaller fs stats` warns
+const indexDiskWarnRatio = 0.5
+
+// IndexCapacity compares the size the index file reaches at
+// max_indexed_files with the free space on the volume holding it
+type IndexCapacity struct {
+	MaxFiles       int
+	CurrentBytes   int64 // Size of the index file now; 0 before it is saved
+	ProjectedBytes int64 // Estimated size with MaxFiles records
+	FreeBytes      int64 // Space available on the index volume; -1 when unknown
+	Volume         string
+}
+
+// IndexCapacity estimates the on-disk size of the index holding
+// max_indexed_files records (the fixed-size records plus the header, root
+// paths, bloom filter, sketch and the tags stored so far) and reads the free
+// space where the index is stored
+func (fi *FilesystemIndexer) IndexCapacity() IndexCapacity {
+	fixed := int64(indexHeaderSize + fi.countMinSketch.SizeBytes())
+	for _, rootPath := range fi.rootPaths {
+		fixed += int64(4 + len(rootPath))
+	}
+	if n, err := fi.bloomFilter.WriteTo(io.Discard); err == nil {
+		fixed += n
+	}
+	var tags bytes.Buffer
+	if err := fi.writeTags(&tags); err == nil {
+		fixed += int64(tags.Len())
+	}
+
+	capacity := IndexCapacity{
+		MaxFiles:       fi.config.MaxIndexedFiles,
+		ProjectedBytes: fixed + int64(fi.config.MaxIndexedFiles)*PathRecordSize,
+		FreeBytes:      -1,
+	}
+	capacity.CurrentBytes, _ = fi.GetIndexFileSize()
+	capacity.Volume, capacity.FreeBytes = freeDiskSpace(filepath.Dir(fi.GetIndexPath()))
+	return capacity
+}
+
+// Growth is how many more bytes the index takes once it reaches MaxFiles
+func (ic IndexCapacity) Growth() int64 {
+	return max(ic.ProjectedBytes-ic.CurrentBytes, 0)
+}
+
+// AtRisk reports whether growing the index to MaxFiles would take more than
+// indexDiskWarnRatio of the free space
+func (ic IndexCapacity) AtRisk() bool {
+	return ic.FreeBytes >= 0 && float64(ic.Growth()) > indexDiskWarnRatio*float64(ic.FreeBytes)
+}
+
+// freeDiskSpace returns the space available to this user on the volume
+// holding dir, measured at dir or its closest existing parent, or -1 when
+// it cannot be read
+func freeDiskSpace(dir string) (string, int64) {
+	for {
+		var stat unix.Statfs_t
+		if err := unix.Statfs(dir, &stat); err == nil {
+			return dir, int64(stat.Bavail) * int64(stat.Bsize)
+		} else if !os.IsNotExist(err) {
+			return dir, -1
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir, -1
+		}
+		dir = parent
+	}
+}
+
+// printIndexCapacity writes the projected index size and free disk space,
+// warning when indexing up to the cap could fill the disk
+func printIndexCapacity(w io.Writer, capacity IndexCapacity) {
+	cliFprintf(w, "💾 At max_indexed_files (%d): about %s on disk (now %s)\n",
+		capacity.MaxFiles, formatFileSize(capacity.ProjectedBytes), formatFileSize(capacity.CurrentBytes))
+	if capacity.FreeBytes < 0 {
+		cliFprintf(w, "💽 Free space on %s: unknown\n", capacity.Volume)
+		return
+	}
+	cliFprintf(w, "💽 Free space on %s: %s\n", capacity.Volume, formatFileSize(capacity.FreeBytes))
+	if capacity.AtRisk() {
+		cliFprintf(w, "⚠️  Indexing up to the cap needs %s more, over half the free space. Lower max_indexed_files or move index_path to a larger volume.\n",
+			formatFileSize(capacity.Growth()))
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+	"os"
+	"sort"
+)
+
+// hashSampleSize is how much of the start and end of a file is hashed
+const hashSampleSize = 64 * 1024
+
+// DuplicateGroup is a set of indexed files with identical content hashes
+type DuplicateGroup struct {
+	Hash  uint64
+	Size  int64
+	Paths []string
+}
+
+// WastedBytes is the space that would be freed by keeping only one copy
+func (g DuplicateGroup) WastedBytes() int64 {
+	return g.Size * int64(len(g.Paths)-1)
+}
+
+// hashFileContents returns a fast fingerprint of a file: its size plus the
+// first and last 64KB. Files up to 128KB are hashed in full. It never
+// returns 0, which marks a record as not hashed.
+func hashFileContents(path string, size int64) (uint64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	h := fnv.New64a()
+	var sizeBytes [8]byte
+	binary.LittleEndian.PutUint64(sizeBytes[:], uint64(size))
+	h.Write(sizeBytes[:])
+
+	if size <= 2*hashSampleSize {
+		if _, err := io.Copy(h, file); err != nil {
+			return 0, err
+		}
+	} else {
+		if _, err := io.CopyN(h, file, hashSampleSize); err != nil {
+			return 0, err
+		}
+		if _, err := file.Seek(size-hashSampleSize, io.SeekStart); err != nil {
+			return 0, err
+		}
+		if _, err := io.CopyN(h, file, hashSampleSize); err != nil {
+			return 0, err
+		}
+	}
+
+	sum := h.Sum64()
+	if sum == 0 {
+		sum = 1
+	}
+	return sum, nil
+}
+
+// contentHash (re)hashes a regular file whose record has not been hashed yet
+// or whose size or modification time changed. It returns false when record's
+// hash still stands or the file cannot be hashed; files over the configured
+// limit are skipped.
+func (fi *FilesystemIndexer) contentHash(record PathRecord, path string, info os.FileInfo) (uint64, bool) {
+	if !info.Mode().IsRegular() {
+		return 0, false
+	}
+	size := info.Size()
+	if limit := fi.config.HashMaxFileSizeMB; limit > 0 && size > limit*1024*1024 {
+		return 0, false
+	}
+	if record.ContentHash != 0 && record.Size == size && record.ModTime == info.ModTime().UnixNano() {
+		return 0, false
+	}
+
+	hash, err := hashFileContents(path, size)
+	if err != nil {
+		return 0, false
+	}
+	return hash, true
+}
+
+// FindDuplicates groups hashed, non-empty files by content hash and returns
+// groups with more than one path, largest wasted space first
+func (fi *FilesystemIndexer) FindDuplicates() []DuplicateGroup {
+	byHash := make(map[uint64]*DuplicateGroup)
+	for _, record := range fi.pathRecords {
+		if record.ContentHash == 0 || record.Size == 0 || record.Flags&FlagIsDirectory != 0 {
+			continue
+		}
+		group, ok := byHash[record.ContentHash]
+		if !ok {
+			group = &DuplicateGroup{Hash: record.ContentHash, Size: record.Size}
+			byHash[record.ContentHash] = group
+		}
+		group.Paths = append(group.Paths, fi.bytesToPath(record.Path))
+	}
+
+	var groups []DuplicateGroup
+	for _, group := range byHash {
+		if len(group.Paths) > 1 {
+			sort.Strings(group.Paths)
+			groups = append(groups, *group)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].WastedBytes() != groups[j].WastedBytes() {
+			return groups[i].WastedBytes() > groups[j].WastedBytes()
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// indexExportFormats are the formats `recaller fs export` writes
+var indexExportFormats = []string{"json", "csv"}
+
+// ExportedRecord is one index entry as written by `recaller fs export`
+type ExportedRecord struct {
+	Path        string     `json:"path"`
+	AccessCount int32      `json:"access_count"`
+	Timestamp   *time.Time `json:"timestamp"` // Last access, null if never recorded
+	IsDirectory bool       `json:"is_directory"`
+	IsHidden    bool       `json:"is_hidden"`
+	IsSymlink   bool       `json:"is_symlink"`
+	Size        int64      `json:"size"`
+}
+
+// ExportRecords returns the indexed entries that are, or are inside, root
+// (all of them when root is empty), in index order, with sizes as reported
+// by recordSize
+func (fi *FilesystemIndexer) ExportRecords(root string) []ExportedRecord {
+	var records []ExportedRecord
+	for _, record := range fi.pathRecords {
+		path := fi.bytesToPath(record.Path)
+		if root != "" && !isUnderRoot(path, root) {
+			continue
+		}
+		var timestamp *time.Time
+		if record.Timestamp > 0 {
+			t := time.Unix(record.Timestamp, 0).UTC()
+			timestamp = &t
+		}
+		records = append(records, ExportedRecord{
+			Path:        path,
+			AccessCount: record.AccessCount,
+			Timestamp:   timestamp,
+			IsDirectory: record.Flags&FlagIsDirectory != 0,
+			IsHidden:    record.Flags&FlagIsHidden != 0,
+			IsSymlink:   record.Flags&FlagIsSymlink != 0,
+			Size:        recordSize(record, path),
+		})
+	}
+	return records
+}
+
+// writeIndexExport writes records to w as a JSON array or as CSV with a
+// header row
+func writeIndexExport(w io.Writer, records []ExportedRecord, format string) error {
+	switch format {
+	case "json":
+		if records == nil {
+			records = []ExportedRecord{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"path", "access_count", "timestamp", "is_directory", "is_hidden", "is_symlink", "size"})
+		for _, record := range records {
+			var timestamp string
+			if record.Timestamp != nil {
+				timestamp = record.Timestamp.Format(time.RFC3339)
+			}
+			writer.Write([]string{
+				record.Path,
+				strconv.Itoa(int(record.AccessCount)),
+				timestamp,
+				strconv.FormatBool(record.IsDirectory),
+				strconv.FormatBool(record.IsHidden),
+				strconv.FormatBool(record.IsSymlink),
+				strconv.FormatInt(record.Size, 10),
+			})
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unknown export format %q (use %s)", format, strings.Join(indexExportFormats, " or "))
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExtensionStat counts the indexed files sharing one file extension
+type ExtensionStat struct {
+	Extension string // Lower-cased, dot included; "" for files without one
+	Files     int
+	Bytes     int64
+}
+
+// ExtensionStats breaks the indexed files down by extension, most common
+// first, with their sizes as reported by recordSize
+func (fi *FilesystemIndexer) ExtensionStats() []ExtensionStat {
+	byExtension := make(map[string]*ExtensionStat)
+	for _, record := range fi.pathRecords {
+		if record.Flags&FlagIsDirectory != 0 {
+			continue
+		}
+		path := fi.bytesToPath(record.Path)
+		extension := strings.ToLower(filepath.Ext(path))
+		stat, ok := byExtension[extension]
+		if !ok {
+			stat = &ExtensionStat{Extension: extension}
+			byExtension[extension] = stat
+		}
+		stat.Files++
+		stat.Bytes += recordSize(record, path)
+	}
+
+	stats := make([]ExtensionStat, 0, len(byExtension))
+	for _, stat := range byExtension {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Files != stats[j].Files {
+			return stats[i].Files > stats[j].Files
+		}
+		if stats[i].Bytes != stats[j].Bytes {
+			return stats[i].Bytes > stats[j].Bytes
+		}
+		return stats[i].Extension < stats[j].Extension
+	})
+	return stats
+}
+
+// recordSize is the size of the file behind record: the size stored when its
+// contents were hashed, or else its size on disk. Directories and files that
+// no longer exist count as empty.
+func recordSize(record PathRecord, path string) int64 {
+	if record.Size != 0 || record.Flags&FlagIsDirectory != 0 {
+		return record.Size
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		return info.Size()
+	}
+	return 0
+}
+
+// printExtensionStats writes the extension breakdown as a table, limited to
+// the top most common extensions unless top is 0
+func printExtensionStats(w io.Writer, stats []ExtensionStat, top int) {
+	shown := stats
+	if top > 0 && len(shown) > top {
+		shown = shown[:top]
+	}
+
+	cliFprintf(w, "\n📂 Indexed files by extension:\n")
+	cliFprintf(w, "  %-16s %10s %12s\n", "Extension", "Files", "Size")
+	for _, stat := range shown {
+		cliFprintf(w, "  %-16s %10d %12s\n", extensionLabel(stat.Extension), stat.Files, formatFileSize(stat.Bytes))
+	}
+
+	if hidden := len(stats) - len(shown); hidden > 0 {
+		var files int
+		var bytes int64
+		for _, stat := range stats[len(shown):] {
+			files += stat.Files
+			bytes += stat.Bytes
+		}
+		cliFprintf(w, "  %-16s %10d %12s\n", fmt.Sprintf("(%d more)", hidden), files, formatFileSize(bytes))
+	}
+}
+
+// extensionLabel is how an extension is shown in the breakdown table
+func extensionLabel(extension string) string {
+	if extension == "" {
+		return "(none)"
+	}
+	return extension
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// gitLsFilesTimeout bounds listing the tracked files of one repository
+const gitLsFilesTimeout = 30 * time.Second
+
+// trackedPaths is the set of paths git_tracked_only indexes under a root:
+// the files git tracks and the directories leading to them
+type trackedPaths map[string]bool
+
+// gitTrackedPaths lists the files git tracks under rootPath, including those
+// of submodules, keyed as the walk of rootPath reaches them. It returns nil
+// when git_tracked_only is off, rootPath is not inside a git work tree or git
+// cannot be run, so the whole tree is indexed.
+func (fi *FilesystemIndexer) gitTrackedPaths(ctx context.Context, rootPath string) trackedPaths {
+	if !fi.config.GitTrackedOnly {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, gitLsFilesTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "git", "-C", rootPath, "ls-files", "-z", "--recurse-submodules").Output()
+	if err != nil {
+		fi.logger.Printf("Indexing all of %s: not listing git-tracked files (%v)", rootPath, err)
+		return nil
+	}
+
+	tracked := trackedPaths{rootPath: true}
+	for _, name := range bytes.Split(output, []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		// Mark the file and its directories up to the first one already seen
+		for path := filepath.Join(rootPath, filepath.FromSlash(string(name))); !tracked[path]; path = filepath.Dir(path) {
+			tracked[path] = true
+		}
+	}
+	return tracked
+}
+
+// filter wraps a walk function so it only sees tracked paths, skipping
+// untracked directories without descending into them
+func (tp trackedPaths) filter(fn fs.WalkDirFunc) fs.WalkDirFunc {
+	return func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !tp[path] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(path, d, err)
+	}
+}
@@ -15,6 +15,8 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -22,10 +24,15 @@ import (
 	"io"
 	"io/fs"
 	"log"
+	"maps"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -34,20 +41,47 @@ import (
 )
 
 const (
-	MaxPathLength   = 512                                                         // Fixed path length for binary representation
-	CountMinWidth   = 2048                                                        // Width of Count-Min Sketch
-	CountMinDepth   = 4                                                           // Depth of Count-Min Sketch
-	TimestampSize   = 8                                                           // int64 timestamp (8 bytes)
-	AccessCountSize = 4                                                           // int32 access count (4 bytes)
-	FlagsSize       = 1                                                           // uint8 flags (1 byte)
-	PathRecordSize  = MaxPathLength + TimestampSize + AccessCountSize + FlagsSize // Total: 525 bytes per record
+	MaxPathLength   = 512  // Fixed path length for binary representation
+	CountMinWidth   = 2048 // Width of Count-Min Sketch
+	CountMinDepth   = 4    // Depth of Count-Min Sketch
+	TimestampSize   = 8    // int64 timestamp (8 bytes)
+	AccessCountSize = 4    // int32 access count (4 bytes)
+	FlagsSize       = 1    // uint8 flags (1 byte)
+	ContentHashSize = 8    // uint64 content hash (8 bytes)
+	FileSizeSize    = 8    // int64 file size (8 bytes)
+	OwnerSize       = 4    // uint32 owner UID (4 bytes)
+	ModeSize        = 4    // uint32 permission bits (4 bytes)
+	ModTimeSize     = 8    // int64 modification time (8 bytes)
+	// Total: 557 bytes per record (549 in formats v6-v7, 541 in v4-v5, 525 before v4)
+	PathRecordSize = MaxPathLength + TimestampSize + AccessCountSize + FlagsSize + ContentHashSize + FileSizeSize + OwnerSize + ModeSize + ModTimeSize
 )
 
+// IndexFormatVersion is the on-disk index format written by SaveToFile.
+// LoadFromFile accepts this version and every older one listed in
+// supportedIndexVersions.
+//
+// Version 3 stores the count-min sketch dimensions in the header's reserved
+// bytes and uses Kirsch-Mitzenmacher hashing. Sketches from older versions are
+// discarded on load and rebuilt from the path records' access counts.
+// Version 4 adds a content hash and file size to each path record.
+// Version 5 appends a section of file tags after the path records.
+// Version 6 adds the owner UID and permission bits to each path record.
+// Version 7 records when the roots were last indexed after the header.
+// Version 8 adds the modification time a file was hashed at to each path
+// record, so a rewrite that keeps the size is rehashed.
+const IndexFormatVersion uint32 = 8
+
+var supportedIndexVersions = []uint32{1, 2, 3, 4, 5, 6, 7, 8}
+
+// legacySketchSize is the byte size of the fixed 4x2048 sketch in v1/v2 indexes
+const legacySketchSize = CountMinDepth * CountMinWidth * 4
+
 // Binary flags for file metadata
 const (
 	FlagIsDirectory = 1 << 0
 	FlagIsHidden    = 1 << 1
 	FlagIsSymlink   = 1 << 2
+	FlagHasOwner    = 1 << 3 // UID and Mode were captured (v6+)
 )
 
 type FileMetadata struct {
@@ -59,6 +93,9 @@ type FileMetadata struct {
 	IsSymlink    bool
 	Size         int64
 	LastModified time.Time
+	Tags         []string
+	Owner        int         // UID of the owner, -1 when unknown (indexed before v6)
+	Mode         os.FileMode // Permission bits, valid when Owner is known
 }
 
 type RankedFile struct {
@@ -67,55 +104,140 @@ type RankedFile struct {
 	Metadata FileMetadata
 }
 
-// Fixed-size binary path record (525 bytes)
+// Fixed-size binary path record (557 bytes)
 type PathRecord struct {
 	Path        [MaxPathLength]byte // 512 bytes - null-padded path
 	Timestamp   int64               // 8 bytes - Unix timestamp
 	AccessCount int32               // 4 bytes - access count
 	Flags       uint8               // 1 byte - flags (directory, hidden, etc.)
+	ContentHash uint64              // 8 bytes - content hash, 0 if not hashed
+	Size        int64               // 8 bytes - file size when hashed
+	UID         uint32              // 4 bytes - owner user ID
+	Mode        uint32              // 4 bytes - permission bits
+	ModTime     int64               // 8 bytes - modification time in Unix nanoseconds when hashed
+}
+
+// v6PathRecord is the 549-byte record layout of index formats v6-v7
+type v6PathRecord struct {
+	Path        [MaxPathLength]byte
+	Timestamp   int64
+	AccessCount int32
+	Flags       uint8
+	ContentHash uint64
+	Size        int64
+	UID         uint32
+	Mode        uint32
 }
 
-// Count-Min Sketch with fixed binary representation
+// v4PathRecord is the 541-byte record layout of index formats v4-v5
+type v4PathRecord struct {
+	Path        [MaxPathLength]byte
+	Timestamp   int64
+	AccessCount int32
+	Flags       uint8
+	ContentHash uint64
+	Size        int64
+}
+
+// legacyPathRecord is the 525-byte record layout of index formats v1-v3
+type legacyPathRecord struct {
+	Path        [MaxPathLength]byte
+	Timestamp   int64
+	AccessCount int32
+	Flags       uint8
+}
+
+// Count-Min Sketch with a fixed binary representation. Row positions are
+// derived from two independent hashes combined as h1 + i*h2
+// (Kirsch-Mitzenmacher), so any depth gets well-spread rows from one pass
+// over the item.
 type CountMinSketch struct {
-	table [CountMinDepth][CountMinWidth]int32
+	width int
+	depth int
+	table []int32 // depth rows of width counters, row-major
 }
 
-func NewCountMinSketch() *CountMinSketch {
-	return &CountMinSketch{}
+// NewCountMinSketch creates a sketch with the given dimensions. Non-positive
+// values fall back to CountMinWidth and CountMinDepth.
+func NewCountMinSketch(width, depth int) *CountMinSketch {
+	if width <= 0 {
+		width = CountMinWidth
+	}
+	if depth <= 0 || depth > maxSketchCounters {
+		depth = CountMinDepth
+	}
+	width = min(width, maxSketchCounters/depth)
+	return &CountMinSketch{
+		width: width,
+		depth: depth,
+		table: make([]int32, width*depth),
+	}
 }
 
-func (cms *CountMinSketch) hash(item string, row int) uint32 {
-	h := fnv.New32a()
+// Width returns the number of counters per row
+func (cms *CountMinSketch) Width() int { return cms.width }
+
+// Depth returns the number of rows (hash functions)
+func (cms *CountMinSketch) Depth() int { return cms.depth }
+
+// hashPair returns two independent 32-bit hashes of item. The 64-bit FNV-1a
+// sum is passed through the splitmix64 finalizer so both halves are well mixed.
+func (cms *CountMinSketch) hashPair(item string) (uint32, uint32) {
+	h := fnv.New64a()
 	h.Write([]byte(item))
-	h.Write([]byte{byte(row)}) // Salt with row number
-	return h.Sum32() % CountMinWidth
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	// An odd h2 never collapses every row onto the same column
+	return uint32(x), uint32(x>>32) | 1
+}
+
+func (cms *CountMinSketch) index(row int, h1, h2 uint32) int {
+	pos := (uint64(h1) + uint64(row)*uint64(h2)) % uint64(cms.width)
+	return row*cms.width + int(pos)
 }
 
 func (cms *CountMinSketch) Add(item string, count int32) {
-	for i := 0; i < CountMinDepth; i++ {
-		pos := cms.hash(item, i)
-		cms.table[i][pos] += count
+	h1, h2 := cms.hashPair(item)
+	for i := 0; i < cms.depth; i++ {
+		cms.table[cms.index(i, h1, h2)] += count
 	}
 }
 
 func (cms *CountMinSketch) Estimate(item string) int32 {
-	min := cms.table[0][cms.hash(item, 0)]
-	for i := 1; i < CountMinDepth; i++ {
-		pos := cms.hash(item, i)
-		if cms.table[i][pos] < min {
-			min = cms.table[i][pos]
+	h1, h2 := cms.hashPair(item)
+	min := cms.table[cms.index(0, h1, h2)]
+	for i := 1; i < cms.depth; i++ {
+		if v := cms.table[cms.index(i, h1, h2)]; v < min {
+			min = v
 		}
 	}
 	return min
 }
 
-// Binary serialization for Count-Min Sketch
-func (cms *CountMinSketch) WriteTo(w io.Writer) error {
-	return binary.Write(w, binary.LittleEndian, cms.table)
+// SizeBytes returns the memory used by the counters
+func (cms *CountMinSketch) SizeBytes() int {
+	return len(cms.table) * 4 // int32 = 4 bytes
 }
 
-func (cms *CountMinSketch) ReadFrom(r io.Reader) error {
-	return binary.Read(r, binary.LittleEndian, &cms.table)
+// WriteTo implements io.WriterTo for binary serialization of the counters
+func (cms *CountMinSketch) WriteTo(w io.Writer) (int64, error) {
+	if err := binary.Write(w, binary.LittleEndian, cms.table); err != nil {
+		return 0, err
+	}
+	return int64(cms.SizeBytes()), nil
+}
+
+// ReadFrom implements io.ReaderFrom. The sketch must already have the
+// dimensions the counters were written with.
+func (cms *CountMinSketch) ReadFrom(r io.Reader) (int64, error) {
+	if err := binary.Read(r, binary.LittleEndian, cms.table); err != nil {
+		return 0, err
+	}
+	return int64(cms.SizeBytes()), nil
 }
 
 type FilesystemIndexer struct {
@@ -126,11 +248,18 @@ type FilesystemIndexer struct {
 	rootPaths      []string       // Tracks root directories that were indexed
 	config         FilesystemConfig
 	isDirty        bool
+	loadedVersion  uint32              // Format version of the last index read from disk
+	needsBackup    bool                // Back up the on-disk index before the next persist
+	tags           map[string][]string // User tags of tagged paths (filesystem.index_tags)
+	priorityPaths  []string            // Absolute forms of filesystem.priority_paths
+	noRefreshPaths []string            // Absolute forms of filesystem.no_auto_refresh_paths
+	lastIndexed    time.Time           // When the roots were last walked, zero if unknown
+	logger         *log.Logger         // Indexing progress and warnings; the standard logger by default
 }
 
 func NewFilesystemIndexer(config FilesystemConfig) *FilesystemIndexer {
 	bloomFilter := bloom.New(config.BloomFilterSize, config.BloomFilterHashes)
-	countMinSketch := NewCountMinSketch()
+	countMinSketch := NewCountMinSketch(config.SketchWidth, config.SketchDepth)
 
 	return &FilesystemIndexer{
 		bloomFilter:    bloomFilter,
@@ -138,9 +267,75 @@ func NewFilesystemIndexer(config FilesystemConfig) *FilesystemIndexer {
 		pathRecords:    make([]PathRecord, 0, config.MaxIndexedFiles),
 		pathIndex:      make(map[string]int),
 		rootPaths:      make([]string, 0),
+		tags:           make(map[string][]string),
+		priorityPaths:  absolutePaths(config.PriorityPaths),
+		noRefreshPaths: absolutePaths(config.NoAutoRefreshPaths),
 		config:         config,
 		isDirty:        false,
+		logger:         log.Default(),
+	}
+}
+
+// Clone returns a deep copy of the index, which can be refreshed while the
+// original keeps serving searches and then swapped in with ReplaceWith
+func (fi *FilesystemIndexer) Clone() *FilesystemIndexer {
+	clone := *fi
+	clone.bloomFilter = fi.bloomFilter.Copy()
+	clone.countMinSketch = &CountMinSketch{
+		width: fi.countMinSketch.width,
+		depth: fi.countMinSketch.depth,
+		table: slices.Clone(fi.countMinSketch.table),
+	}
+	clone.pathRecords = slices.Clone(fi.pathRecords)
+	clone.pathIndex = maps.Clone(fi.pathIndex)
+	clone.rootPaths = slices.Clone(fi.rootPaths)
+	clone.tags = maps.Clone(fi.tags)
+	return &clone
+}
+
+// ReplaceWith makes fi hold the index of other, typically a refreshed Clone
+// of it, keeping fi's logger. other must not be used afterwards.
+func (fi *FilesystemIndexer) ReplaceWith(other *FilesystemIndexer) {
+	logger := fi.logger
+	*fi = *other
+	fi.logger = logger
+}
+
+// SetLogger sends indexing progress and warnings to logger, e.g. one writing
+// to io.Discard while a terminal UI is drawn
+func (fi *FilesystemIndexer) SetLogger(logger *log.Logger) {
+	fi.logger = logger
+}
+
+// absolutePaths expands "~/" and makes each path absolute and clean,
+// dropping paths that cannot be resolved
+func absolutePaths(paths []string) []string {
+	var result []string
+	for _, path := range paths {
+		if strings.HasPrefix(path, "~/") {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				continue
+			}
+			path = filepath.Join(homeDir, path[2:])
+		}
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			continue
+		}
+		result = append(result, absPath)
+	}
+	return result
+}
+
+// underPriorityPath reports whether path is, or is inside, a priority path
+func (fi *FilesystemIndexer) underPriorityPath(path string) bool {
+	for _, prefix := range fi.priorityPaths {
+		if path == prefix || strings.HasPrefix(path, prefix+string(filepath.Separator)) {
+			return true
+		}
 	}
+	return false
 }
 
 func (fi *FilesystemIndexer) pathToBytes(path string) [MaxPathLength]byte {
@@ -168,39 +363,89 @@ func (fi *FilesystemIndexer) bytesToPath(bytes [MaxPathLength]byte) string {
 }
 
 func (fi *FilesystemIndexer) AddPath(path string, eventTime time.Time, incrementAccess bool) (bool, int32) {
-	existed := fi.bloomFilter.TestString(path)
+	existed, count, _ := fi.addPath(path, nil, eventTime, incrementAccess, nil)
+	return existed, count
+}
+
+// addPath is AddPath for an indexing walk under guard, with info the path's
+// Lstat result when the walk already has it. The file is read (owner, hash,
+// tags) outside the guard; when the walk was given up meanwhile the index is
+// left alone and ok is false.
+func (fi *FilesystemIndexer) addPath(path string, info os.FileInfo, eventTime time.Time, incrementAccess bool, guard *walkGuard) (existed bool, count int32, ok bool) {
+	idx, found := fi.pathIndex[path]
+	var previous PathRecord
+	if found {
+		previous = fi.pathRecords[idx]
+	}
+
+	var (
+		statErr error
+		hash    uint64
+		hashed  bool
+		tags    []string
+	)
+	if !guard.blocking(func() {
+		if info == nil {
+			info, statErr = os.Lstat(path)
+		}
+		if statErr == nil && fi.config.HashContents {
+			hash, hashed = fi.contentHash(previous, path, info)
+		}
+		if fi.config.IndexTags {
+			tags = readFileTags(path, fi.config.TagsXattr)
+		}
+	}) {
+		return false, 0, false
+	}
+	// The record may have moved while the guard was released
+	idx, found = fi.pathIndex[path]
+
+	setFileData := func(record *PathRecord) {
+		if statErr != nil {
+			return
+		}
+		updateOwner(record, info)
+		if hashed {
+			record.ContentHash = hash
+			record.Size = info.Size()
+			record.ModTime = info.ModTime().UnixNano()
+		}
+	}
+
+	existed = fi.bloomFilter.TestString(path)
 
 	fi.bloomFilter.AddString(path)
 	if incrementAccess {
 		fi.countMinSketch.Add(path, 1)
 	}
 	fi.isDirty = true
+	if fi.config.IndexTags {
+		fi.setTags(path, tags)
+	}
 
-	if existed {
+	if existed && found {
 		// Update existing record
-		if idx, found := fi.pathIndex[path]; found {
-			if incrementAccess {
-				fi.pathRecords[idx].AccessCount++
-				fi.pathRecords[idx].Timestamp = eventTime.Unix()
-				return true, fi.pathRecords[idx].AccessCount
-			}
+		setFileData(&fi.pathRecords[idx])
+		if incrementAccess {
+			fi.pathRecords[idx].AccessCount++
+			fi.pathRecords[idx].Timestamp = eventTime.Unix()
+			return true, fi.pathRecords[idx].AccessCount, true
+		}
 
-			if !eventTime.IsZero() {
-				fi.pathRecords[idx].Timestamp = eventTime.Unix()
-			}
-			return true, fi.pathRecords[idx].AccessCount
+		if !eventTime.IsZero() {
+			fi.pathRecords[idx].Timestamp = eventTime.Unix()
 		}
+		return true, fi.pathRecords[idx].AccessCount, true
 	}
 
 	// Add new record
 	if len(fi.pathRecords) >= fi.config.MaxIndexedFiles {
-		log.Printf("Warning: Maximum indexed files limit (%d) reached", fi.config.MaxIndexedFiles)
-		return existed, fi.countMinSketch.Estimate(path)
+		fi.logger.Printf("Warning: Maximum indexed files limit (%d) reached", fi.config.MaxIndexedFiles)
+		return existed, fi.countMinSketch.Estimate(path), true
 	}
 
-	info, err := os.Lstat(path)
 	var flags uint8
-	if err == nil {
+	if statErr == nil {
 		if info.IsDir() {
 			flags |= FlagIsDirectory
 		}
@@ -222,6 +467,7 @@ func (fi *FilesystemIndexer) AddPath(path string, eventTime time.Time, increment
 	if !eventTime.IsZero() {
 		record.Timestamp = eventTime.Unix()
 	}
+	setFileData(&record)
 
 	if incrementAccess {
 		if record.Timestamp == 0 {
@@ -238,7 +484,7 @@ func (fi *FilesystemIndexer) AddPath(path string, eventTime time.Time, increment
 	fi.pathIndex[path] = len(fi.pathRecords)
 	fi.pathRecords = append(fi.pathRecords, record)
 
-	return existed, record.AccessCount
+	return existed, record.AccessCount, true
 }
 
 func (fi *FilesystemIndexer) TestMembership(path string) bool {
@@ -268,34 +514,29 @@ func (fi *FilesystemIndexer) IndexDirectories(rootPaths []string) error {
 }
 
 func (fi *FilesystemIndexer) IndexDirectoryWithProgress(rootPath string, showProgress bool) error {
-	log.Printf("Starting filesystem indexing for: %s", rootPath)
+	fi.logger.Printf("Starting filesystem indexing for: %s", rootPath)
 
 	// Track this root path if not already tracked
 	fi.addRootPath(rootPath)
 
 	count := 0
+	cutoff := fi.modifiedCutoff()
 
 	var bar *progressbar.ProgressBar
 	if showProgress {
-		// Create progress bar with unknown total initially
-		bar = progressbar.NewOptions(-1,
-			progressbar.OptionSetDescription("📁 Indexing files..."),
-			progressbar.OptionSetWidth(50),
-			progressbar.OptionShowCount(),
-			progressbar.OptionSetTheme(progressbar.Theme{
-				Saucer:        "█",
-				SaucerHead:    "█",
-				SaucerPadding: " ",
-				BarStart:      "[",
-				BarEnd:        "]",
-			}),
+		// The total stays unknown (-1) unless index_show_eta pre-counts it
+		bar = newIndexProgressBar(fi.progressTotal([]string{rootPath}), "📁 Indexing files...",
 			progressbar.OptionOnCompletion(func() {
-				fmt.Printf("\n✔️ Indexing completed!\n")
+				cliPrintf("\n✔️ Indexing completed!\n")
 			}),
 		)
 	}
 
-	err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+	ctx, cancel := fi.indexContext(context.Background())
+	defer cancel()
+
+	guard := &walkGuard{}
+	err := fi.walkIndex(ctx, rootPath, guard, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			if os.IsPermission(err) {
 				return nil
@@ -310,26 +551,30 @@ func (fi *FilesystemIndexer) IndexDirectoryWithProgress(rootPath string, showPro
 			return nil
 		}
 
+		if modifiedBefore(d, cutoff) {
+			return nil
+		}
+
 		if count >= fi.config.MaxIndexedFiles {
 			if showProgress && bar != nil {
-				bar.Describe("⚠️  Max files limit reached")
+				bar.Describe(plainText("⚠️  Max files limit reached"))
 				bar.Finish()
 			}
 			return errors.New("max indexed files limit reached")
 		}
 
-		fi.AddPath(path, time.Time{}, false)
+		info, _ := d.Info()
+		if _, _, ok := fi.addPath(path, info, time.Time{}, false, guard); !ok {
+			return filepath.SkipAll
+		}
 		count++
 
 		// Update progress bar
 		if showProgress && bar != nil {
 			bar.Add(1)
 			// Show current file being processed (truncate if too long)
-			currentFile := filepath.Base(path)
-			if len(currentFile) > 30 {
-				currentFile = currentFile[:27] + "..."
-			}
-			bar.Describe(fmt.Sprintf("📁 Indexing: %s", currentFile))
+			currentFile := truncateWidth(filepath.Base(path), 30)
+			bar.Describe(plainText(fmt.Sprintf("📁 Indexing: %s", currentFile)))
 		}
 
 		return nil
@@ -339,49 +584,60 @@ func (fi *FilesystemIndexer) IndexDirectoryWithProgress(rootPath string, showPro
 		bar.Finish()
 	}
 
-	log.Printf("Filesystem indexing completed. Indexed %d files/directories", count)
+	if isIndexTimeout(err) {
+		fi.logger.Printf("Warning: %v", err)
+		if showProgress {
+			cliPrintf("⚠️  Indexing %s stopped early (%v); keeping the %d entries indexed so far\n", rootPath, err, count)
+		}
+		err = nil
+	}
+
+	fi.logger.Printf("Filesystem indexing completed. Indexed %d files/directories", count)
 	return err
 }
 
 func (fi *FilesystemIndexer) IndexDirectoriesWithProgress(rootPaths []string, showProgress bool) error {
+	_, err := fi.indexDirectories(context.Background(), rootPaths, showProgress)
+	return err
+}
+
+// indexDirectories indexes rootPaths, reporting whether every one was walked
+// to the end rather than stopped by an error, the indexing timeout,
+// cancellation of ctx or filesystem.max_indexed_files
+func (fi *FilesystemIndexer) indexDirectories(ctx context.Context, rootPaths []string, showProgress bool) (complete bool, err error) {
 	if len(rootPaths) == 0 {
-		return fmt.Errorf("no directories provided for indexing")
+		return false, fmt.Errorf("no directories provided for indexing")
 	}
+	complete = true
 
 	totalCount := 0
 	var overallBar *progressbar.ProgressBar
 
 	if showProgress {
 		// Create overall progress bar
-		overallBar = progressbar.NewOptions(-1,
-			progressbar.OptionSetDescription("📁 Indexing multiple directories..."),
-			progressbar.OptionSetWidth(50),
-			progressbar.OptionShowCount(),
-			progressbar.OptionSetTheme(progressbar.Theme{
-				Saucer:        "█",
-				SaucerHead:    "█",
-				SaucerPadding: " ",
-				BarStart:      "[",
-				BarEnd:        "]",
-			}),
-		)
+		overallBar = newIndexProgressBar(fi.progressTotal(rootPaths), "📁 Indexing multiple directories...")
 	}
 
+	ctx, cancel := fi.indexContext(ctx)
+	defer cancel()
+
+	cutoff := fi.modifiedCutoff()
 	for i, rootPath := range rootPaths {
 		if showProgress {
-			overallBar.Describe(fmt.Sprintf("📁 [%d/%d] %s", i+1, len(rootPaths), filepath.Base(rootPath)))
+			overallBar.Describe(plainText(fmt.Sprintf("📁 [%d/%d] %s", i+1, len(rootPaths), filepath.Base(rootPath))))
 		}
 
 		// Track this root path if not already tracked
 		fi.addRootPath(rootPath)
 
 		if showProgress {
-			log.Printf("Starting filesystem indexing for directory %d/%d: %s", i+1, len(rootPaths), rootPath)
+			fi.logger.Printf("Starting filesystem indexing for directory %d/%d: %s", i+1, len(rootPaths), rootPath)
 		}
 
 		count := 0
 
-		err := filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+		guard := &walkGuard{}
+		err := fi.walkIndex(ctx, rootPath, guard, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				if os.IsPermission(err) {
 					return nil
@@ -396,37 +652,46 @@ func (fi *FilesystemIndexer) IndexDirectoriesWithProgress(rootPaths []string, sh
 				return nil
 			}
 
+			if modifiedBefore(d, cutoff) {
+				return nil
+			}
+
 			if totalCount >= fi.config.MaxIndexedFiles {
 				if showProgress && overallBar != nil {
-					overallBar.Describe("⚠️  Max files limit reached")
+					overallBar.Describe(plainText("⚠️  Max files limit reached"))
 					overallBar.Finish()
 				}
 				return errors.New("max indexed files limit reached")
 			}
 
-			fi.AddPath(path, time.Time{}, false)
+			info, _ := d.Info()
+			if _, _, ok := fi.addPath(path, info, time.Time{}, false, guard); !ok {
+				return filepath.SkipAll
+			}
 			count++
 			totalCount++
 
 			if showProgress && overallBar != nil {
 				overallBar.Add(1)
 				// Show current directory and file being processed
-				currentFile := filepath.Base(path)
-				if len(currentFile) > 25 {
-					currentFile = currentFile[:22] + "..."
-				}
-				dirName := filepath.Base(rootPath)
-				if len(dirName) > 15 {
-					dirName = dirName[:12] + "..."
-				}
-				overallBar.Describe(fmt.Sprintf("📁 [%d/%d] %s: %s", i+1, len(rootPaths), dirName, currentFile))
+				currentFile := truncateWidth(filepath.Base(path), 25)
+				dirName := truncateWidth(filepath.Base(rootPath), 15)
+				overallBar.Describe(plainText(fmt.Sprintf("📁 [%d/%d] %s: %s", i+1, len(rootPaths), dirName, currentFile)))
 			}
 
 			return nil
 		})
 
 		if err != nil {
-			log.Printf("Warning: Error indexing directory %s: %v", rootPath, err)
+			complete = false
+			fi.logger.Printf("Warning: Error indexing directory %s: %v", rootPath, err)
+			if isIndexTimeout(err) && showProgress {
+				cliPrintf("\n⚠️  Indexing %s stopped early (%v); keeping the %d entries indexed so far\n", rootPath, err, count)
+			}
+			// Past the deadline or once cancelled there is no time left for the remaining directories
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+				break
+			}
 			if err.Error() == "max indexed files limit reached" {
 				if showProgress && overallBar != nil {
 					overallBar.Finish()
@@ -436,17 +701,269 @@ func (fi *FilesystemIndexer) IndexDirectoriesWithProgress(rootPaths []string, sh
 		}
 
 		if showProgress {
-			log.Printf("Completed indexing directory %s: %d files/directories", rootPath, count)
+			fi.logger.Printf("Completed indexing directory %s: %d files/directories", rootPath, count)
 		}
 	}
 
 	if showProgress && overallBar != nil {
-		overallBar.Describe("✔️ Indexing completed")
+		overallBar.Describe(plainText("✔️ Indexing completed"))
 		overallBar.Finish()
 
-		log.Printf("Multi-directory indexing completed. Total indexed: %d files/directories across %d directories", totalCount, len(rootPaths))
+		fi.logger.Printf("Multi-directory indexing completed. Total indexed: %d files/directories across %d directories", totalCount, len(rootPaths))
 	}
-	return nil
+	return complete, nil
+}
+
+// newIndexProgressBar creates the progress bar shown while indexing. With a
+// known total it also shows the percentage done, indexing speed and ETA;
+// a total of -1 shows a running count only. options are applied last, so
+// they override the defaults.
+func newIndexProgressBar(total int, description string, options ...progressbar.Option) *progressbar.ProgressBar {
+	defaults := []progressbar.Option{
+		progressbar.OptionSetDescription(plainText(description)),
+		progressbar.OptionSetWidth(50),
+		progressbar.OptionShowCount(),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "█",
+			SaucerHead:    "█",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}),
+	}
+	if total > 0 {
+		defaults = append(defaults,
+			progressbar.OptionShowIts(),
+			progressbar.OptionSetItsString("files"),
+			progressbar.OptionSetPredictTime(true),
+		)
+	}
+	return progressbar.NewOptions(total, append(defaults, options...)...)
+}
+
+// errCountAborted stops the pre-count walk of countIndexableEntries
+var errCountAborted = errors.New("count aborted")
+
+// progressTotal pre-counts the entries indexing will visit when
+// index_show_eta is on, so the progress bar can show an ETA. It returns -1
+// (unknown total) when the option is off, the count is skipped with Ctrl+C,
+// or the trees hold more than eta_count_limit entries.
+func (fi *FilesystemIndexer) progressTotal(rootPaths []string) int {
+	if !fi.config.IndexShowETA {
+		return -1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cliPrintf("🔢 Counting files to estimate the time left (Ctrl+C to skip)...\n")
+	total, ok := fi.countIndexableEntries(ctx, rootPaths, fi.config.ETACountLimit)
+	if !ok {
+		cliPrintf("⏭️  Skipped counting, progress shows a running count only\n")
+		return -1
+	}
+	return total
+}
+
+// countIndexableEntries counts the entries under rootPaths that indexing
+// would add, applying the same filters and capped at max_indexed_files.
+// It gives up, returning false, when ctx is cancelled or the count exceeds
+// limit (0 means no limit).
+func (fi *FilesystemIndexer) countIndexableEntries(ctx context.Context, rootPaths []string, limit int) (int, bool) {
+	count := 0
+	cutoff := fi.modifiedCutoff()
+
+	for _, rootPath := range rootPaths {
+		guard := &walkGuard{}
+		err := fi.walkIndex(ctx, rootPath, guard, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsPermission(err) {
+					return nil
+				}
+				return err
+			}
+
+			if fi.shouldSkipPath(path) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if modifiedBefore(d, cutoff) {
+				return nil
+			}
+
+			count++
+			if count >= fi.config.MaxIndexedFiles {
+				return filepath.SkipAll
+			}
+			if limit > 0 && count > limit {
+				return errCountAborted
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, false
+		}
+		if count >= fi.config.MaxIndexedFiles {
+			break
+		}
+	}
+	return count, true
+}
+
+// errIndexStalled reports a walk abandoned because the filesystem stopped
+// responding, typically a hung network mount
+var errIndexStalled = errors.New("filesystem not responding")
+
+// indexContext returns the context bounding one indexing run, which ends
+// with parent or after index_timeout_seconds when that is set
+func (fi *FilesystemIndexer) indexContext(parent context.Context) (context.Context, context.CancelFunc) {
+	if fi.config.IndexTimeoutSeconds > 0 {
+		return context.WithTimeout(parent, time.Duration(fi.config.IndexTimeoutSeconds)*time.Second)
+	}
+	return context.WithCancel(parent)
+}
+
+// walkIndex walks rootPath for indexing, guarded by ctx and
+// stat_timeout_seconds. With git_tracked_only it visits only git-tracked
+// files and their directories when rootPath is inside a repository.
+func (fi *FilesystemIndexer) walkIndex(ctx context.Context, rootPath string, guard *walkGuard, fn fs.WalkDirFunc) error {
+	if tracked := fi.gitTrackedPaths(ctx, rootPath); tracked != nil {
+		fn = tracked.filter(fn)
+	}
+	return guardedWalk(ctx, rootPath, time.Duration(fi.config.StatTimeoutSeconds)*time.Second, guard, fn)
+}
+
+// isIndexTimeout reports whether err ended a walk early because of a stalled
+// filesystem or the index deadline; what was indexed before is kept
+func isIndexTimeout(err error) bool {
+	return errors.Is(err, errIndexStalled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// walkGuard keeps an abandoned walk from changing the index: guardedWalk
+// calls fn holding mu, fn releases it only around blocking I/O (blocking),
+// and once guardedWalk gives up no callback changes anything again.
+type walkGuard struct {
+	mu      sync.Mutex
+	stopped bool
+}
+
+// blocking runs io without holding the guard and reports whether the walk
+// is still going afterwards; when it is not, the callback must return
+// without touching shared state. A nil guard just runs io.
+func (g *walkGuard) blocking(io func()) bool {
+	if g == nil {
+		io()
+		return true
+	}
+	g.mu.Unlock()
+	io()
+	g.mu.Lock()
+	return !g.stopped
+}
+
+// stop gives up the walk, waiting only for a callback that is not blocked
+// in I/O to return
+func (g *walkGuard) stop() {
+	g.mu.Lock()
+	g.stopped = true
+	g.mu.Unlock()
+}
+
+// statedEntry is a fs.DirEntry whose Info was read ahead, outside the guard
+type statedEntry struct {
+	fs.DirEntry
+	info fs.FileInfo
+	err  error
+}
+
+func (e statedEntry) Info() (fs.FileInfo, error) { return e.info, e.err }
+
+// guardedWalk is filepath.WalkDir that cannot hang: it gives up when ctx ends
+// or when no entry is reached or processed for stallTimeout (0 disables that
+// check), returning an error naming the path it was stuck on. The walk runs
+// in its own goroutine, which is abandoned if it is blocked in a system call.
+// fn runs under guard (a new one if nil) with each entry's Info already read,
+// so once guardedWalk returns fn no longer changes anything.
+func guardedWalk(ctx context.Context, rootPath string, stallTimeout time.Duration, guard *walkGuard, fn fs.WalkDirFunc) error {
+	if guard == nil {
+		guard = &walkGuard{}
+	}
+	var (
+		current      atomic.Value // Path of the entry being processed
+		lastProgress atomic.Int64 // UnixNano of the last entry reached or processed
+	)
+	current.Store(rootPath)
+	lastProgress.Store(time.Now().UnixNano())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- filepath.WalkDir(rootPath, func(path string, d fs.DirEntry, err error) error {
+			current.Store(path)
+			if d != nil {
+				info, infoErr := d.Info()
+				d = statedEntry{d, info, infoErr}
+			}
+
+			guard.mu.Lock()
+			defer guard.mu.Unlock()
+			if guard.stopped {
+				return filepath.SkipAll
+			}
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return fmt.Errorf("indexing stopped at %s: %w", path, ctxErr)
+			}
+			lastProgress.Store(time.Now().UnixNano())
+			result := fn(path, d, err)
+			lastProgress.Store(time.Now().UnixNano())
+			return result
+		})
+	}()
+
+	var tick <-chan time.Time
+	if stallTimeout > 0 {
+		ticker := time.NewTicker(min(stallTimeout/4, time.Second))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			guard.stop()
+			return fmt.Errorf("indexing stopped at %s: %w", current.Load(), ctx.Err())
+		case <-tick:
+			if time.Since(time.Unix(0, lastProgress.Load())) > stallTimeout {
+				guard.stop()
+				return fmt.Errorf("%w: no progress at %s for %v", errIndexStalled, current.Load(), stallTimeout)
+			}
+		}
+	}
+}
+
+// modifiedCutoff returns the oldest modification time indexed under
+// index_modified_within_days, or the zero time when the option is off
+func (fi *FilesystemIndexer) modifiedCutoff() time.Time {
+	if fi.config.IndexModifiedWithinDays <= 0 {
+		return time.Time{}
+	}
+	return time.Now().AddDate(0, 0, -fi.config.IndexModifiedWithinDays)
+}
+
+// modifiedBefore reports whether a file was last modified before cutoff.
+// Directories never match so their contents are still traversed.
+func modifiedBefore(d fs.DirEntry, cutoff time.Time) bool {
+	if cutoff.IsZero() || d.IsDir() {
+		return false
+	}
+	info, err := d.Info()
+	if err != nil {
+		return false
+	}
+	return info.ModTime().Before(cutoff)
 }
 
 func (fi *FilesystemIndexer) shouldSkipPath(path string) bool {
@@ -495,27 +1012,132 @@ func (fi *FilesystemIndexer) GetRootPaths() []string {
 	return result
 }
 
-// ReindexExistingPaths re-indexes all tracked root paths to discover new files
+// RootPathStat describes one tracked root directory
+type RootPathStat struct {
+	Path    string
+	Exists  bool // Whether the directory is still on disk
+	Records int  // Indexed entries under it, the root included
+}
+
+// RootPathStats reports each tracked root path, whether it still exists and
+// how many indexed records fall under it
+func (fi *FilesystemIndexer) RootPathStats() []RootPathStat {
+	stats := make([]RootPathStat, len(fi.rootPaths))
+	for i, rootPath := range fi.rootPaths {
+		stats[i].Path = rootPath
+		if info, err := os.Stat(rootPath); err == nil && info.IsDir() {
+			stats[i].Exists = true
+		}
+	}
+	for _, record := range fi.pathRecords {
+		path := fi.bytesToPath(record.Path)
+		for i := range stats {
+			if isUnderRoot(path, stats[i].Path) {
+				stats[i].Records++
+			}
+		}
+	}
+	return stats
+}
+
+// RemoveRootPath stops tracking rootPath, so refreshes no longer re-index it.
+// With removeRecords its indexed entries are dropped as well, except those
+// still under another tracked root. It returns how many entries were dropped.
+func (fi *FilesystemIndexer) RemoveRootPath(rootPath string, removeRecords bool) (int, error) {
+	absPath, err := filepath.Abs(rootPath)
+	if err != nil {
+		absPath = rootPath
+	}
+
+	index := -1
+	for i, existing := range fi.rootPaths {
+		if existing == absPath {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return 0, fmt.Errorf("%s is not a tracked root", absPath)
+	}
+	fi.rootPaths = append(fi.rootPaths[:index], fi.rootPaths[index+1:]...)
+	fi.isDirty = true
+
+	if !removeRecords {
+		return 0, nil
+	}
+	stats, err := fi.CleanupIndex(CleanupOptions{
+		Match: func(path string) bool {
+			if !isUnderRoot(path, absPath) {
+				return false
+			}
+			for _, other := range fi.rootPaths {
+				if isUnderRoot(path, other) {
+					return false
+				}
+			}
+			return true
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return stats.RemovedEntries, nil
+}
+
+// isUnderRoot reports whether path is root or inside it
+func isUnderRoot(path string, root string) bool {
+	if path == root {
+		return true
+	}
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(path, root)
+}
+
+// autoRefreshDisabled reports whether rootPath is, or is inside, one of the
+// filesystem.no_auto_refresh_paths
+func (fi *FilesystemIndexer) autoRefreshDisabled(rootPath string) bool {
+	for _, path := range fi.noRefreshPaths {
+		if isUnderRoot(rootPath, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReindexExistingPaths re-indexes all tracked root paths to discover new files.
+// Roots under filesystem.no_auto_refresh_paths keep their indexed entries and
+// stay tracked, but are not touched (not even stat'ed, so an absent drive
+// cannot stall the refresh).
 func (fi *FilesystemIndexer) ReindexExistingPaths(showProgress bool) error {
+	return fi.reindexExistingPaths(context.Background(), showProgress)
+}
+
+func (fi *FilesystemIndexer) reindexExistingPaths(ctx context.Context, showProgress bool) error {
 	if len(fi.rootPaths) == 0 {
 		return nil
 	}
 
 	if showProgress {
-		log.Printf("Re-indexing %d tracked root paths to discover new files", len(fi.rootPaths))
+		fi.logger.Printf("Re-indexing %d tracked root paths to discover new files", len(fi.rootPaths))
 	}
 
 	// Filter out root paths that no longer exist
-	var validRootPaths []string
+	var validRootPaths, refreshPaths []string
 	for _, rootPath := range fi.rootPaths {
-		if _, err := os.Stat(rootPath); err == nil {
+		if fi.autoRefreshDisabled(rootPath) {
+			fi.logger.Printf("Skipping root path excluded from auto refresh: %s", rootPath)
 			validRootPaths = append(validRootPaths, rootPath)
+		} else if _, err := os.Stat(rootPath); err == nil {
+			validRootPaths = append(validRootPaths, rootPath)
+			refreshPaths = append(refreshPaths, rootPath)
 		} else {
-			log.Printf("Skipping non-existent root path: %s", rootPath)
+			fi.logger.Printf("Skipping non-existent root path: %s", rootPath)
 		}
 	}
 
-	if len(validRootPaths) == 0 {
+	if len(refreshPaths) == 0 {
 		return nil
 	}
 
@@ -523,86 +1145,176 @@ func (fi *FilesystemIndexer) ReindexExistingPaths(showProgress bool) error {
 	fi.rootPaths = validRootPaths
 	fi.isDirty = true
 
-	// Re-index all valid root paths
-	return fi.IndexDirectoriesWithProgress(validRootPaths, showProgress)
+	// Re-index the valid root paths that take part in auto refresh. Only a
+	// refresh that walked all of them makes the index up to date.
+	complete, err := fi.indexDirectories(ctx, refreshPaths, showProgress)
+	if complete {
+		fi.lastIndexed = timeNow()
+	}
+	return err
+}
+
+// LastIndexed returns when the tracked roots were last fully re-indexed, or
+// the zero time for an index that has never recorded it (written before
+// format v7 or only indexed one path at a time)
+func (fi *FilesystemIndexer) LastIndexed() time.Time {
+	return fi.lastIndexed
+}
+
+// RefreshDue reports whether the index is older than
+// filesystem.index_cache_duration_hours and should be refreshed on launch.
+// A duration of 0 or less refreshes on every launch.
+func (fi *FilesystemIndexer) RefreshDue() bool {
+	if fi.config.IndexCacheDuration <= 0 || fi.lastIndexed.IsZero() {
+		return true
+	}
+	return timeNow().Sub(fi.lastIndexed) >= time.Duration(fi.config.IndexCacheDuration)*time.Hour
 }
 
 // RefreshIndex performs a complete refresh of all tracked paths with progress display and persistence
 func (fi *FilesystemIndexer) RefreshIndex(showProgress bool, showStats bool) error {
+	return fi.RefreshIndexContext(context.Background(), showProgress, showStats)
+}
+
+// RefreshIndexContext is RefreshIndex stopping early, without persisting,
+// once ctx is cancelled
+func (fi *FilesystemIndexer) RefreshIndexContext(ctx context.Context, showProgress bool, showStats bool) error {
 	rootPaths := fi.GetRootPaths()
 	if len(rootPaths) == 0 {
 		return fmt.Errorf("no tracked paths found in index")
 	}
 
-	fmt.Printf("📊 Current index: %s\n", fi.GetIndexStats())
-
 	if showProgress {
-		fmt.Printf("🔄 Re-indexing %d tracked paths to discover new files...\n", len(rootPaths))
+		cliPrintf("📊 Current index: %s\n", fi.GetIndexStats())
+		cliPrintf("🔄 Re-indexing %d tracked paths to discover new files...\n", len(rootPaths))
 	}
 
 	// Re-index all tracked paths
-	err := fi.ReindexExistingPaths(showProgress)
+	err := fi.reindexExistingPaths(ctx, showProgress)
 	if err != nil {
 		return err
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	// Persist the updated index
 	if showProgress {
-		fmt.Printf("\n💾 Saving updated index to disk...")
+		cliPrintf("\n💾 Saving updated index to disk...")
 	}
 
 	if persistErr := fi.PersistIndex(showProgress); persistErr != nil {
 		if showProgress {
-			fmt.Printf(" ❌\n")
+			cliPrintf(" ❌\n")
 		}
 		return fmt.Errorf("failed to persist updated index: %v", persistErr)
 	}
 
 	if showStats {
-		fmt.Printf("\n📊 Updated index: %s\n", fi.GetIndexStats())
+		cliPrintf("\n📊 Updated index: %s\n", fi.GetIndexStats())
 	}
 
 	return nil
 }
 
+// basenameMatchBonus multiplies the score of results whose file name matches
+// the query, so they outrank paths that only match in a parent directory
+const basenameMatchBonus = 1.5
+
+// priorityPathBonus multiplies the score of paths under filesystem.priority_paths
+const priorityPathBonus = 3.0
+
 func (fi *FilesystemIndexer) SearchFiles(query string, enableFuzzy bool) []RankedFile {
-	var candidates []string
+	return fi.searchRecords(query, enableFuzzy, false, "")
+}
+
+// SearchFilesIn is SearchFiles restricted to paths at or under the absolute
+// path scope, ranked as usual. An empty scope searches the whole index.
+func (fi *FilesystemIndexer) SearchFilesIn(query string, enableFuzzy bool, scope string) []RankedFile {
+	return fi.searchRecords(query, enableFuzzy, false, scope)
+}
+
+// SearchDirectories ranks only indexed directories matching query
+func (fi *FilesystemIndexer) SearchDirectories(query string, enableFuzzy bool) []RankedFile {
+	return fi.searchRecords(query, enableFuzzy, true, "")
+}
+
+// rankedFileLess orders files by descending score. Equal scores go to the
+// most recently accessed path, then alphabetically, so results never reorder
+// between runs.
+func rankedFileLess(a, b RankedFile) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if c := compareRecency(a.Metadata.Timestamp, b.Metadata.Timestamp); c != 0 {
+		return c > 0
+	}
+	return a.Path < b.Path
+}
+
+func (fi *FilesystemIndexer) searchRecords(query string, enableFuzzy bool, dirsOnly bool, scope string) []RankedFile {
+	// matchCandidate remembers whether the query hit the file name itself
+	type matchCandidate struct {
+		path       string
+		inBasename bool
+	}
+	var candidates []matchCandidate
+	wantTags, query := splitTagQuery(query)
+	perms, query := splitPermissionQuery(query)
 	queryLower := strings.ToLower(query)
 
 	// Search through indexed paths
 	for _, record := range fi.pathRecords {
+		if dirsOnly && record.Flags&FlagIsDirectory == 0 {
+			continue
+		}
+		if !perms.matches(record) {
+			continue
+		}
 		path := fi.bytesToPath(record.Path)
+		if scope != "" && !isUnderRoot(path, scope) {
+			continue
+		}
+		if wantTags != nil && !matchesTags(fi.tags[path], wantTags) {
+			continue
+		}
+		baseLower := strings.ToLower(filepath.Base(path))
 
 		if enableFuzzy {
-			if strings.Contains(strings.ToLower(filepath.Base(path)), queryLower) ||
-				strings.Contains(strings.ToLower(path), queryLower) {
-				candidates = append(candidates, path)
+			if strings.Contains(baseLower, queryLower) {
+				candidates = append(candidates, matchCandidate{path, true})
+			} else if strings.Contains(strings.ToLower(path), queryLower) {
+				candidates = append(candidates, matchCandidate{path, false})
 			}
 		} else {
-			if strings.HasPrefix(strings.ToLower(filepath.Base(path)), queryLower) {
-				candidates = append(candidates, path)
+			if strings.HasPrefix(baseLower, queryLower) {
+				candidates = append(candidates, matchCandidate{path, true})
 			}
 		}
 	}
 
 	rankedFiles := make([]RankedFile, 0, len(candidates))
+	now := timeNow()
 
-	for _, path := range candidates {
-		metadata, err := fi.getFileMetadata(path)
+	for _, candidate := range candidates {
+		metadata, err := fi.getFileMetadata(candidate.path)
 		if err != nil {
 			continue
 		}
 
-		score := fi.calculateFileScore(metadata)
+		score := fi.calculateFileScore(metadata, now)
+		if candidate.inBasename {
+			score *= basenameMatchBonus
+		}
 		rankedFiles = append(rankedFiles, RankedFile{
-			Path:     path,
+			Path:     candidate.path,
 			Score:    score,
 			Metadata: metadata,
 		})
 	}
 
 	sort.SliceStable(rankedFiles, func(i, j int) bool {
-		return rankedFiles[i].Score > rankedFiles[j].Score
+		return rankedFileLess(rankedFiles[i], rankedFiles[j])
 	})
 
 	if len(rankedFiles) > 50 {
@@ -628,6 +1340,12 @@ func (fi *FilesystemIndexer) getFileMetadata(path string) (FileMetadata, error)
 			IsDirectory: (record.Flags & FlagIsDirectory) != 0,
 			IsHidden:    (record.Flags & FlagIsHidden) != 0,
 			IsSymlink:   (record.Flags & FlagIsSymlink) != 0,
+			Tags:        fi.tags[path],
+			Owner:       -1,
+		}
+		if record.Flags&FlagHasOwner != 0 {
+			metadata.Owner = int(record.UID)
+			metadata.Mode = os.FileMode(record.Mode)
 		}
 
 		if info, err := os.Stat(path); err == nil {
@@ -641,12 +1359,13 @@ func (fi *FilesystemIndexer) getFileMetadata(path string) (FileMetadata, error)
 	return FileMetadata{}, fmt.Errorf("path not found in index: %s", path)
 }
 
-func (fi *FilesystemIndexer) calculateFileScore(metadata FileMetadata) float64 {
+// calculateFileScore scores a file as of now, which is shared by every file
+// ranked together so files with the same history score exactly the same
+func (fi *FilesystemIndexer) calculateFileScore(metadata FileMetadata, now time.Time) float64 {
 	if metadata.Timestamp == nil {
 		return 0
 	}
 
-	now := time.Now()
 	timeDelta := now.Sub(*metadata.Timestamp).Hours()
 
 	frequencyScore := float64(metadata.AccessCount)
@@ -658,6 +1377,10 @@ func (fi *FilesystemIndexer) calculateFileScore(metadata FileMetadata) float64 {
 		score *= 0.8
 	}
 
+	if fi.underPriorityPath(metadata.Path) {
+		score *= priorityPathBonus
+	}
+
 	return score
 }
 
@@ -667,26 +1390,58 @@ func (fi *FilesystemIndexer) calculateFileScore(metadata FileMetadata) float64 {
 //   - Version (4 bytes): uint32
 //   - Record count (4 bytes): uint32
 //   - Root path count (4 bytes): uint32
-//   - Reserved (12 bytes)
+//   - Reserved (12 bytes): v3+ stores the sketch width and depth as two
+//     uint32s in the first 8 bytes
+// Last indexed (v7+, 8 bytes): int64 Unix timestamp, 0 if unknown
 // Root paths section (variable size):
 //   - Each root path: length (4 bytes) + path string
 // Bloom filter data (variable size)
-// Count-Min Sketch (32KB fixed size: 4 * 2048 * 4 bytes)
-// Path records (525 bytes each, fixed size)
+// Count-Min Sketch (depth * width int32 counters; a fixed 4 * 2048 before v3)
+// Path records (PathRecordSize bytes each, fixed size)
+// Tags section (v5+, variable size)
 
+// SaveToFile writes the index to a temporary file next to filePath and
+// renames it into place, so an interrupted save never leaves a truncated
+// index behind
 func (fi *FilesystemIndexer) SaveToFile(filePath string) error {
-	file, err := os.Create(filePath)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %v", err)
+	}
+	file, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create index file: %v", err)
 	}
-	defer file.Close()
 
+	w := bufio.NewWriter(file)
+	err = fi.writeIndex(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filePath)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("failed to write index file: %v", err)
+	}
+
+	fi.isDirty = false
+	return nil
+}
+
+func (fi *FilesystemIndexer) writeIndex(file io.Writer) error {
 	// Write header
 	magic := [8]byte{'R', 'E', 'C', 'A', 'L', 'L', 'E', 'R'}
-	version := uint32(2) // Increment version to support root paths
+	version := IndexFormatVersion
 	recordCount := uint32(len(fi.pathRecords))
 	rootPathCount := uint32(len(fi.rootPaths))
+	// Reserved bytes carry the sketch width and depth (v3+), then 4 unused bytes
 	reserved := [12]byte{}
+	binary.LittleEndian.PutUint32(reserved[0:4], uint32(fi.countMinSketch.Width()))
+	binary.LittleEndian.PutUint32(reserved[4:8], uint32(fi.countMinSketch.Depth()))
 
 	if err := binary.Write(file, binary.LittleEndian, magic); err != nil {
 		return err
@@ -703,6 +1458,13 @@ func (fi *FilesystemIndexer) SaveToFile(filePath string) error {
 	if err := binary.Write(file, binary.LittleEndian, reserved); err != nil {
 		return err
 	}
+	var lastIndexed int64
+	if !fi.lastIndexed.IsZero() {
+		lastIndexed = fi.lastIndexed.Unix()
+	}
+	if err := binary.Write(file, binary.LittleEndian, lastIndexed); err != nil {
+		return err
+	}
 
 	// Write root paths
 	for _, rootPath := range fi.rootPaths {
@@ -722,7 +1484,7 @@ func (fi *FilesystemIndexer) SaveToFile(filePath string) error {
 	}
 
 	// Write Count-Min Sketch
-	if err := fi.countMinSketch.WriteTo(file); err != nil {
+	if _, err := fi.countMinSketch.WriteTo(file); err != nil {
 		return err
 	}
 
@@ -733,8 +1495,8 @@ func (fi *FilesystemIndexer) SaveToFile(filePath string) error {
 		}
 	}
 
-	fi.isDirty = false
-	return nil
+	// Write tags section (v5+)
+	return fi.writeTags(file)
 }
 
 func (fi *FilesystemIndexer) LoadFromFile(filePath string) error {
@@ -759,7 +1521,7 @@ func (fi *FilesystemIndexer) LoadFromFile(filePath string) error {
 	if err := binary.Read(file, binary.LittleEndian, &version); err != nil {
 		return err
 	}
-	if version != 1 && version != 2 {
+	if !isSupportedIndexVersion(version) {
 		return fmt.Errorf("unsupported file version: %d", version)
 	}
 
@@ -768,7 +1530,7 @@ func (fi *FilesystemIndexer) LoadFromFile(filePath string) error {
 	}
 
 	// Handle version differences
-	if version == 2 {
+	if version >= 2 {
 		if err := binary.Read(file, binary.LittleEndian, &rootPathCount); err != nil {
 			return err
 		}
@@ -785,6 +1547,18 @@ func (fi *FilesystemIndexer) LoadFromFile(filePath string) error {
 		return err
 	}
 
+	// Older indexes never recorded it, so they count as never indexed
+	fi.lastIndexed = time.Time{}
+	if version >= 7 {
+		var lastIndexed int64
+		if err := binary.Read(file, binary.LittleEndian, &lastIndexed); err != nil {
+			return err
+		}
+		if lastIndexed != 0 {
+			fi.lastIndexed = time.Unix(lastIndexed, 0)
+		}
+	}
+
 	// Read root paths (only in version 2+)
 	fi.rootPaths = make([]string, 0, rootPathCount)
 	for i := uint32(0); i < rootPathCount; i++ {
@@ -806,9 +1580,25 @@ func (fi *FilesystemIndexer) LoadFromFile(filePath string) error {
 	}
 
 	// Read Count-Min Sketch
-	fi.countMinSketch = NewCountMinSketch()
-	if err := fi.countMinSketch.ReadFrom(file); err != nil {
-		return err
+	rebuildSketch := false
+	if version >= 3 {
+		width := int(binary.LittleEndian.Uint32(reserved[0:4]))
+		depth := int(binary.LittleEndian.Uint32(reserved[4:8]))
+		if width <= 0 || depth <= 0 || width*depth > maxSketchCounters {
+			return fmt.Errorf("invalid sketch dimensions %dx%d", depth, width)
+		}
+		fi.countMinSketch = NewCountMinSketch(width, depth)
+		if _, err := fi.countMinSketch.ReadFrom(file); err != nil {
+			return err
+		}
+		// Honor a changed sketch configuration by rebuilding at the new size
+		rebuildSketch = !fi.sketchMatchesConfig()
+	} else {
+		// Older sketches used incompatible hashing; skip them and rebuild
+		if _, err := io.CopyN(io.Discard, file, legacySketchSize); err != nil {
+			return err
+		}
+		rebuildSketch = true
 	}
 
 	// Read path records
@@ -817,36 +1607,236 @@ func (fi *FilesystemIndexer) LoadFromFile(filePath string) error {
 
 	for i := uint32(0); i < recordCount; i++ {
 		var record PathRecord
-		if err := binary.Read(file, binary.LittleEndian, &record); err != nil {
-			return err
+		if version >= 8 {
+			if err := binary.Read(file, binary.LittleEndian, &record); err != nil {
+				return err
+			}
+		} else if version >= 6 {
+			// Without a modification time the hash is taken again on the next walk
+			var v6 v6PathRecord
+			if err := binary.Read(file, binary.LittleEndian, &v6); err != nil {
+				return err
+			}
+			record = PathRecord{
+				Path:        v6.Path,
+				Timestamp:   v6.Timestamp,
+				AccessCount: v6.AccessCount,
+				Flags:       v6.Flags,
+				ContentHash: v6.ContentHash,
+				Size:        v6.Size,
+				UID:         v6.UID,
+				Mode:        v6.Mode,
+			}
+		} else if version >= 4 {
+			var v4 v4PathRecord
+			if err := binary.Read(file, binary.LittleEndian, &v4); err != nil {
+				return err
+			}
+			record = PathRecord{
+				Path:        v4.Path,
+				Timestamp:   v4.Timestamp,
+				AccessCount: v4.AccessCount,
+				Flags:       v4.Flags,
+				ContentHash: v4.ContentHash,
+				Size:        v4.Size,
+			}
+		} else {
+			var legacy legacyPathRecord
+			if err := binary.Read(file, binary.LittleEndian, &legacy); err != nil {
+				return err
+			}
+			record = PathRecord{
+				Path:        legacy.Path,
+				Timestamp:   legacy.Timestamp,
+				AccessCount: legacy.AccessCount,
+				Flags:       legacy.Flags,
+			}
 		}
 		fi.pathRecords[i] = record
 		path := fi.bytesToPath(record.Path)
 		fi.pathIndex[path] = int(i)
 	}
 
+	fi.tags = make(map[string][]string)
+	if version >= 5 {
+		if err := fi.readTags(file); err != nil {
+			return fmt.Errorf("failed to read tags: %v", err)
+		}
+	}
+
+	if rebuildSketch {
+		fi.rebuildSketchFromRecords()
+	}
+
+	fi.loadedVersion = version
 	fi.isDirty = false
 	return nil
 }
 
+// Tags section (v5+): a uint32 entry count, then per tagged record its uint32
+// record index, a uint32 byte length and the tags joined by newlines.
+func (fi *FilesystemIndexer) writeTags(w io.Writer) error {
+	type tagEntry struct {
+		index  uint32
+		joined []byte
+	}
+	var entries []tagEntry
+	for i, record := range fi.pathRecords {
+		if tags := fi.tags[fi.bytesToPath(record.Path)]; len(tags) > 0 {
+			entries = append(entries, tagEntry{uint32(i), []byte(strings.Join(tags, "\n"))})
+		}
+	}
+
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(entries))); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := binary.Write(w, binary.LittleEndian, [2]uint32{entry.index, uint32(len(entry.joined))}); err != nil {
+			return err
+		}
+		if _, err := w.Write(entry.joined); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (fi *FilesystemIndexer) readTags(r io.Reader) error {
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return err
+	}
+	for i := uint32(0); i < count; i++ {
+		var header [2]uint32
+		if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+			return err
+		}
+		if int(header[0]) >= len(fi.pathRecords) || header[1] > maxTagsXattrSize {
+			return fmt.Errorf("invalid tag entry for record %d", header[0])
+		}
+		joined := make([]byte, header[1])
+		if _, err := io.ReadFull(r, joined); err != nil {
+			return err
+		}
+		path := fi.bytesToPath(fi.pathRecords[header[0]].Path)
+		fi.tags[path] = strings.Split(string(joined), "\n")
+	}
+	return nil
+}
+
+// maxSketchCounters bounds the sketch size read from an index header so a
+// corrupt file cannot trigger a huge allocation. Configured sketches are
+// capped to it too, or their index could not be loaded again.
+const maxSketchCounters = 1 << 26
+
+// checkSketchSize reports a sketch_width and sketch_depth with more counters
+// than maxSketchCounters, which NewCountMinSketch would cap
+func (c FilesystemConfig) checkSketchSize() error {
+	if c.SketchWidth > 0 && c.SketchDepth > 0 && c.SketchWidth > maxSketchCounters/c.SketchDepth {
+		return fmt.Errorf("filesystem.sketch_width × sketch_depth (%d × %d) exceeds %d counters", c.SketchWidth, c.SketchDepth, maxSketchCounters)
+	}
+	return nil
+}
+
+// sketchMatchesConfig reports whether the current sketch has the configured dimensions
+func (fi *FilesystemIndexer) sketchMatchesConfig() bool {
+	configured := NewCountMinSketch(fi.config.SketchWidth, fi.config.SketchDepth)
+	return fi.countMinSketch.Width() == configured.Width() && fi.countMinSketch.Depth() == configured.Depth()
+}
+
+// rebuildSketchFromRecords replaces the sketch with one of the configured size
+// populated from each record's access count
+func (fi *FilesystemIndexer) rebuildSketchFromRecords() {
+	fi.countMinSketch = NewCountMinSketch(fi.config.SketchWidth, fi.config.SketchDepth)
+	for _, record := range fi.pathRecords {
+		if record.AccessCount > 0 {
+			fi.countMinSketch.Add(fi.bytesToPath(record.Path), record.AccessCount)
+		}
+	}
+}
+
+func isSupportedIndexVersion(version uint32) bool {
+	for _, v := range supportedIndexVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadedVersion returns the format version of the index last read from disk,
+// or 0 if no index has been loaded.
+func (fi *FilesystemIndexer) LoadedVersion() uint32 {
+	return fi.loadedVersion
+}
+
+// MigrateIndex loads the on-disk index in any supported format and rewrites it
+// in the current format, preserving all records and root paths. The new file is
+// written next to the old one and renamed into place so a failed write never
+// leaves a truncated index behind. It returns the version that was migrated from.
+func (fi *FilesystemIndexer) MigrateIndex() (uint32, error) {
+	indexPath := fi.GetIndexPath()
+
+	if _, err := os.Stat(indexPath); err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("no index found at %s", indexPath)
+		}
+		return 0, err
+	}
+
+	if err := fi.LoadFromFile(indexPath); err != nil {
+		return 0, fmt.Errorf("failed to load index: %v", err)
+	}
+
+	fromVersion := fi.loadedVersion
+	if fromVersion == IndexFormatVersion {
+		return fromVersion, nil
+	}
+
+	if err := fi.SaveToFile(indexPath); err != nil {
+		return fromVersion, fmt.Errorf("failed to write migrated index: %v", err)
+	}
+
+	fi.loadedVersion = IndexFormatVersion
+	return fromVersion, nil
+}
+
+// GetIndexPath returns where the index is stored (see resolveIndexPath)
 func (fi *FilesystemIndexer) GetIndexPath() string {
-	homeDir, err := os.UserHomeDir()
+	return resolveIndexPath(fi.config.IndexPath, os.Getenv)
+}
+
+// resolveIndexPath picks the index location: configured (filesystem.index_path)
+// when set, otherwise recaller/fs_index.bin under $XDG_STATE_HOME, since the
+// access counts it keeps cannot be rebuilt. An index already in the home
+// directory as ~/.recaller_fs_index.bin keeps being used, so it is not lost.
+func resolveIndexPath(configured string, getenv func(string) string) string {
+	if configured != "" {
+		if strings.HasPrefix(configured, "~/") {
+			if homeDir, err := os.UserHomeDir(); err == nil {
+				configured = filepath.Join(homeDir, configured[2:])
+			}
+		}
+		return configured
+	}
+
+	path, err := recallerFilePath(getenv, ".recaller_fs_index.bin", "XDG_STATE_HOME", "fs_index.bin", false)
 	if err != nil {
 		return ".recaller_fs_index.bin"
 	}
-	return filepath.Join(homeDir, ".recaller_fs_index.bin")
+	return path
 }
 
 func (fi *FilesystemIndexer) LoadOrCreateIndex(showProgress bool) error {
 	indexPath := fi.GetIndexPath()
 
 	if _, err := os.Stat(indexPath); os.IsNotExist(err) {
-		log.Printf("No existing filesystem index found, will create new one")
+		fi.logger.Printf("No existing filesystem index found, will create new one")
 		return nil
 	}
 
 	if showProgress {
-		log.Printf("Loading existing filesystem index from: %s", indexPath)
+		fi.logger.Printf("Loading existing filesystem index from: %s", indexPath)
 	}
 	return fi.LoadFromFile(indexPath)
 }
@@ -858,16 +1848,82 @@ func (fi *FilesystemIndexer) PersistIndex(showProgress bool) error {
 
 	indexPath := fi.GetIndexPath()
 
+	if fi.needsBackup {
+		if err := fi.backupIndexFile(); err != nil {
+			return fmt.Errorf("failed to back up index before overwriting it: %w", err)
+		}
+		fi.needsBackup = false
+	}
+
 	if showProgress {
-		log.Printf("Persisting filesystem index to: %s", indexPath)
+		fi.logger.Printf("Persisting filesystem index to: %s", indexPath)
 	}
 	return fi.SaveToFile(indexPath)
 }
 
+// GetBackupPath returns where the index is copied before a destructive change
+func (fi *FilesystemIndexer) GetBackupPath() string {
+	return fi.GetIndexPath() + ".bak"
+}
+
+// backupIndexFile copies the on-disk index to the backup path, replacing any
+// previous backup so only the most recent one is kept
+func (fi *FilesystemIndexer) backupIndexFile() error {
+	src, err := os.Open(fi.GetIndexPath())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(fi.GetBackupPath(), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// RestoreBackup swaps the backup and the current index on disk, so running it
+// twice returns to where it started
+func (fi *FilesystemIndexer) RestoreBackup() error {
+	indexPath, backupPath := fi.GetIndexPath(), fi.GetBackupPath()
+	if _, err := os.Stat(backupPath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no index backup found at %s", backupPath)
+		}
+		return err
+	}
+
+	swapPath := indexPath + ".swap"
+	hasCurrent := true
+	if err := os.Rename(indexPath, swapPath); os.IsNotExist(err) {
+		hasCurrent = false
+	} else if err != nil {
+		return err
+	}
+
+	if err := os.Rename(backupPath, indexPath); err != nil {
+		if hasCurrent {
+			os.Rename(swapPath, indexPath)
+		}
+		return err
+	}
+
+	if hasCurrent {
+		return os.Rename(swapPath, backupPath)
+	}
+	return nil
+}
+
 func (fi *FilesystemIndexer) GetIndexStats() string {
 	indexSize := len(fi.pathRecords) * int(unsafe.Sizeof(PathRecord{}))
-	sketchSize := CountMinDepth * CountMinWidth * 4 // int32 = 4 bytes
-	bloomSize := int(fi.bloomFilter.Cap() / 8)      // Approximate bloom filter size in bytes
+	sketchSize := fi.countMinSketch.SizeBytes()
+	bloomSize := int(fi.bloomFilter.Cap() / 8) // Approximate bloom filter size in bytes
 
 	return fmt.Sprintf("Index Stats: %d files, Memory: %.2fKB (Records: %.2fKB, Metadata: %.2fKB)",
 		len(fi.pathRecords),
@@ -878,10 +1934,11 @@ func (fi *FilesystemIndexer) GetIndexStats() string {
 
 // CleanupOptions defines options for index cleanup
 type CleanupOptions struct {
-	Path          string // Optional path prefix filter
-	RemoveStale   bool   // Remove non-existent files
-	OlderThanDays int    // Remove entries older than N days
-	ShowProgress  bool   // Show progress bar
+	Path          string                 // Optional path prefix filter
+	RemoveStale   bool                   // Remove non-existent files
+	OlderThanDays int                    // Remove entries older than N days
+	ShowProgress  bool                   // Show progress bar
+	Match         func(path string) bool // Optional: also remove paths it reports true for
 }
 
 // CleanupStats contains statistics from cleanup operation
@@ -893,7 +1950,8 @@ type CleanupStats struct {
 	FreedKB        float64
 }
 
-// CleanupIndex removes stale and old entries from the filesystem index
+// CleanupIndex removes stale and old entries from the filesystem index. When
+// entries are removed, the next PersistIndex first backs up the on-disk index.
 func (fi *FilesystemIndexer) CleanupIndex(options CleanupOptions) (*CleanupStats, error) {
 	stats := &CleanupStats{
 		TotalEntries: len(fi.pathRecords),
@@ -902,7 +1960,7 @@ func (fi *FilesystemIndexer) CleanupIndex(options CleanupOptions) (*CleanupStats
 	var bar *progressbar.ProgressBar
 	if options.ShowProgress {
 		bar = progressbar.NewOptions(len(fi.pathRecords),
-			progressbar.OptionSetDescription("🧹 Cleaning index..."),
+			progressbar.OptionSetDescription(plainText("🧹 Cleaning index...")),
 			progressbar.OptionSetWidth(50),
 			progressbar.OptionShowCount(),
 			progressbar.OptionSetTheme(progressbar.Theme{
@@ -936,6 +1994,10 @@ func (fi *FilesystemIndexer) CleanupIndex(options CleanupOptions) (*CleanupStats
 				stats.RemovedEntries++
 			}
 		}
+		if !shouldRemove && options.Match != nil && options.Match(path) {
+			shouldRemove = true
+			stats.RemovedEntries++
+		}
 
 		// Check if file still exists (stale check)
 		if !shouldRemove && options.RemoveStale {
@@ -958,6 +2020,7 @@ func (fi *FilesystemIndexer) CleanupIndex(options CleanupOptions) (*CleanupStats
 
 		if shouldRemove {
 			removedPaths[path] = true
+			delete(fi.tags, path)
 		} else {
 			validRecords = append(validRecords, record)
 			validPaths = append(validPaths, path)
@@ -982,7 +2045,7 @@ func (fi *FilesystemIndexer) CleanupIndex(options CleanupOptions) (*CleanupStats
 
 		// Create new bloom filter and count-min sketch
 		newBloomFilter := bloom.New(fi.config.BloomFilterSize, fi.config.BloomFilterHashes)
-		newCountMinSketch := NewCountMinSketch()
+		newCountMinSketch := NewCountMinSketch(fi.config.SketchWidth, fi.config.SketchDepth)
 
 		// Re-populate bloom filter and sketch with valid entries
 		for _, record := range validRecords {
@@ -997,6 +2060,7 @@ func (fi *FilesystemIndexer) CleanupIndex(options CleanupOptions) (*CleanupStats
 		fi.bloomFilter = newBloomFilter
 		fi.countMinSketch = newCountMinSketch
 		fi.isDirty = true
+		fi.needsBackup = true
 	}
 
 	return stats, nil
@@ -1035,14 +2099,18 @@ func (fi *FilesystemIndexer) FullCleanup(olderThanDays int, showProgress bool) (
 	})
 }
 
-// ClearIndex completely clears the filesystem index
+// ClearIndex completely clears the filesystem index. The next PersistIndex
+// first backs up the on-disk index so it can be restored.
 func (fi *FilesystemIndexer) ClearIndex() error {
 	fi.pathRecords = fi.pathRecords[:0]
 	fi.pathIndex = make(map[string]int)
 	fi.rootPaths = fi.rootPaths[:0]
+	fi.tags = make(map[string][]string)
 	fi.bloomFilter = bloom.New(fi.config.BloomFilterSize, fi.config.BloomFilterHashes)
-	fi.countMinSketch = NewCountMinSketch()
+	fi.countMinSketch = NewCountMinSketch(fi.config.SketchWidth, fi.config.SketchDepth)
+	fi.lastIndexed = time.Time{}
 	fi.isDirty = true
+	fi.needsBackup = true
 	return nil
 }
 
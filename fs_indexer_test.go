@@ -0,0 +1,1254 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/willf/bloom"
+)
+
+// TestCountMinSketchErrorBound checks the standard count-min guarantee: with
+// width w and depth d, an estimate exceeds the true count by more than (e/w)*N
+// with probability at most e^-d.
+func TestCountMinSketchErrorBound(t *testing.T) {
+	const (
+		width = 2048
+		depth = 4
+		items = 20000
+	)
+	cms := NewCountMinSketch(width, depth)
+
+	var total int64
+	for i := 0; i < items; i++ {
+		count := int32(i%10 + 1)
+		cms.Add(fmt.Sprintf("/home/user/project/file-%d.go", i), count)
+		total += int64(count)
+	}
+
+	bound := int32(math.E / width * float64(total))
+	exceeded := 0
+	var errSum int64
+	for i := 0; i < items; i++ {
+		truth := int32(i%10 + 1)
+		est := cms.Estimate(fmt.Sprintf("/home/user/project/file-%d.go", i))
+		if est < truth {
+			t.Fatalf("estimate %d below true count %d for item %d", est, truth, i)
+		}
+		if est-truth > bound {
+			exceeded++
+		}
+		errSum += int64(est - truth)
+	}
+
+	// A single row over-counts by N/w on average; the minimum over rows must do better
+	if mean := float64(errSum) / items; mean > float64(total)/width {
+		t.Errorf("mean over-count %.1f exceeds N/w = %.1f", mean, float64(total)/width)
+	}
+
+	// e^-4 is about 1.8%; allow a little slack over the theoretical rate
+	if rate := float64(exceeded) / items; rate > 0.025 {
+		t.Errorf("%.2f%% of estimates exceeded the error bound %d; want <= 2.5%%", rate*100, bound)
+	}
+}
+
+func TestCountMinSketchDimensions(t *testing.T) {
+	cms := NewCountMinSketch(100, 7)
+	if cms.Width() != 100 || cms.Depth() != 7 {
+		t.Errorf("NewCountMinSketch(100, 7) = %dx%d; want 7x100", cms.Depth(), cms.Width())
+	}
+
+	cms = NewCountMinSketch(0, -1)
+	if cms.Width() != CountMinWidth || cms.Depth() != CountMinDepth {
+		t.Errorf("NewCountMinSketch(0, -1) = %dx%d; want defaults %dx%d", cms.Depth(), cms.Width(), CountMinDepth, CountMinWidth)
+	}
+
+	cms = NewCountMinSketch(16, 12)
+	cms.Add("ls", 3)
+	if got := cms.Estimate("ls"); got != 3 {
+		t.Errorf("Estimate(ls) = %d; want 3", got)
+	}
+}
+
+// newTestIndexer returns an indexer with default settings and some accessed files.
+func newTestIndexer(t *testing.T, config FilesystemConfig) (*FilesystemIndexer, map[string]int32) {
+	t.Helper()
+	dir := t.TempDir()
+	fi := NewFilesystemIndexer(config)
+	accesses := map[string]int32{}
+	for i := 1; i <= 3; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < i; j++ {
+			fi.AddPath(path, time.Now(), true)
+		}
+		accesses[path] = int32(i)
+	}
+	return fi, accesses
+}
+
+func assertFrequencies(t *testing.T, fi *FilesystemIndexer, accesses map[string]int32) {
+	t.Helper()
+	for path, want := range accesses {
+		if got := fi.GetFrequency(path); got != want {
+			t.Errorf("GetFrequency(%s) = %d; want %d", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestIndexRoundTripKeepsSketch(t *testing.T) {
+	config := cloneDefaultConfig().Filesystem
+	fi, accesses := newTestIndexer(t, config)
+
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	if err := fi.SaveToFile(indexPath); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if loaded.LoadedVersion() != IndexFormatVersion {
+		t.Errorf("LoadedVersion() = %d; want %d", loaded.LoadedVersion(), IndexFormatVersion)
+	}
+	assertFrequencies(t, loaded, accesses)
+}
+
+func TestIndexSaveLeavesNoTempFiles(t *testing.T) {
+	config := cloneDefaultConfig().Filesystem
+	fi, _ := newTestIndexer(t, config)
+
+	dir := t.TempDir()
+	indexPath := filepath.Join(dir, "index.bin")
+	for i := 0; i < 2; i++ {
+		if err := fi.SaveToFile(indexPath); err != nil {
+			t.Fatalf("SaveToFile: %v", err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "index.bin" {
+		t.Errorf("index dir holds %v; want only index.bin", entries)
+	}
+}
+
+// TestIndexCloneIsIndependent checks that accesses recorded on a clone, as a
+// background refresh does, only reach the original through ReplaceWith.
+func TestIndexCloneIsIndependent(t *testing.T) {
+	config := cloneDefaultConfig().Filesystem
+	fi, accesses := newTestIndexer(t, config)
+
+	clone := fi.Clone()
+	for path := range accesses {
+		clone.AddPath(path, time.Now(), true)
+	}
+	assertFrequencies(t, fi, accesses)
+
+	fi.ReplaceWith(clone)
+	for path := range accesses {
+		accesses[path]++
+	}
+	assertFrequencies(t, fi, accesses)
+}
+
+func TestIndexLoadRebuildsSketchForNewDepth(t *testing.T) {
+	config := cloneDefaultConfig().Filesystem
+	fi, accesses := newTestIndexer(t, config)
+
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	if err := fi.SaveToFile(indexPath); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+
+	config.SketchDepth = 6
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if loaded.countMinSketch.Depth() != 6 {
+		t.Errorf("sketch depth = %d; want 6", loaded.countMinSketch.Depth())
+	}
+	assertFrequencies(t, loaded, accesses)
+}
+
+// TestIndexLoadLegacyVersion writes a v2 index whose sketch uses the old layout
+// and checks that the sketch is rebuilt from the records' access counts.
+func TestIndexLoadLegacyVersion(t *testing.T) {
+	config := cloneDefaultConfig().Filesystem
+	fi, accesses := newTestIndexer(t, config)
+
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	file, err := os.Create(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(v any) {
+		if err := binary.Write(file, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write([8]byte{'R', 'E', 'C', 'A', 'L', 'L', 'E', 'R'})
+	write(uint32(2))
+	write(uint32(len(fi.pathRecords)))
+	write(uint32(0)) // root path count
+	write([12]byte{})
+	if _, err := bloom.New(config.BloomFilterSize, config.BloomFilterHashes).WriteTo(file); err != nil {
+		t.Fatal(err)
+	}
+	var legacySketch [CountMinDepth][CountMinWidth]int32
+	for i := range legacySketch {
+		for j := range legacySketch[i] {
+			legacySketch[i][j] = 99
+		}
+	}
+	write(legacySketch)
+	for _, record := range fi.pathRecords {
+		write(legacyPathRecord{
+			Path:        record.Path,
+			Timestamp:   record.Timestamp,
+			AccessCount: record.AccessCount,
+			Flags:       record.Flags,
+		})
+	}
+	file.Close()
+
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if loaded.LoadedVersion() != 2 {
+		t.Errorf("LoadedVersion() = %d; want 2", loaded.LoadedVersion())
+	}
+	assertFrequencies(t, loaded, accesses)
+}
+
+func TestFindDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":       "hello world",
+		"copy/a.txt":  "hello world",
+		"b.txt":       "something else",
+		"big1.bin":    string(make([]byte, 200*1024)),
+		"big2.bin":    string(make([]byte, 200*1024)),
+		"big3.bin":    string(make([]byte, 200*1024)),
+		"empty1.txt":  "",
+		"empty2.txt":  "",
+		"nearly.txt":  "hello worle",
+		"copy/b2.txt": "something else!",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := cloneDefaultConfig().Filesystem
+	config.HashContents = true
+	fi := NewFilesystemIndexer(config)
+	if err := fi.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+
+	groups := fi.FindDuplicates()
+	if len(groups) != 2 {
+		t.Fatalf("FindDuplicates returned %d groups; want 2: %+v", len(groups), groups)
+	}
+	if len(groups[0].Paths) != 3 || groups[0].WastedBytes() != 2*200*1024 {
+		t.Errorf("largest group = %d paths, %d wasted; want 3 paths, %d wasted", len(groups[0].Paths), groups[0].WastedBytes(), 2*200*1024)
+	}
+	want := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "copy/a.txt")}
+	if fmt.Sprint(groups[1].Paths) != fmt.Sprint(want) {
+		t.Errorf("second group = %v; want %v", groups[1].Paths, want)
+	}
+
+	// Hashes survive a save/load round trip
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	if err := fi.SaveToFile(indexPath); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if got := len(loaded.FindDuplicates()); got != 2 {
+		t.Errorf("FindDuplicates after reload returned %d groups; want 2", got)
+	}
+
+	// Without hashing nothing is reported
+	config.HashContents = false
+	plain := NewFilesystemIndexer(config)
+	plain.IndexDirectory(dir)
+	if got := len(plain.FindDuplicates()); got != 0 {
+		t.Errorf("FindDuplicates without hashing returned %d groups; want 0", got)
+	}
+}
+
+func TestContentHashFollowsModTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("aaaa"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := cloneDefaultConfig().Filesystem
+	config.HashContents = true
+	fi := NewFilesystemIndexer(config)
+	fi.AddPath(path, time.Time{}, false)
+	before := fi.pathRecords[fi.pathIndex[path]].ContentHash
+
+	// A same-size rewrite is only told apart by its modification time
+	if err := os.WriteFile(path, []byte("bbbb"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	fi.AddPath(path, time.Time{}, false)
+	record := fi.pathRecords[fi.pathIndex[path]]
+	if record.ContentHash == before || record.ModTime != later.UnixNano() {
+		t.Errorf("after a same-size rewrite hash = %x (was %x), mod time %d; want a new hash taken at %d", record.ContentHash, before, record.ModTime, later.UnixNano())
+	}
+}
+
+// TestIndexLoadV7RehashesContents reads a v7 index, whose records predate
+// modification times, and checks that hashed files keep their hash until
+// they are walked again
+func TestIndexLoadV7RehashesContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("aaaa"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := cloneDefaultConfig().Filesystem
+	config.HashContents = true
+	fi := NewFilesystemIndexer(config)
+	fi.AddPath(path, time.Time{}, false)
+
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	file, err := os.Create(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(v any) {
+		if err := binary.Write(file, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reserved := [12]byte{}
+	binary.LittleEndian.PutUint32(reserved[0:4], uint32(fi.countMinSketch.Width()))
+	binary.LittleEndian.PutUint32(reserved[4:8], uint32(fi.countMinSketch.Depth()))
+	write([8]byte{'R', 'E', 'C', 'A', 'L', 'L', 'E', 'R'})
+	write(uint32(7))
+	write(uint32(len(fi.pathRecords)))
+	write(uint32(0)) // root path count
+	write(reserved)
+	write(int64(0)) // last indexed
+	if _, err := fi.bloomFilter.WriteTo(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fi.countMinSketch.WriteTo(file); err != nil {
+		t.Fatal(err)
+	}
+	hash := fi.pathRecords[0].ContentHash
+	for _, record := range fi.pathRecords {
+		write(v6PathRecord{
+			Path:        record.Path,
+			Timestamp:   record.Timestamp,
+			Flags:       record.Flags,
+			ContentHash: record.ContentHash,
+			Size:        record.Size,
+			UID:         record.UID,
+			Mode:        record.Mode,
+		})
+	}
+	if err := fi.writeTags(file); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if record := loaded.pathRecords[0]; record.ContentHash != hash || record.ModTime != 0 {
+		t.Fatalf("loaded hash %x, mod time %d; want %x without a mod time", record.ContentHash, record.ModTime, hash)
+	}
+	loaded.AddPath(path, time.Time{}, false)
+	if record := loaded.pathRecords[0]; record.ContentHash != hash || record.ModTime == 0 {
+		t.Errorf("after a walk hash %x, mod time %d; want %x with the file's mod time", record.ContentHash, record.ModTime, hash)
+	}
+}
+
+func TestSearchDirectoriesSkipsFiles(t *testing.T) {
+	dir := t.TempDir()
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+
+	paths := map[string]int{
+		filepath.Join(dir, "project-old"):      1,
+		filepath.Join(dir, "project-new"):      3,
+		filepath.Join(dir, "project-notes.md"): 5,
+	}
+	for path, visits := range paths {
+		var err error
+		if filepath.Ext(path) == ".md" {
+			err = os.WriteFile(path, []byte("x"), 0600)
+		} else {
+			err = os.Mkdir(path, 0700)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < visits; i++ {
+			fi.AddPath(path, time.Now(), true)
+		}
+	}
+
+	var got []string
+	for _, match := range fi.SearchDirectories("project", true) {
+		got = append(got, filepath.Base(match.Path))
+	}
+	want := []string{"project-new", "project-old"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("SearchDirectories = %v; want %v", got, want)
+	}
+}
+
+func TestSearchFilesPrefersBasenameMatches(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "config"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	dirOnly := filepath.Join(dir, "config", "random.txt")
+	basename := filepath.Join(dir, "app.config")
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	now := time.Now()
+	for _, path := range []string{dirOnly, basename} {
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		fi.AddPath(path, now, true)
+	}
+
+	results := fi.SearchFiles("config", true)
+	if len(results) < 2 {
+		t.Fatalf("SearchFiles returned %d results; want at least 2", len(results))
+	}
+	if results[0].Path != basename {
+		t.Errorf("top result = %s; want basename match %s", results[0].Path, basename)
+	}
+}
+
+func TestPriorityPathsOutrankEquallyRecentFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"current", "other"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	prioritized := filepath.Join(dir, "current", "notes.md")
+	other := filepath.Join(dir, "other", "notes.md")
+
+	config := cloneDefaultConfig().Filesystem
+	config.PriorityPaths = []string{filepath.Join(dir, "current")}
+	fi := NewFilesystemIndexer(config)
+	now := time.Now()
+	// Index the other file first so insertion order cannot explain the result
+	for _, path := range []string{other, prioritized} {
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		fi.AddPath(path, now, true)
+	}
+
+	results := fi.SearchFiles("notes", true)
+	if len(results) != 2 {
+		t.Fatalf("SearchFiles returned %d results; want 2", len(results))
+	}
+	if results[0].Path != prioritized {
+		t.Errorf("top result = %s; want %s under the priority path", results[0].Path, prioritized)
+	}
+	if fi.underPriorityPath(filepath.Join(dir, "current-old", "notes.md")) {
+		t.Error("a sibling sharing the prefix string should not count as under the priority path")
+	}
+}
+
+func TestClearIndexBackupAndRestore(t *testing.T) {
+	setTestHome(t)
+
+	fi, _ := newTestIndexer(t, cloneDefaultConfig().Filesystem)
+	if err := fi.PersistIndex(false); err != nil {
+		t.Fatalf("PersistIndex: %v", err)
+	}
+	if err := fi.RestoreBackup(); err == nil {
+		t.Error("RestoreBackup without a backup should fail")
+	}
+
+	fi.ClearIndex()
+	if err := fi.PersistIndex(false); err != nil {
+		t.Fatalf("PersistIndex after clear: %v", err)
+	}
+	if _, err := os.Stat(fi.GetBackupPath()); err != nil {
+		t.Fatalf("expected a backup after clearing: %v", err)
+	}
+
+	if err := fi.RestoreBackup(); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	restored := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	if err := restored.LoadOrCreateIndex(false); err != nil {
+		t.Fatalf("LoadOrCreateIndex: %v", err)
+	}
+	if got := len(restored.pathRecords); got != 3 {
+		t.Errorf("restored index has %d records; want 3", got)
+	}
+
+	// Restoring again swaps back to the cleared index
+	if err := fi.RestoreBackup(); err != nil {
+		t.Fatalf("second RestoreBackup: %v", err)
+	}
+	cleared := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	if err := cleared.LoadOrCreateIndex(false); err != nil {
+		t.Fatalf("LoadOrCreateIndex: %v", err)
+	}
+	if cleared.HasIndexedFiles() {
+		t.Error("second restore should swap back to the cleared index")
+	}
+}
+
+func TestIndexModifiedWithinDays(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "archive")
+	if err := os.Mkdir(nested, 0700); err != nil {
+		t.Fatal(err)
+	}
+	// The directory itself is old but its recent contents must still be found
+	oldDir := time.Now().AddDate(0, 0, -90)
+	ages := map[string]int{
+		filepath.Join(dir, "today.txt"):      0,
+		filepath.Join(dir, "last-week.txt"):  6,
+		filepath.Join(dir, "last-month.txt"): 30,
+		filepath.Join(nested, "recent.txt"):  1,
+		filepath.Join(nested, "ancient.txt"): 400,
+	}
+	for path, days := range ages {
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().AddDate(0, 0, -days)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Chtimes(nested, oldDir, oldDir)
+
+	config := cloneDefaultConfig().Filesystem
+	config.IndexModifiedWithinDays = 7
+	fi := NewFilesystemIndexer(config)
+	if err := fi.IndexDirectoryWithProgress(dir, false); err != nil {
+		t.Fatalf("IndexDirectoryWithProgress: %v", err)
+	}
+
+	var got []string
+	for path := range fi.pathIndex {
+		if rel, _ := filepath.Rel(dir, path); rel != "." {
+			got = append(got, filepath.ToSlash(rel))
+		}
+	}
+	sort.Strings(got)
+	want := []string{"archive", "archive/recent.txt", "last-week.txt", "today.txt"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("indexed %v; want %v", got, want)
+	}
+
+	config.IndexModifiedWithinDays = 0
+	fi = NewFilesystemIndexer(config)
+	if err := fi.IndexDirectoriesWithProgress([]string{dir}, false); err != nil {
+		t.Fatalf("IndexDirectoriesWithProgress: %v", err)
+	}
+	if got := len(fi.pathIndex); got != len(ages)+2 {
+		t.Errorf("indexed %d paths with the option off; want %d", got, len(ages)+2)
+	}
+}
+
+func TestIndexGitTrackedOnly(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	for _, name := range []string{"main.go", "src/app/util.go", "src/README.md", "src/app/app.test", "out/app.bin", "notes.txt"} {
+		path := filepath.Join(repo, name)
+		os.MkdirAll(filepath.Dir(path), 0700)
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "main.go", "src/app/util.go", "src/README.md"}} {
+		if output, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	indexed := func(fi *FilesystemIndexer, root string) []string {
+		var got []string
+		for path := range fi.pathIndex {
+			if rel, _ := filepath.Rel(root, path); rel != "." {
+				got = append(got, filepath.ToSlash(rel))
+			}
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	config := cloneDefaultConfig().Filesystem
+	config.GitTrackedOnly = true
+	fi := NewFilesystemIndexer(config)
+	if err := fi.IndexDirectory(repo); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+	want := []string{"main.go", "src", "src/README.md", "src/app", "src/app/util.go"}
+	if got := indexed(fi, repo); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("indexed %v; want only the tracked files %v", got, want)
+	}
+
+	// A root below the top of the repository
+	src := filepath.Join(repo, "src")
+	fi = NewFilesystemIndexer(config)
+	if err := fi.IndexDirectoriesWithProgress([]string{src}, false); err != nil {
+		t.Fatalf("IndexDirectoriesWithProgress: %v", err)
+	}
+	want = []string{"README.md", "app", "app/util.go"}
+	if got := indexed(fi, src); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("indexed %v under src; want %v", got, want)
+	}
+
+	// Outside a repository everything is indexed
+	plain := t.TempDir()
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(plain, name)), 0700)
+		os.WriteFile(filepath.Join(plain, name), []byte("x"), 0600)
+	}
+	fi = NewFilesystemIndexer(config)
+	if err := fi.IndexDirectory(plain); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+	want = []string{"a.txt", "sub", "sub/b.txt"}
+	if got := indexed(fi, plain); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("indexed %v outside git; want %v", got, want)
+	}
+}
+
+func TestCountIndexableEntriesMatchesIndexing(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "skip.log", "sub/c.txt", "node_modules/d.js"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	total, ok := fi.countIndexableEntries(context.Background(), []string{dir}, 0)
+	if !ok {
+		t.Fatal("count was aborted")
+	}
+	if err := fi.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+	if total != len(fi.pathRecords) {
+		t.Errorf("pre-count = %d; indexing added %d entries", total, len(fi.pathRecords))
+	}
+
+	if _, ok := fi.countIndexableEntries(context.Background(), []string{dir}, 2); ok {
+		t.Error("count should give up beyond the limit")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok := fi.countIndexableEntries(ctx, []string{dir}, 0); ok {
+		t.Error("count should stop when cancelled")
+	}
+}
+
+func TestGuardedWalkAbandonsStalledEntry(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "slow.txt", "z.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// slow.txt never returns, like a stat on a hung network mount
+	hang := make(chan struct{})
+	defer close(hang)
+	// Once the walk is given up, the callback stuck on it changes nothing
+	guard := &walkGuard{}
+	var visited []string
+	err := guardedWalk(context.Background(), dir, 100*time.Millisecond, guard, func(path string, d fs.DirEntry, err error) error {
+		if filepath.Base(path) == "slow.txt" && !guard.blocking(func() { <-hang }) {
+			return filepath.SkipAll
+		}
+		visited = append(visited, filepath.Base(path))
+		return nil
+	})
+
+	if !errors.Is(err, errIndexStalled) || !isIndexTimeout(err) {
+		t.Fatalf("guardedWalk error = %v; want errIndexStalled", err)
+	}
+	if !strings.Contains(err.Error(), "slow.txt") {
+		t.Errorf("error %q should name the stalled path", err)
+	}
+	if fmt.Sprint(visited) != fmt.Sprint([]string{filepath.Base(dir), "a.txt"}) {
+		t.Errorf("visited %v before the stall", visited)
+	}
+}
+
+func TestGuardedWalkDeadline(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	hang := make(chan struct{})
+	defer close(hang)
+	guard := &walkGuard{}
+	err := guardedWalk(ctx, dir, 0, guard, func(path string, d fs.DirEntry, err error) error {
+		guard.blocking(func() { <-hang })
+		return nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !isIndexTimeout(err) {
+		t.Errorf("guardedWalk error = %v; want the deadline reported", err)
+	}
+}
+
+func TestSearchFilesBreaksTiesByPath(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	names := []string{"report-c.txt", "report-a.txt", "report-b.txt"}
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		fi.AddPath(path, now, true)
+	}
+
+	var got []string
+	for _, result := range fi.SearchFiles("report", true) {
+		got = append(got, filepath.Base(result.Path))
+	}
+	want := []string{"report-a.txt", "report-b.txt", "report-c.txt"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("equally scored files ordered %v; want %v", got, want)
+	}
+}
+
+func TestFileScoringWithFrozenClock(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "plan.md")
+	sub := filepath.Join(dir, "plans")
+	if err := os.WriteFile(file, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	fi.AddPath(file, now.Add(-time.Hour), true)
+	fi.AddPath(sub, now, true)
+
+	// 0.7*access count + 0.3/(hours since last access + 1), directories x0.8
+	want := map[string]float64{
+		file: 0.7 + 0.3/2,
+		sub:  (0.7 + 0.3) * 0.8,
+	}
+	results := fi.SearchFiles("plan", true)
+	if len(results) != 2 {
+		t.Fatalf("SearchFiles returned %d results; want 2", len(results))
+	}
+	if results[0].Path != file {
+		t.Errorf("top result = %s; want %s", results[0].Path, file)
+	}
+	for _, result := range results {
+		if math.Abs(result.Score-want[result.Path]*basenameMatchBonus) > 1e-12 {
+			t.Errorf("%s scored %.6f; want %.6f", result.Path, result.Score, want[result.Path]*basenameMatchBonus)
+		}
+	}
+}
+
+func TestResolveIndexPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	state := t.TempDir()
+	env := func(xdg string) func(string) string {
+		return func(key string) string {
+			if key == "XDG_STATE_HOME" {
+				return xdg
+			}
+			return ""
+		}
+	}
+	legacy := filepath.Join(home, ".recaller_fs_index.bin")
+
+	if got := resolveIndexPath("/data/index.bin", env(state)); got != "/data/index.bin" {
+		t.Errorf("configured path = %q", got)
+	}
+	if got := resolveIndexPath("~/idx/index.bin", env("")); got != filepath.Join(home, "idx/index.bin") {
+		t.Errorf("configured ~ path = %q", got)
+	}
+	defaultPath := filepath.Join(home, ".local", "state", "recaller", "fs_index.bin")
+	if got := resolveIndexPath("", env("")); got != defaultPath {
+		t.Errorf("default = %q; want %q", got, defaultPath)
+	}
+	if got := resolveIndexPath("", env("relative/state")); got != defaultPath {
+		t.Errorf("relative XDG_STATE_HOME = %q; want it ignored", got)
+	}
+	if got, want := resolveIndexPath("", env(state)), filepath.Join(state, "recaller", "fs_index.bin"); got != want {
+		t.Errorf("XDG_STATE_HOME = %q; want %q", got, want)
+	}
+
+	// An existing index in the home directory is kept in use
+	if err := os.WriteFile(legacy, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolveIndexPath("", env(state)); got != legacy {
+		t.Errorf("with a home index = %q; want %q", got, legacy)
+	}
+}
+
+func TestSaveToFileCreatesParentDirectories(t *testing.T) {
+	config := cloneDefaultConfig().Filesystem
+	fi, accesses := newTestIndexer(t, config)
+
+	indexPath := filepath.Join(t.TempDir(), "cache", "recaller", "fs_index.bin")
+	if err := fi.SaveToFile(indexPath); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	assertFrequencies(t, loaded, accesses)
+}
+
+func TestRootPathStatsAndRemoveRootPath(t *testing.T) {
+	dir := t.TempDir()
+	projects := filepath.Join(dir, "projects")
+	docs := filepath.Join(dir, "docs")
+	for _, path := range []string{
+		filepath.Join(projects, "app", "main.go"),
+		filepath.Join(projects, "README.md"),
+		filepath.Join(docs, "notes.txt"),
+		filepath.Join(dir, "projects-old", "x.txt"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	if err := fi.IndexDirectories([]string{projects, docs, filepath.Join(dir, "projects-old")}); err != nil {
+		t.Fatalf("IndexDirectories: %v", err)
+	}
+	if err := os.RemoveAll(docs); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := fi.RootPathStats()
+	want := []RootPathStat{
+		{Path: projects, Exists: true, Records: 4}, // projects, app, main.go, README.md
+		{Path: docs, Exists: false, Records: 2},
+		{Path: filepath.Join(dir, "projects-old"), Exists: true, Records: 2},
+	}
+	if fmt.Sprint(stats) != fmt.Sprint(want) {
+		t.Errorf("RootPathStats = %+v; want %+v", stats, want)
+	}
+
+	if _, err := fi.RemoveRootPath(filepath.Join(dir, "elsewhere"), true); err == nil {
+		t.Error("removing an untracked root should fail")
+	}
+	if removed, err := fi.RemoveRootPath(docs, false); err != nil || removed != 0 {
+		t.Errorf("RemoveRootPath without records = %d, %v", removed, err)
+	}
+	if got := len(fi.pathRecords); got != 8 {
+		t.Errorf("%d records after untracking; want all 8 kept", got)
+	}
+
+	// Records of the sibling projects-old directory are not under projects
+	removed, err := fi.RemoveRootPath(projects, true)
+	if err != nil || removed != 4 {
+		t.Errorf("RemoveRootPath with records = %d, %v; want 4 removed", removed, err)
+	}
+	if roots := fi.GetRootPaths(); len(roots) != 1 || roots[0] != filepath.Join(dir, "projects-old") {
+		t.Errorf("roots left = %v", roots)
+	}
+}
+
+func TestExtensionStats(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]int{
+		"app.js":         10,
+		"app.js.map":     300,
+		"vendor.js.map":  500,
+		"lib/util.JS":    20,
+		"lib/bundle.map": 700,
+		"Makefile":       5,
+	}
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	if err := fi.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+
+	var got []string
+	for _, stat := range fi.ExtensionStats() {
+		got = append(got, fmt.Sprintf("%s:%d:%d", stat.Extension, stat.Files, stat.Bytes))
+	}
+	want := []string{".map:3:1500", ".js:2:30", ":1:5"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ExtensionStats = %v; want %v", got, want)
+	}
+
+	var out bytes.Buffer
+	printExtensionStats(&out, fi.ExtensionStats(), 1)
+	table := StripANSI(out.String())
+	for _, line := range []string{".map", "1.5 KB", "(2 more)"} {
+		if !strings.Contains(table, line) {
+			t.Errorf("table with --top 1 is missing %q:\n%s", line, table)
+		}
+	}
+	if strings.Contains(table, ".js ") {
+		t.Errorf("table with --top 1 lists more than one extension:\n%s", table)
+	}
+}
+
+func TestIndexCapacity(t *testing.T) {
+	dir := t.TempDir()
+	config := cloneDefaultConfig().Filesystem
+	config.MaxIndexedFiles = 1000
+	config.IndexPath = filepath.Join(dir, "not", "yet", "fs_index.bin")
+	fi := NewFilesystemIndexer(config)
+	fi.addRootPath(dir)
+
+	capacity := fi.IndexCapacity()
+	if capacity.CurrentBytes != 0 || capacity.FreeBytes <= 0 || capacity.Volume != dir {
+		t.Errorf("capacity before saving = %+v; want no index yet and the free space of %s", capacity, dir)
+	}
+
+	// The projection matches a saved index with that many records
+	for i := range config.MaxIndexedFiles {
+		fi.AddPath(filepath.Join(dir, fmt.Sprintf("file-%d", i)), time.Time{}, false)
+	}
+	if err := fi.PersistIndex(false); err != nil {
+		t.Fatalf("PersistIndex: %v", err)
+	}
+	saved := fi.IndexCapacity()
+	if saved.CurrentBytes != capacity.ProjectedBytes {
+		t.Errorf("saved index is %d bytes; projected %d", saved.CurrentBytes, capacity.ProjectedBytes)
+	}
+	if saved.Growth() != 0 || saved.AtRisk() {
+		t.Errorf("full index = %+v; want no growth left", saved)
+	}
+
+	tight := IndexCapacity{MaxFiles: 100000, ProjectedBytes: 60 << 20, CurrentBytes: 1 << 20, FreeBytes: 100 << 20, Volume: "/data"}
+	var out bytes.Buffer
+	printIndexCapacity(&out, tight)
+	if !tight.AtRisk() || !strings.Contains(StripANSI(out.String()), "needs 59.0 MB more") {
+		t.Errorf("59 MB of growth on 100 MB free should warn:\n%s", out.String())
+	}
+	tight.FreeBytes = 1 << 30
+	out.Reset()
+	printIndexCapacity(&out, tight)
+	if tight.AtRisk() || strings.Contains(out.String(), "⚠️") {
+		t.Errorf("59 MB of growth on 1 GB free should not warn:\n%s", out.String())
+	}
+}
+
+func TestExportRecords(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	notes := filepath.Join(dir, "docs", "notes, draft.txt")
+	if err := os.WriteFile(notes, make([]byte, 42), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".env"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A sibling sharing the directory name as a prefix is not under it
+	os.MkdirAll(filepath.Join(dir, "docs-old"), 0755)
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	if err := fi.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+	accessed := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	fi.AddPath(notes, accessed, true)
+
+	records := fi.ExportRecords(filepath.Join(dir, "docs"))
+	if len(records) != 2 {
+		t.Fatalf("ExportRecords under docs = %+v; want the directory and its file", records)
+	}
+	byPath := make(map[string]ExportedRecord)
+	for _, record := range fi.ExportRecords("") {
+		byPath[record.Path] = record
+	}
+	if len(byPath) != len(fi.pathRecords) {
+		t.Errorf("ExportRecords(\"\") returned %d entries; want all %d", len(byPath), len(fi.pathRecords))
+	}
+	got := byPath[notes]
+	if got.Size != 42 || got.IsDirectory || got.Timestamp == nil || !got.Timestamp.Equal(accessed) || got.AccessCount == 0 {
+		t.Errorf("exported file = %+v; want size 42, accessed at %v", got, accessed)
+	}
+	if !byPath[filepath.Join(dir, "docs")].IsDirectory {
+		t.Error("docs is not exported as a directory")
+	}
+	if !byPath[filepath.Join(dir, ".env")].IsHidden {
+		t.Error(".env is not exported as hidden")
+	}
+
+	var out bytes.Buffer
+	if err := writeIndexExport(&out, []ExportedRecord{got}, "csv"); err != nil {
+		t.Fatalf("csv export: %v", err)
+	}
+	wantCSV := "path,access_count,timestamp,is_directory,is_hidden,is_symlink,size\n" +
+		fmt.Sprintf("%q,%d,2025-03-14T12:00:00Z,false,false,false,42\n", notes, got.AccessCount)
+	if out.String() != wantCSV {
+		t.Errorf("csv export = %q; want %q", out.String(), wantCSV)
+	}
+
+	out.Reset()
+	if err := writeIndexExport(&out, nil, "json"); err != nil || strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("json export of no records = %q, %v; want []", out.String(), err)
+	}
+	out.Reset()
+	if err := writeIndexExport(&out, []ExportedRecord{got}, "json"); err != nil {
+		t.Fatalf("json export: %v", err)
+	}
+	for _, field := range []string{`"access_count"`, `"timestamp": "2025-03-14T12:00:00Z"`, `"is_symlink": false`, `"size": 42`} {
+		if !strings.Contains(out.String(), field) {
+			t.Errorf("json export is missing %s:\n%s", field, out.String())
+		}
+	}
+	if err := writeIndexExport(&out, nil, "xml"); err == nil {
+		t.Error("exporting as xml should fail")
+	}
+}
+
+func TestRefreshDueFollowsIndexCacheDuration(t *testing.T) {
+	indexedAt := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, indexedAt)
+
+	config := cloneDefaultConfig().Filesystem
+	config.IndexCacheDuration = 24
+	fi := NewFilesystemIndexer(config)
+	if !fi.RefreshDue() {
+		t.Error("an index that was never indexed must be due for a refresh")
+	}
+	if err := fi.IndexDirectory(t.TempDir()); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+	if !fi.LastIndexed().IsZero() {
+		t.Error("indexing one path must not mark the tracked roots as re-indexed")
+	}
+	if err := fi.ReindexExistingPaths(false); err != nil {
+		t.Fatalf("ReindexExistingPaths: %v", err)
+	}
+
+	// The time survives a save/load round trip through the header
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	if err := fi.SaveToFile(indexPath); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if !loaded.LastIndexed().Equal(indexedAt) {
+		t.Errorf("LastIndexed() = %v; want %v", loaded.LastIndexed(), indexedAt)
+	}
+
+	tests := []struct {
+		hours   int
+		elapsed time.Duration
+		want    bool
+	}{
+		{24, time.Hour, false},
+		{24, 23*time.Hour + 59*time.Minute, false},
+		{24, 24 * time.Hour, true},
+		{1, 2 * time.Hour, true},
+		{0, time.Minute, true},
+	}
+	for _, tt := range tests {
+		freezeTime(t, indexedAt.Add(tt.elapsed))
+		loaded.config.IndexCacheDuration = tt.hours
+		if got := loaded.RefreshDue(); got != tt.want {
+			t.Errorf("RefreshDue() with %dh after %v = %t; want %t", tt.hours, tt.elapsed, got, tt.want)
+		}
+	}
+
+	loaded.ClearIndex()
+	if !loaded.LastIndexed().IsZero() {
+		t.Error("ClearIndex must forget when the index was built")
+	}
+}
+
+func TestReindexSkipsNoAutoRefreshPaths(t *testing.T) {
+	fast, external, gone := t.TempDir(), t.TempDir(), t.TempDir()
+	for _, dir := range []string{fast, external, gone} {
+		if err := os.WriteFile(filepath.Join(dir, "old.txt"), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := cloneDefaultConfig().Filesystem
+	config.NoAutoRefreshPaths = []string{external}
+	fi := NewFilesystemIndexer(config)
+	if err := fi.IndexDirectories([]string{fast, external, gone}); err != nil {
+		t.Fatalf("IndexDirectories: %v", err)
+	}
+
+	for _, dir := range []string{fast, external} {
+		if err := os.WriteFile(filepath.Join(dir, "new.txt"), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fi.ReindexExistingPaths(false); err != nil {
+		t.Fatalf("ReindexExistingPaths: %v", err)
+	}
+	if _, ok := fi.pathIndex[filepath.Join(fast, "new.txt")]; !ok {
+		t.Error("a new file under a refreshed root was not indexed")
+	}
+	if _, ok := fi.pathIndex[filepath.Join(external, "new.txt")]; ok {
+		t.Error("a root in no_auto_refresh_paths was re-indexed")
+	}
+	if _, ok := fi.pathIndex[filepath.Join(external, "old.txt")]; !ok {
+		t.Error("entries under a skipped root must stay searchable")
+	}
+	if got, want := fi.GetRootPaths(), []string{fast, external}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("tracked roots = %v; want %v (skipped root kept, missing root dropped)", got, want)
+	}
+}
+
+func TestSearchFilesInScope(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"api/main.go", "api/handlers/main_test.go", "web/main.go", "apiary/main.go"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	if err := fi.IndexDirectory(dir); err != nil {
+		t.Fatalf("IndexDirectory: %v", err)
+	}
+
+	var got []string
+	for _, file := range fi.SearchFilesIn("main", true, filepath.Join(dir, "api")) {
+		got = append(got, strings.TrimPrefix(file.Path, dir+"/"))
+	}
+	sort.Strings(got)
+	want := []string{"api/handlers/main_test.go", "api/main.go"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("scoped search = %v; want %v", got, want)
+	}
+
+	if all := fi.SearchFilesIn("main", true, ""); len(all) != 4 {
+		t.Errorf("unscoped search found %d files; want 4", len(all))
+	}
+}
+
+func TestSetLoggerRedirectsIndexingLogs(t *testing.T) {
+	var global bytes.Buffer
+	log.SetOutput(&global)
+	defer log.SetOutput(os.Stderr)
+
+	dir := t.TempDir()
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	var indexer bytes.Buffer
+	fi.SetLogger(log.New(&indexer, "", 0))
+	if err := fi.IndexDirectory(dir); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(indexer.String(), "Starting filesystem indexing for: "+dir) {
+		t.Errorf("indexer log = %q; want the indexing progress", indexer.String())
+	}
+	if global.Len() != 0 {
+		t.Errorf("standard logger got %q; want nothing", global.String())
+	}
+}
+
+func TestIncompleteRefreshKeepsLastIndexed(t *testing.T) {
+	freezeTime(t, time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := cloneDefaultConfig().Filesystem
+	config.MaxIndexedFiles = 2
+	fi := NewFilesystemIndexer(config)
+	fi.IndexDirectory(dir) // Stops at the limit
+	if err := fi.ReindexExistingPaths(false); err != nil {
+		t.Fatalf("ReindexExistingPaths: %v", err)
+	}
+	if !fi.LastIndexed().IsZero() || !fi.RefreshDue() {
+		t.Errorf("a refresh stopped by max_indexed_files set LastIndexed() = %v; want it left unset", fi.LastIndexed())
+	}
+}
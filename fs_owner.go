@@ -0,0 +1,136 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// updateOwner records the owner UID and permission bits of info in record
+func updateOwner(record *PathRecord, info os.FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	record.UID = stat.Uid
+	record.Mode = uint32(info.Mode().Perm())
+	record.Flags |= FlagHasOwner
+}
+
+// permissionQuery holds the owner:… and exe:… terms of a search query
+type permissionQuery struct {
+	owner      int   // UID files must belong to, -1 for any
+	executable *bool // Whether files must be executable, nil for either
+}
+
+// active reports whether the query filters on owner or permissions
+func (pq permissionQuery) active() bool {
+	return pq.owner >= 0 || pq.executable != nil
+}
+
+// matches reports whether record passes the query. Records indexed before
+// owners were captured never pass an active query.
+func (pq permissionQuery) matches(record PathRecord) bool {
+	if !pq.active() {
+		return true
+	}
+	if record.Flags&FlagHasOwner == 0 {
+		return false
+	}
+	if pq.owner >= 0 && int(record.UID) != pq.owner {
+		return false
+	}
+	if pq.executable != nil && isExecutableRecord(record) != *pq.executable {
+		return false
+	}
+	return true
+}
+
+// isExecutableRecord reports whether record is a file with an execute bit
+// set for its owner, group or others
+func isExecutableRecord(record PathRecord) bool {
+	return record.Flags&FlagIsDirectory == 0 && record.Mode&0111 != 0
+}
+
+// splitPermissionQuery separates "owner:" and "exe:" terms from the rest of
+// a search query. owner: takes "me", a user name or a UID; exe: takes true or
+// false. Terms whose value cannot be resolved are searched as plain text.
+func splitPermissionQuery(query string) (pq permissionQuery, rest string) {
+	pq.owner = -1
+	var words []string
+	found := false
+	for _, word := range strings.Fields(query) {
+		key, value, ok := strings.Cut(word, ":")
+		switch {
+		case ok && strings.EqualFold(key, "owner"):
+			if uid, resolved := resolveOwner(value); resolved {
+				pq.owner = uid
+				found = true
+				continue
+			}
+		case ok && strings.EqualFold(key, "exe"):
+			if exe, err := strconv.ParseBool(value); err == nil {
+				pq.executable = &exe
+				found = true
+				continue
+			}
+		}
+		words = append(words, word)
+	}
+	if !found {
+		return pq, query
+	}
+	return pq, strings.Join(words, " ")
+}
+
+// resolveOwner turns "me", a user name or a numeric UID into a UID
+func resolveOwner(value string) (int, bool) {
+	if strings.EqualFold(value, "me") {
+		return os.Getuid(), true
+	}
+	if uid, err := strconv.Atoi(value); err == nil && uid >= 0 {
+		return uid, true
+	}
+	if u, err := user.Lookup(value); err == nil {
+		if uid, err := strconv.Atoi(u.Uid); err == nil {
+			return uid, true
+		}
+	}
+	return 0, false
+}
+
+// ownerName describes a UID as "name (uid)", or just the UID when the user
+// cannot be looked up
+func ownerName(uid int) string {
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		return strconv.Itoa(uid)
+	}
+	return u.Username + " (" + strconv.Itoa(uid) + ")"
+}
+
+// permissionSummary renders permission bits like ls, e.g. "rwxr-xr-x",
+// noting when a file is executable
+func permissionSummary(mode os.FileMode, isDirectory bool) string {
+	summary := mode.Perm().String()[1:]
+	if !isDirectory && mode&0111 != 0 {
+		summary += " (executable)"
+	}
+	return summary
+}
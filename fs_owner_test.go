@@ -0,0 +1,149 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/willf/bloom"
+)
+
+func TestSplitPermissionQuery(t *testing.T) {
+	pq, rest := splitPermissionQuery("deploy owner:me exe:true")
+	if pq.owner != os.Getuid() || pq.executable == nil || !*pq.executable || rest != "deploy" {
+		t.Errorf("got owner %d, exe %v, rest %q; want my UID, true, \"deploy\"", pq.owner, pq.executable, rest)
+	}
+
+	pq, rest = splitPermissionQuery("owner:" + strconv.Itoa(os.Getuid()+1))
+	if pq.owner != os.Getuid()+1 || rest != "" {
+		t.Errorf("numeric owner = %d, rest %q", pq.owner, rest)
+	}
+
+	// Unresolvable values stay part of the text query
+	pq, rest = splitPermissionQuery("exe:maybe owner:zz-no-such-user report")
+	if pq.active() || rest != "exe:maybe owner:zz-no-such-user report" {
+		t.Errorf("got active %t, rest %q; want plain text", pq.active(), rest)
+	}
+}
+
+func TestSearchFilesByOwnerAndPermissions(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "deploy.sh")
+	notes := filepath.Join(dir, "deploy.txt")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(notes, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fi := NewFilesystemIndexer(cloneDefaultConfig().Filesystem)
+	fi.AddPath(script, time.Now(), false)
+	fi.AddPath(notes, time.Now(), false)
+
+	paths := func(query string) []string {
+		var result []string
+		for _, f := range fi.SearchFiles(query, true) {
+			result = append(result, filepath.Base(f.Path))
+		}
+		return result
+	}
+	if got := paths("deploy exe:true"); len(got) != 1 || got[0] != "deploy.sh" {
+		t.Errorf("exe:true = %v; want [deploy.sh]", got)
+	}
+	if got := paths("deploy exe:false"); len(got) != 1 || got[0] != "deploy.txt" {
+		t.Errorf("exe:false = %v; want [deploy.txt]", got)
+	}
+	if got := paths("deploy owner:me"); len(got) != 2 {
+		t.Errorf("owner:me = %v; want both files", got)
+	}
+	if got := paths("deploy owner:" + strconv.Itoa(os.Getuid()+1)); len(got) != 0 {
+		t.Errorf("another owner = %v; want none", got)
+	}
+
+	metadata, err := fi.getFileMetadata(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Owner != os.Getuid() || metadata.Mode.Perm() != 0755 {
+		t.Errorf("metadata owner %d mode %v; want %d and 0755", metadata.Owner, metadata.Mode, os.Getuid())
+	}
+	if got := permissionSummary(metadata.Mode, false); got != "rwxr-xr-x (executable)" {
+		t.Errorf("permissionSummary = %q", got)
+	}
+}
+
+// TestIndexLoadV5WithoutOwners reads a v5 index, whose records predate owners,
+// and checks that its files load with an unknown owner
+func TestIndexLoadV5WithoutOwners(t *testing.T) {
+	config := cloneDefaultConfig().Filesystem
+	fi, accesses := newTestIndexer(t, config)
+
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	file, err := os.Create(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(v any) {
+		if err := binary.Write(file, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reserved := [12]byte{}
+	binary.LittleEndian.PutUint32(reserved[0:4], uint32(fi.countMinSketch.Width()))
+	binary.LittleEndian.PutUint32(reserved[4:8], uint32(fi.countMinSketch.Depth()))
+	write([8]byte{'R', 'E', 'C', 'A', 'L', 'L', 'E', 'R'})
+	write(uint32(5))
+	write(uint32(len(fi.pathRecords)))
+	write(uint32(0)) // root path count
+	write(reserved)
+	if _, err := bloom.New(config.BloomFilterSize, config.BloomFilterHashes).WriteTo(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fi.countMinSketch.WriteTo(file); err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range fi.pathRecords {
+		write(v4PathRecord{
+			Path:        record.Path,
+			Timestamp:   record.Timestamp,
+			AccessCount: record.AccessCount,
+			Flags:       record.Flags &^ FlagHasOwner,
+		})
+	}
+	if err := fi.writeTags(file); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	assertFrequencies(t, loaded, accesses)
+	for path := range accesses {
+		if metadata, err := loaded.getFileMetadata(path); err != nil || metadata.Owner != -1 {
+			t.Errorf("%s: owner %d, %v; want unknown (-1)", filepath.Base(path), metadata.Owner, err)
+		}
+	}
+	if got := loaded.SearchFiles("file owner:me", true); len(got) != 0 {
+		t.Errorf("owner:me matched %d files without a known owner", len(got))
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// setupCandidateDirectories returns the directories offered during fs setup:
+// ~/Documents, ~/Projects and the current directory, skipping any that do not exist.
+func setupCandidateDirectories() []string {
+	var candidates []string
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(homeDir, "Documents"), filepath.Join(homeDir, "Projects"))
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		candidates = append(candidates, cwd)
+	}
+	return existingDirectories(candidates)
+}
+
+// indexPresets are the directory sets for 'recaller fs index --preset'.
+// Paths are relative to the home directory; "." is the current directory.
+var indexPresets = map[string][]string{
+	"dev":  {"Projects", "src", "go/src", "code", "."},
+	"docs": {"Documents", "Desktop", "Notes", "Dropbox"},
+}
+
+// indexPresetNames lists the presets in a stable order for help and errors
+func indexPresetNames() []string {
+	names := make([]string, 0, len(indexPresets))
+	for name := range indexPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// presetDirectories returns the directories of the named index preset that
+// exist
+func presetDirectories(name string) ([]string, error) {
+	paths, ok := indexPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(indexPresetNames(), ", "))
+	}
+
+	homeDir, homeErr := os.UserHomeDir()
+	var candidates []string
+	for _, path := range paths {
+		if path == "." {
+			if cwd, err := os.Getwd(); err == nil {
+				candidates = append(candidates, cwd)
+			}
+			continue
+		}
+		if homeErr == nil {
+			candidates = append(candidates, filepath.Join(homeDir, path))
+		}
+	}
+	return existingDirectories(candidates), nil
+}
+
+// existingDirectories filters candidates down to the directories that exist,
+// dropping duplicates
+func existingDirectories(candidates []string) []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, dir := range candidates {
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// promptYesNo asks a yes/no question, returning def on an empty answer or EOF.
+func promptYesNo(reader *bufio.Reader, out io.Writer, question string, def bool) bool {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	cliFprintf(out, "%s %s ", question, hint)
+
+	answer, err := reader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return def
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return def
+	}
+}
+
+// enableFilesystemSearch turns on filesystem search with the given directories
+// and saves the result to the config file.
+func enableFilesystemSearch(config *Config, dirs []string) error {
+	config.Filesystem.Enabled = true
+	config.Filesystem.IndexDirectories = append([]string{}, dirs...)
+	return writeConfigFile(config)
+}
+
+// runFilesystemSetup walks the user through enabling filesystem search,
+// saves the configuration and builds the initial index.
+func runFilesystemSetup(config *Config, in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+
+	if config.Filesystem.Enabled {
+		cliFprintf(out, "✅ Filesystem search is already enabled.\n")
+		if !promptYesNo(reader, out, "Choose the indexed directories again?", false) {
+			return nil
+		}
+	} else if !promptYesNo(reader, out, "🔍 Enable filesystem search?", true) {
+		cliFprintf(out, "👋 Filesystem search left disabled. Run 'recaller fs setup' any time to enable it.\n")
+		return nil
+	}
+
+	var dirs []string
+	for _, dir := range setupCandidateDirectories() {
+		if promptYesNo(reader, out, fmt.Sprintf("📂 Index %s?", dir), true) {
+			dirs = append(dirs, dir)
+		}
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("no directories selected")
+	}
+
+	if err := enableFilesystemSearch(config, dirs); err != nil {
+		return err
+	}
+	if configPath, err := getConfigPath(); err == nil {
+		cliFprintf(out, "✅ Saved configuration to %s\n\n", configPath)
+	}
+
+	fsIndexer := NewFilesystemIndexer(config.Filesystem)
+	if err := fsIndexer.LoadOrCreateIndex(!config.Quiet); err != nil {
+		log.Printf("Failed to load filesystem index: %v", err)
+	}
+
+	cliFprintf(out, "🔍 Starting filesystem indexing for %d directories:\n", len(dirs))
+	for i, dir := range dirs {
+		cliFprintf(out, "  %d. %s\n", i+1, dir)
+	}
+	fmt.Fprintln(out)
+	if err := fsIndexer.IndexDirectoriesWithProgress(dirs, true); err != nil {
+		if err.Error() == "max indexed files limit reached" {
+			cliFprintf(out, "⚠️  Reached maximum file limit (%d files)\n", config.Filesystem.MaxIndexedFiles)
+		} else {
+			log.Printf("Warning: Indexing completed with errors: %v", err)
+		}
+	}
+
+	cliFprintf(out, "\n💾 Saving index to disk...")
+	if err := fsIndexer.PersistIndex(!config.Quiet); err != nil {
+		return fmt.Errorf("failed to persist index: %w", err)
+	}
+	cliFprintf(out, " ✅\n")
+
+	cliFprintf(out, "\n%s\n", fsIndexer.GetIndexStats())
+	cliFprintf(out, "\n💡 Run 'recaller fs' to launch the search UI.\n")
+	return nil
+}
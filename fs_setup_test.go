@@ -0,0 +1,117 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptYesNo(t *testing.T) {
+	testCases := []struct {
+		input    string
+		def      bool
+		expected bool
+	}{
+		{"y\n", false, true},
+		{"YES\n", false, true},
+		{"n\n", true, false},
+		{"\n", true, true},
+		{"\n", false, false},
+		{"maybe\n", true, true},
+		{"", true, true},
+	}
+
+	for _, tc := range testCases {
+		reader := bufio.NewReader(strings.NewReader(tc.input))
+		if got := promptYesNo(reader, io.Discard, "Enable?", tc.def); got != tc.expected {
+			t.Errorf("promptYesNo(%q, def=%t) = %t; want %t", tc.input, tc.def, got, tc.expected)
+		}
+	}
+}
+
+func TestEnableFilesystemSearchWritesConfig(t *testing.T) {
+	setTestHome(t)
+
+	if configFileExists() {
+		t.Fatal("config file should not exist in a fresh home directory")
+	}
+
+	config := cloneDefaultConfig()
+	dirs := []string{"/tmp/docs", "/tmp/code"}
+	if err := enableFilesystemSearch(config, dirs); err != nil {
+		t.Fatalf("enableFilesystemSearch: %v", err)
+	}
+
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if !loaded.Filesystem.Enabled {
+		t.Error("filesystem search not enabled in saved config")
+	}
+	if fmt.Sprint(loaded.Filesystem.IndexDirectories) != fmt.Sprint(dirs) {
+		t.Errorf("IndexDirectories = %v; want %v", loaded.Filesystem.IndexDirectories, dirs)
+	}
+}
+
+func TestFilesystemSetupDeclined(t *testing.T) {
+	setTestHome(t)
+
+	var out bytes.Buffer
+	if err := runFilesystemSetup(cloneDefaultConfig(), strings.NewReader("n\n"), &out); err != nil {
+		t.Fatalf("runFilesystemSetup: %v", err)
+	}
+	if configFileExists() {
+		t.Error("declining setup should not write a config file")
+	}
+	if !strings.Contains(out.String(), "left disabled") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestPresetDirectories(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, dir := range []string{"Projects", "go/src"} {
+		if err := os.MkdirAll(filepath.Join(home, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(filepath.Join(home, "Projects"))
+
+	dirs, err := presetDirectories("dev")
+	if err != nil {
+		t.Fatalf("presetDirectories: %v", err)
+	}
+	// ~/src and ~/code do not exist, and the current directory is ~/Projects
+	want := []string{filepath.Join(home, "Projects"), filepath.Join(home, "go/src")}
+	if fmt.Sprint(dirs) != fmt.Sprint(want) {
+		t.Errorf("dev preset = %v; want %v", dirs, want)
+	}
+
+	if dirs, err := presetDirectories("docs"); err != nil || len(dirs) != 0 {
+		t.Errorf("docs preset = %v, %v; want no directories", dirs, err)
+	}
+	if _, err := presetDirectories("music"); err == nil || !strings.Contains(err.Error(), "dev, docs") {
+		t.Errorf("unknown preset error = %v", err)
+	}
+}
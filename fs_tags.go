@@ -0,0 +1,219 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// macTagsXattr holds Finder tags as a binary plist array of strings
+	macTagsXattr = "com.apple.metadata:_kMDItemUserTags"
+	// DefaultTagsXattr is the freedesktop.org convention: comma-separated tags
+	DefaultTagsXattr = "user.xdg.tags"
+	// maxTagsXattrSize bounds how much of a tags attribute is read
+	maxTagsXattrSize = 4096
+)
+
+// readFileTags returns the user tags of path. On macOS these are Finder tags;
+// elsewhere the configured xattr (user.xdg.tags by default) is read as a
+// comma-separated list. Files without tags return nil.
+func readFileTags(path string, xattrName string) []string {
+	name := xattrName
+	if runtime.GOOS == "darwin" && name == "" {
+		name = macTagsXattr
+	}
+	if name == "" {
+		name = DefaultTagsXattr
+	}
+
+	buf := make([]byte, maxTagsXattrSize)
+	n, err := unix.Lgetxattr(path, name, buf)
+	if err != nil || n <= 0 {
+		return nil
+	}
+
+	if name == macTagsXattr {
+		tags, err := parseFinderTags(buf[:n])
+		if err != nil {
+			return nil
+		}
+		return tags
+	}
+	return splitTags(string(buf[:n]))
+}
+
+// splitTags parses a comma-separated tag list, dropping blanks
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseFinderTags decodes the binary plist (bplist00) array of strings that
+// Finder stores in _kMDItemUserTags. Entries look like "Work\n6", where the
+// suffix is the label color; only the name is kept.
+func parseFinderTags(data []byte) ([]string, error) {
+	errInvalid := errors.New("invalid binary plist")
+	if len(data) < 40 || string(data[:8]) != "bplist00" {
+		return nil, errInvalid
+	}
+
+	trailer := data[len(data)-32:]
+	offsetSize := int(trailer[6])
+	refSize := int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	topObject := binary.BigEndian.Uint64(trailer[16:24])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:32])
+	if offsetSize == 0 || refSize == 0 || topObject >= numObjects ||
+		tableOffset+numObjects*uint64(offsetSize) > uint64(len(data)) {
+		return nil, errInvalid
+	}
+
+	readUint := func(b []byte) uint64 {
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v
+	}
+	objectOffset := func(ref uint64) (int, bool) {
+		if ref >= numObjects {
+			return 0, false
+		}
+		start := tableOffset + ref*uint64(offsetSize)
+		off := readUint(data[start : start+uint64(offsetSize)])
+		return int(off), off < tableOffset
+	}
+	// length reads an object's element count, which may spill into a following int object
+	length := func(off int) (int, int, bool) {
+		count := int(data[off] & 0x0F)
+		if count != 0x0F {
+			return count, off + 1, true
+		}
+		if off+2 > len(data) || data[off+1]>>4 != 0x1 {
+			return 0, 0, false
+		}
+		width := 1 << (data[off+1] & 0x0F)
+		if off+2+width > len(data) {
+			return 0, 0, false
+		}
+		// No count can exceed the data, and larger ones would overflow int
+		spilled := readUint(data[off+2 : off+2+width])
+		if spilled > uint64(len(data)) {
+			return 0, 0, false
+		}
+		return int(spilled), off + 2 + width, true
+	}
+
+	off, ok := objectOffset(topObject)
+	if !ok || data[off]>>4 != 0xA {
+		return nil, errInvalid
+	}
+	count, refs, ok := length(off)
+	if !ok || count < 0 || refs+count*refSize > len(data) {
+		return nil, errInvalid
+	}
+
+	var tags []string
+	for i := 0; i < count; i++ {
+		ref := readUint(data[refs+i*refSize : refs+(i+1)*refSize])
+		strOff, ok := objectOffset(ref)
+		if !ok {
+			return nil, errInvalid
+		}
+		n, start, ok := length(strOff)
+		if !ok {
+			return nil, errInvalid
+		}
+
+		var tag string
+		switch data[strOff] >> 4 {
+		case 0x5: // ASCII
+			if n < 0 || start+n > len(data) {
+				return nil, errInvalid
+			}
+			tag = string(data[start : start+n])
+		case 0x6: // UTF-16BE
+			if n < 0 || start+2*n > len(data) {
+				return nil, errInvalid
+			}
+			units := make([]uint16, n)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(data[start+2*j:])
+			}
+			tag = string(utf16.Decode(units))
+		default:
+			continue
+		}
+
+		if name, _, _ := strings.Cut(tag, "\n"); name != "" {
+			tags = append(tags, name)
+		}
+	}
+	return tags, nil
+}
+
+// splitTagQuery separates "#tag" terms from the rest of a search query
+func splitTagQuery(query string) (tags []string, rest string) {
+	var words []string
+	for _, word := range strings.Fields(query) {
+		if len(word) > 1 && word[0] == '#' {
+			tags = append(tags, strings.ToLower(word[1:]))
+		} else {
+			words = append(words, word)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, query
+	}
+	return tags, strings.Join(words, " ")
+}
+
+// matchesTags reports whether every wanted tag prefixes one of the file's tags
+func matchesTags(fileTags []string, wanted []string) bool {
+	for _, want := range wanted {
+		found := false
+		for _, tag := range fileTags {
+			if strings.HasPrefix(strings.ToLower(tag), want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// setTags records path's tags, as read by readFileTags, in the index
+func (fi *FilesystemIndexer) setTags(path string, tags []string) {
+	if len(tags) > 0 {
+		fi.tags[path] = tags
+	} else {
+		delete(fi.tags, path)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// finderTagsPlist builds the bplist00 Finder writes for ["Work\n6", "Café"]
+func finderTagsPlist() []byte {
+	cafe := []byte{0x64}
+	for _, r := range "Café" {
+		cafe = binary.BigEndian.AppendUint16(cafe, uint16(r))
+	}
+	return buildPlist(
+		[]byte{0xA2, 0x01, 0x02}, // array of two refs
+		append([]byte{0x56}, "Work\n6"...),
+		cafe,
+	)
+}
+
+// buildPlist lays out objects as a bplist00 with one-byte offsets and refs,
+// the first object being the top one
+func buildPlist(objects ...[]byte) []byte {
+	data := []byte("bplist00")
+	var offsets []byte
+	for _, object := range objects {
+		offsets = append(offsets, byte(len(data)))
+		data = append(data, object...)
+	}
+
+	tableOffset := len(data)
+	data = append(data, offsets...)
+
+	trailer := make([]byte, 32)
+	trailer[6], trailer[7] = 1, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(objects)))
+	binary.BigEndian.PutUint64(trailer[16:], 0)
+	binary.BigEndian.PutUint64(trailer[24:], uint64(tableOffset))
+	return append(data, trailer...)
+}
+
+func TestParseFinderTags(t *testing.T) {
+	tags, err := parseFinderTags(finderTagsPlist())
+	if err != nil {
+		t.Fatalf("parseFinderTags: %v", err)
+	}
+	if fmt.Sprint(tags) != "[Work Café]" {
+		t.Errorf("parseFinderTags = %q; want [Work Café]", tags)
+	}
+
+	corrupt := finderTagsPlist()
+	corrupt[len(corrupt)-1] = 0xFF // offset table beyond the data
+	if _, err := parseFinderTags(corrupt); err == nil {
+		t.Error("expected an error for a corrupt plist")
+	}
+	if _, err := parseFinderTags([]byte("work,home")); err == nil {
+		t.Error("expected an error for non-plist data")
+	}
+
+	// Lengths spilling into an int object must not reach past the data
+	for _, length := range []uint64{1 << 63, 1<<64 - 1, 1 << 20} {
+		huge := binary.BigEndian.AppendUint64([]byte{0x5F, 0x13}, length)
+		if _, err := parseFinderTags(buildPlist([]byte{0xA1, 0x01}, append(huge, "Work"...))); err == nil {
+			t.Errorf("expected an error for a string of length %d", length)
+		}
+		if _, err := parseFinderTags(buildPlist(append([]byte{0xAF, 0x13}, huge[2:]...))); err == nil {
+			t.Errorf("expected an error for an array of length %d", length)
+		}
+	}
+}
+
+func TestSplitTagQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		tags  []string
+		rest  string
+	}{
+		{"report", nil, "report"},
+		{"#Work report", []string{"work"}, "report"},
+		{"#work #2025", []string{"work", "2025"}, ""},
+		{"# c#", nil, "# c#"},
+	}
+	for _, tt := range tests {
+		tags, rest := splitTagQuery(tt.query)
+		if fmt.Sprint(tags) != fmt.Sprint(tt.tags) || rest != tt.rest {
+			t.Errorf("splitTagQuery(%q) = %q, %q; want %q, %q", tt.query, tags, rest, tt.tags, tt.rest)
+		}
+	}
+}
+
+func TestTagsSearchAndRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	report := filepath.Join(dir, "report.txt")
+	notes := filepath.Join(dir, "notes.txt")
+	for _, path := range []string{report, notes} {
+		if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := cloneDefaultConfig().Filesystem
+	config.IndexTags = true
+	config.TagsXattr = DefaultTagsXattr
+	if err := unix.Setxattr(report, DefaultTagsXattr, []byte("work, q3"), 0); err != nil {
+		t.Skipf("user xattrs not supported here: %v", err)
+	}
+
+	fi := NewFilesystemIndexer(config)
+	fi.AddPath(report, time.Now(), true)
+	fi.AddPath(notes, time.Now(), true)
+
+	assertSearch := func(fi *FilesystemIndexer, query string, want []string) {
+		t.Helper()
+		var got []string
+		for _, r := range fi.SearchFiles(query, true) {
+			got = append(got, filepath.Base(r.Path))
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("SearchFiles(%q) = %v; want %v", query, got, want)
+		}
+	}
+	assertSearch(fi, "#work", []string{"report.txt"})
+	assertSearch(fi, "#q3 rep", []string{"report.txt"})
+	assertSearch(fi, "#q3 notes", nil)
+
+	indexPath := filepath.Join(t.TempDir(), "index.bin")
+	if err := fi.SaveToFile(indexPath); err != nil {
+		t.Fatalf("SaveToFile: %v", err)
+	}
+	loaded := NewFilesystemIndexer(config)
+	if err := loaded.LoadFromFile(indexPath); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	assertSearch(loaded, "#work", []string{"report.txt"})
+	if metadata, _ := loaded.getFileMetadata(report); fmt.Sprint(metadata.Tags) != "[work q3]" {
+		t.Errorf("Tags after reload = %v; want [work q3]", metadata.Tags)
+	}
+}
@@ -45,7 +45,7 @@ Built with Go %s
 * Zshell (Zsh)
 
 # Please be aware
-* Copy to clipboard feature on Linux or Unix requires 'xclip' or 'xsel' command to be installed
+* Copy to clipboard feature on Linux or Unix requires 'xclip', 'xsel' or 'wl-copy' to be installed (WSL uses clip.exe). Run 'recaller doctor' to check
 
 # License
 Licensed under the Apache License, Version 2.0
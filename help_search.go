@@ -0,0 +1,130 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// helpMatchStyle highlights search matches in the help pane
+const helpMatchStyle = "fg:black,bg:yellow"
+
+// helpSearch finds a term in the help pane like less: "/" types the term,
+// then "n" and "N" jump to the next and previous row containing it. Matches
+// are highlighted in place.
+type helpSearch struct {
+	editing bool // Keys go to buffer until <enter> or <esc>
+	buffer  string
+	term    string
+	plain   []string // Help rows as painted, before highlighting
+	shown   []string // plain with the matches highlighted, as last put in the pane
+	matches []int    // Rows containing term, in order
+}
+
+// find searches the pane for term and selects the first match at or after
+// the selected row, wrapping around to the top. An empty term removes the
+// highlighting.
+func (hs *helpSearch) find(l *widgets.List, term string) {
+	hs.term = term
+	hs.highlight(l)
+	if len(hs.matches) == 0 {
+		return
+	}
+	i, _ := slices.BinarySearch(hs.matches, l.SelectedRow)
+	l.SelectedRow = hs.matches[i%len(hs.matches)]
+}
+
+// step selects the next match after the selected row (forward) or the one
+// before it, wrapping around. It reports whether there was a match to go to.
+func (hs *helpSearch) step(l *widgets.List, forward bool) bool {
+	hs.highlight(l)
+	if len(hs.matches) == 0 {
+		return false
+	}
+	if forward {
+		i, found := slices.BinarySearch(hs.matches, l.SelectedRow)
+		if found {
+			i++
+		}
+		l.SelectedRow = hs.matches[i%len(hs.matches)]
+	} else {
+		i, _ := slices.BinarySearch(hs.matches, l.SelectedRow)
+		l.SelectedRow = hs.matches[(i-1+len(hs.matches))%len(hs.matches)]
+	}
+	return true
+}
+
+// highlight marks term in the pane's rows and records which rows contain it.
+// Rows the pane no longer shows, such as help painted for another command
+// since the last search, are searched afresh.
+func (hs *helpSearch) highlight(l *widgets.List) {
+	if !slices.Equal(l.Rows, hs.shown) {
+		hs.plain = l.Rows
+	}
+	hs.matches = hs.matches[:0]
+	rows := make([]string, len(hs.plain))
+	for i, row := range hs.plain {
+		highlighted, found := highlightTerm(row, hs.term)
+		if found {
+			hs.matches = append(hs.matches, i)
+		}
+		rows[i] = highlighted
+	}
+	hs.shown = rows
+	l.Rows = rows
+}
+
+// status describes the search for the help pane title, e.g. "🔎 commit 2/7"
+func (hs *helpSearch) status(selectedRow int) string {
+	if len(hs.matches) == 0 {
+		return fmt.Sprintf("🔎 %s: not found", hs.term)
+	}
+	i, _ := slices.BinarySearch(hs.matches, selectedRow)
+	return fmt.Sprintf("🔎 %s %d/%d", hs.term, i+1, len(hs.matches))
+}
+
+// highlightTerm wraps every occurrence of term in row, ignoring case, in the
+// match style and reports whether row contains term. Rows holding brackets
+// are left as they are, since termui would read them as style markup, and so
+// are rows whose lowercase form changes length.
+func highlightTerm(row, term string) (string, bool) {
+	if term == "" {
+		return row, false
+	}
+	lowerRow, lowerTerm := strings.ToLower(row), strings.ToLower(term)
+	if !strings.Contains(lowerRow, lowerTerm) {
+		return row, false
+	}
+	if strings.ContainsAny(row, "[]") || len(lowerRow) != len(row) {
+		return row, true
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(lowerRow, lowerTerm)
+		if i < 0 {
+			break
+		}
+		end := i + len(lowerTerm)
+		fmt.Fprintf(&b, "%s[%s](%s)", row[:i], row[i:end], helpMatchStyle)
+		row, lowerRow = row[end:], lowerRow[end:]
+	}
+	b.WriteString(row)
+	return b.String(), true
+}
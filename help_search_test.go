@@ -0,0 +1,100 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+func TestHighlightTerm(t *testing.T) {
+	tests := []struct {
+		row, term, want string
+		found           bool
+	}{
+		{"git commit -m", "commit", "git [commit](fg:black,bg:yellow) -m", true},
+		{"Commit or COMMIT", "commit", "[Commit](fg:black,bg:yellow) or [COMMIT](fg:black,bg:yellow)", true},
+		{"git push", "commit", "git push", false},
+		{"git commit [options]", "commit", "git commit [options]", true},
+		{"anything", "", "anything", false},
+	}
+	for _, tc := range tests {
+		got, found := highlightTerm(tc.row, tc.term)
+		if got != tc.want || found != tc.found {
+			t.Errorf("highlightTerm(%q, %q) = %q, %t; want %q, %t", tc.row, tc.term, got, found, tc.want, tc.found)
+		}
+	}
+
+	// The highlighted row renders as the original text
+	got, _ := highlightTerm("use --amend to amend", "amend")
+	var text []rune
+	for _, cell := range ui.ParseStyles(got, ui.NewStyle(ui.ColorWhite)) {
+		text = append(text, cell.Rune)
+	}
+	if string(text) != "use --amend to amend" {
+		t.Errorf("highlighted row renders as %q", string(text))
+	}
+}
+
+func TestHelpSearchJumpsBetweenMatches(t *testing.T) {
+	help := widgets.NewList()
+	help.Rows = []string{"GIT-COMMIT(1)", "NAME", "git-commit - Record changes", "", "OPTIONS", "--amend", "Replace the tip by creating a new commit."}
+	help.SelectedRow = 2
+
+	var hs helpSearch
+	hs.find(help, "commit")
+	if help.SelectedRow != 2 || fmt.Sprint(hs.matches) != "[0 2 6]" {
+		t.Fatalf("find selected row %d with matches %v; want row 2 of [0 2 6]", help.SelectedRow, hs.matches)
+	}
+	if got := hs.status(help.SelectedRow); got != "🔎 commit 2/3" {
+		t.Errorf("status = %q", got)
+	}
+
+	var visited []int
+	for range 3 {
+		hs.step(help, true)
+		visited = append(visited, help.SelectedRow)
+	}
+	if fmt.Sprint(visited) != "[6 0 2]" {
+		t.Errorf("n visited rows %v; want [6 0 2] wrapping around", visited)
+	}
+	hs.step(help, false)
+	hs.step(help, false)
+	if help.SelectedRow != 6 {
+		t.Errorf("N twice from row 2 selected row %d; want 6", help.SelectedRow)
+	}
+
+	// Help painted for another command is searched afresh
+	help.Rows = []string{"GIT-PUSH(1)", "Update remote refs", "git push origin main"}
+	help.SelectedRow = 0
+	if hs.step(help, true) {
+		t.Errorf("n found a match in help without the term: rows %q", help.Rows)
+	}
+	hs.find(help, "push")
+	if help.SelectedRow != 0 || len(hs.matches) != 2 {
+		t.Errorf("find push selected row %d with matches %v", help.SelectedRow, hs.matches)
+	}
+
+	hs.find(help, "")
+	if help.Rows[2] != "git push origin main" {
+		t.Errorf("an empty term left the highlighting: %q", help.Rows[2])
+	}
+	if hs.find(help, "tag"); hs.status(help.SelectedRow) != "🔎 tag: not found" {
+		t.Errorf("status = %q", hs.status(help.SelectedRow))
+	}
+}
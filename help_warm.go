@@ -0,0 +1,152 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// topCommandsByFrequency returns the n most used commands in tree, most used
+// first. Ties go to the most recently used command, then alphabetically.
+func topCommandsByFrequency(tree *AVLTree, n int) []string {
+	var nodes []*AVLNode
+	collectNodes(tree.Root, func(*AVLNode) bool { return true }, &nodes)
+
+	ranked := make([]RankedCommand, 0, len(nodes))
+	for _, node := range nodes {
+		ranked = append(ranked, RankedCommand{
+			Command:  node.Key,
+			Score:    float64(node.Value.Frequency),
+			Metadata: node.Value,
+		})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return rankedCommandLess(ranked[i], ranked[j])
+	})
+
+	if n > 0 && n < len(ranked) {
+		ranked = ranked[:n]
+	}
+	commands := make([]string, len(ranked))
+	for i, rc := range ranked {
+		commands[i] = rc.Command
+	}
+	return commands
+}
+
+// warmHelpCache looks up help for every command not yet in hc, running at
+// most workers lookups at once. done is called after each command. It
+// returns how many pages were fetched, how many were already cached and how
+// many lookups failed.
+func warmHelpCache(ctx context.Context, hc *HelpCache, commands []string, workers int, done func()) (fetched, cached, failed int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cmd := range jobs {
+				hit := GetHelpPage(hc, cmd) != ""
+				if !hit {
+					if _, err := GetOrfillCacheContext(ctx, hc, cmd); err != nil {
+						done() // Interrupted
+						continue
+					}
+				}
+				// Failures are cached in memory without a fetch time
+				found := !getCachedHelpPage(hc, cmd).Fetched.IsZero()
+				mu.Lock()
+				switch {
+				case hit && found:
+					cached++
+				case found:
+					fetched++
+				default:
+					failed++
+				}
+				mu.Unlock()
+				done()
+			}
+		}()
+	}
+
+feed:
+	for _, cmd := range commands {
+		select {
+		case jobs <- cmd:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return fetched, cached, failed
+}
+
+// runHelpWarm fetches help for the top most used commands into the disk
+// cache, so the help pane is instant from the next launch. Ctrl+C stops
+// early and keeps what was fetched.
+func runHelpWarm(config *Config, top int) error {
+	if !config.Help.DiskCache {
+		return fmt.Errorf("help warm fills the disk cache; set help.disk_cache: true in %s first", displayConfigPath())
+	}
+
+	tree := NewAVLTree()
+	if err := readHistoryAndPopulateTree(tree, config.History); err != nil {
+		return fmt.Errorf("failed to read history: %v", err)
+	}
+	commands := topCommandsByFrequency(tree, top)
+
+	hc := sharedHelpCache(config.Help)
+	configureHelpManager(config.Help)
+
+	var bar *progressbar.ProgressBar
+	if !config.Quiet {
+		bar = newIndexProgressBar(len(commands), "📖 Warming help cache...",
+			progressbar.OptionSetItsString("commands"),
+		)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fetched, cached, failed := warmHelpCache(ctx, hc, commands, config.Help.MaxConcurrentFetches, func() {
+		if bar != nil {
+			bar.Add(1)
+		}
+	})
+
+	if err := hc.SaveFile(GetHelpCachePath(), helpDiskCacheMaxAge(config.Help)); err != nil {
+		return err
+	}
+	if !config.Quiet {
+		cliPrintf("\n✅ Help cached for %d of %d commands (%d fetched, %d already cached)\n",
+			fetched+cached, len(commands), fetched, cached)
+		if failed > 0 {
+			cliPrintf("⚠️  No help found for %d commands; they are looked up again next time\n", failed)
+		}
+	}
+	return nil
+}
@@ -16,10 +16,16 @@ package main
 
 import (
 	"bufio"
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -31,32 +37,104 @@ type HistoryEntry struct {
 	Timestamp *time.Time
 }
 
-// readZshHistoryWithEpoch reads ~/.zsh_history file.
-func readZshHistoryWithEpoch() ([]HistoryEntry, error) {
+// historyFileNames maps a shell to its history file name in the home directory
+var historyFileNames = map[string]string{
+	"zsh":  ".zsh_history",
+	"bash": ".bash_history",
+}
+
+// historyFilePath returns the history file of shell: $HISTFILE when it is
+// set, as for users who keep their history elsewhere, otherwise the default
+// location in the home directory (e.g. ~/.zsh_history)
+func historyFilePath(shell string) (string, error) {
+	name, ok := historyFileNames[shell]
+	if !ok {
+		return "", fmt.Errorf("unknown shell: %s", shell)
+	}
 	homeDir, err := os.UserHomeDir()
+	if histFile := os.Getenv("HISTFILE"); histFile != "" {
+		if strings.HasPrefix(histFile, "~/") && err == nil {
+			histFile = filepath.Join(homeDir, histFile[2:])
+		}
+		return histFile, nil
+	}
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	return filepath.Join(homeDir, name), nil
+}
+
+// openHistoryFile opens a history file for reading, transparently
+// decompressing it when the name ends in .gz. It also returns the on-disk
+// size, which callers use to estimate the number of lines.
+func openHistoryFile(path string) (io.ReadCloser, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
 	}
-	zshHistoryPath := filepath.Join(homeDir, ".zsh_history")
 
-	file, err := os.Open(zshHistoryPath)
+	var size int64
+	if stat, err := file.Stat(); err == nil {
+		size = stat.Size()
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return file, size, nil
+	}
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to decompress %s: %w", path, err)
+	}
+	return &gzipHistoryFile{Reader: gz, file: file}, size, nil
+}
+
+// gzipHistoryFile closes both the gzip stream and the underlying file
+type gzipHistoryFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipHistoryFile) Close() error {
+	err := g.Reader.Close()
+	if cerr := g.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// historyNotFoundError reports a shell history file that does not exist yet,
+// explaining how to create it. It matches os.ErrNotExist.
+type historyNotFoundError struct {
+	message string
+}
+
+func (e *historyNotFoundError) Error() string { return e.message }
+func (e *historyNotFoundError) Unwrap() error { return os.ErrNotExist }
+
+// readZshHistoryWithEpoch reads a zsh history file (usually ~/.zsh_history,
+// or $HISTFILE).
+func readZshHistoryWithEpoch(zshHistoryPath string) ([]HistoryEntry, error) {
+	file, size, err := openHistoryFile(zshHistoryPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("zsh history file not found. Run some commands in zsh to create %s, then try again", zshHistoryPath)
+			return nil, &historyNotFoundError{fmt.Sprintf("zsh history file not found. Run some commands in zsh to create %s, then try again", zshHistoryPath)}
 		}
 		return nil, err
 	}
 	defer file.Close()
+	return parseZshHistory(file, size)
+}
 
+// parseZshHistory parses zsh history lines from r. size is the length of the
+// input, used to size the result.
+func parseZshHistory(r io.Reader, size int64) ([]HistoryEntry, error) {
 	// Pre-allocate history slice with estimated capacity
-	var history []HistoryEntry
-	if stat, err := file.Stat(); err == nil {
-		// Estimate ~50 bytes per line average
-		estimatedLines := int(stat.Size() / 50)
-		history = make([]HistoryEntry, 0, estimatedLines)
-	}
+	// Estimate ~50 bytes per line average
+	history := make([]HistoryEntry, 0, int(size/50))
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	// Increase buffer size for better performance with large history files
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
@@ -114,54 +192,45 @@ func readZshHistoryWithEpoch() ([]HistoryEntry, error) {
 	return history, nil
 }
 
-// readBashHistoryWithEpoch reads ~/.bash_history file.
+// readBashHistoryWithEpoch reads a bash history file (usually ~/.bash_history,
+// or $HISTFILE).
 // Set export HISTTIMEFORMAT="%s "
 // Run `history -w` to store history to .bash_history file (or) close the shell and re-launch
 // in ~/.bash_profile to read epoch timestamps correctly
-func readBashHistoryWithEpoch() ([]HistoryEntry, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, err
-	}
-	historyPath := filepath.Join(homeDir, ".bash_history")
-
-	file, err := os.Open(historyPath)
+func readBashHistoryWithEpoch(historyPath string) ([]HistoryEntry, error) {
+	file, size, err := openHistoryFile(historyPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("bash history file not found. Run 'history -w' to create %s, then try again", historyPath)
+			return nil, &historyNotFoundError{fmt.Sprintf("bash history file not found. Run 'history -w' to create %s, then try again", historyPath)}
 		}
 		return nil, err
 	}
 	defer file.Close()
+	return parseBashHistory(file, size)
+}
 
+// parseBashHistory parses bash history lines from r. size is the length of
+// the input, used to size the result.
+func parseBashHistory(r io.Reader, size int64) ([]HistoryEntry, error) {
 	// Pre-allocate history slice with estimated capacity
-	var history []HistoryEntry
-	if stat, err := file.Stat(); err == nil {
-		// Estimate ~30 bytes per line average for bash
-		estimatedLines := int(stat.Size() / 30)
-		history = make([]HistoryEntry, 0, estimatedLines)
-	}
+	// Estimate ~30 bytes per line average for bash
+	history := make([]HistoryEntry, 0, int(size/30))
 	var lastTimestamp *time.Time
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	// Increase buffer size for better performance with large history files
 	buf := make([]byte, 0, 64*1024)
 	scanner.Buffer(buf, 1024*1024)
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		// Lines starting with '#' are epoch timestamps if HISTTIMEFORMAT was ever enabled
+		// Lines starting with '#' are timestamps if HISTTIMEFORMAT was ever enabled
 		if strings.HasPrefix(line, "#") {
-			// Remove '#' and parse the remainder as an integer (epoch seconds)
-			epochStr := strings.TrimPrefix(line, "#")
-			epochStr = strings.TrimSpace(epochStr)
-			epoch, err := strconv.ParseInt(epochStr, 10, 64)
-			if err == nil {
-				t := time.Unix(epoch, 0)
-				lastTimestamp = &t
-			} else {
-				lastTimestamp = nil
-			}
+			lastTimestamp = parseBashTimestamp(strings.TrimPrefix(line, "#"))
+		} else if ts, command, ok := splitInlineTimestamp(line); ok {
+			// Imported `history` output carries the timestamp on the command line
+			history = append(history, HistoryEntry{Timestamp: ts, Command: command})
+			lastTimestamp = nil
 		} else {
 			// This line is a command
 			entry := HistoryEntry{
@@ -181,6 +250,79 @@ func readBashHistoryWithEpoch() ([]HistoryEntry, error) {
 	return history, nil
 }
 
+// bashTimeFormats are HISTTIMEFORMAT date layouts recognized in bash history,
+// in dateutil notation, with a pattern matching them
+var bashTimeFormats = []struct {
+	pattern string
+	format  string
+}{
+	{`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}`, "YYYY-MM-DD hh:mm:ss"}, // %F %T
+	{`\d{4}-\d{2}-\d{2} \d{2}:\d{2}`, "YYYY-MM-DD hh:mm"},             // %F %R
+	{`\d{2}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}`, "DD/MM/YY hh:mm:ss"},      // %d/%m/%y %T (bash manual example)
+}
+
+// inlineTimestampLine matches a command line that starts with a formatted
+// timestamp, as printed by `history` with HISTTIMEFORMAT set: an optional
+// entry number, then the date (optionally in brackets), then the command.
+// The date group is tried against bashTimeFormats in order.
+var inlineTimestampLine = func() *regexp.Regexp {
+	var dates []string
+	for _, f := range bashTimeFormats {
+		dates = append(dates, f.pattern)
+	}
+	return regexp.MustCompile(`^\s*(?:\d+\s+)?\[?(` + strings.Join(dates, "|") + `)\]?\s+(\S.*)$`)
+}()
+
+// zshExtendedLine matches zsh's ": <epoch>:<duration>;<command>" format,
+// which shows up in bash histories merged from zsh
+var zshExtendedLine = regexp.MustCompile(`^: (\d+):\d+;(.*)$`)
+
+// parseBashTimestamp parses the body of a '#' line: epoch seconds or a date
+// in one of bashTimeFormats (local time). Anything else is a comment and
+// yields nil.
+func parseBashTimestamp(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(epoch, 0)
+		return &t
+	}
+	return parseBashDate(strings.Trim(value, "[]"))
+}
+
+// parseBashDate parses a formatted HISTTIMEFORMAT date, or returns nil
+func parseBashDate(value string) *time.Time {
+	value = strings.Replace(value, "T", " ", 1)
+	for _, f := range bashTimeFormats {
+		if t, err := ParseInLocation(f.format, value, time.Local); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// splitInlineTimestamp splits a history line that carries its own timestamp,
+// e.g. "  42  2024-01-02 15:04:05 git status" or ": 1700000000:0;git status".
+// ok is false for ordinary command lines.
+func splitInlineTimestamp(line string) (ts *time.Time, command string, ok bool) {
+	if m := zshExtendedLine.FindStringSubmatch(line); m != nil {
+		epoch, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, "", false
+		}
+		t := time.Unix(epoch, 0)
+		return &t, m[2], true
+	}
+
+	m := inlineTimestampLine.FindStringSubmatch(line)
+	if m == nil {
+		return nil, "", false
+	}
+	if ts = parseBashDate(m[1]); ts == nil {
+		return nil, "", false
+	}
+	return ts, m[2], true
+}
+
 // detectCurrentShell detects the type of Unix shell: Bash, Zshell etc.
 func detectCurrentShell() (string, error) {
 	currentShellPath, ok := os.LookupEnv("SHELL")
@@ -194,26 +336,293 @@ func detectCurrentShell() (string, error) {
 	return currentShell, nil
 }
 
-func readHistoryAndPopulateTree(tree *AVLTree) error {
+func readHistoryAndPopulateTree(tree *AVLTree, config HistoryConfig) error {
+	history, err := readHistoryEntries(config)
+	if err != nil {
+		return err
+	}
+	populateTreeFromHistory(tree, history, config)
+	return nil
+}
+
+// readHistoryEntries parses the current shell's history file (and its
+// rotated copies when configured) without aggregating it
+func readHistoryEntries(config HistoryConfig) ([]HistoryEntry, error) {
+	s, historyPath, err := currentHistoryFile()
+	if err != nil {
+		return nil, err
+	}
+	return readHistoryFile(s, historyPath, config)
+}
+
+// currentHistoryFile returns the current shell and the history file it writes
+func currentHistoryFile() (shell string, historyPath string, err error) {
 	s, err := detectCurrentShell()
 	if err != nil {
-		log.Fatalf("Error while resolving the path: %v", err)
+		return "", "", fmt.Errorf("error while resolving the path: %v", err)
 	}
 
-	var history []HistoryEntry
-	switch s {
+	if _, ok := historyFileNames[s]; !ok {
+		return "", "", fmt.Errorf("unknown shell: %s detected. Recaller reads zsh and bash history", s)
+	}
+
+	historyPath, err = historyFilePath(s)
+	if err != nil {
+		return "", "", err
+	}
+	return s, historyPath, nil
+}
+
+// readHistoryFileAndPopulateTree parses the history file at historyPath using
+// the format of shell ("zsh" or "bash") and inserts its commands into tree.
+// With config.IncludeRotated, rotated copies next to the file are merged in
+// ahead of it, oldest first.
+func readHistoryFileAndPopulateTree(tree *AVLTree, shell string, historyPath string, config HistoryConfig) error {
+	history, err := readHistoryFile(shell, historyPath, config)
+	if err != nil {
+		return err
+	}
+
+	populateTreeFromHistory(tree, history, config)
+	return nil
+}
+
+// readHistoryFile parses the history file at historyPath using the format of
+// shell, merging in rotated copies ahead of it when config.IncludeRotated is set
+func readHistoryFile(shell string, historyPath string, config HistoryConfig) ([]HistoryEntry, error) {
+	history, err := readShellHistory(shell, historyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.IncludeRotated {
+		var merged []HistoryEntry
+		for _, rotatedPath := range findRotatedHistoryFiles(historyPath, config.RotatedGlob) {
+			entries, err := readShellHistory(shell, rotatedPath)
+			if err != nil {
+				log.Printf("Skipping rotated history file %s: %v", rotatedPath, err)
+				continue
+			}
+			merged = append(merged, entries...)
+		}
+		history = append(merged, history...)
+	}
+	return history, nil
+}
+
+// rawHistoryEntry is a parsed history entry as printed by `recaller history --raw`
+type rawHistoryEntry struct {
+	Command   string     `json:"command"`
+	Timestamp *time.Time `json:"timestamp"`
+	Frequency int        `json:"frequency"` // Entries with the same command
+}
+
+// writeRawHistory prints history as the parser read it, oldest first, as a
+// JSON array. Nothing is filtered, so parser mistakes stay visible.
+func writeRawHistory(w io.Writer, history []HistoryEntry) error {
+	frequency := make(map[string]int, len(history))
+	for _, entry := range history {
+		frequency[strings.TrimSpace(entry.Command)]++
+	}
+
+	raw := make([]rawHistoryEntry, 0, len(history))
+	for _, entry := range history {
+		raw = append(raw, rawHistoryEntry{
+			Command:   entry.Command,
+			Timestamp: entry.Timestamp,
+			Frequency: frequency[strings.TrimSpace(entry.Command)],
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(raw)
+}
+
+// readShellHistory reads historyPath with the parser for shell
+func readShellHistory(shell string, historyPath string) ([]HistoryEntry, error) {
+	switch shell {
 	case "zsh":
-		history, err = readZshHistoryWithEpoch()
+		return readZshHistoryWithEpoch(historyPath)
 	case "bash":
-		history, err = readBashHistoryWithEpoch()
+		return readBashHistoryWithEpoch(historyPath)
 	default:
-		log.Fatalf("Unknown shell: %s detected. Aborting.", s)
+		return nil, fmt.Errorf("unknown shell: %s", shell)
 	}
+}
 
-	if err != nil {
-		return err
+// readShellHistoryFrom parses history lines read from r with the parser for
+// shell; size is the length of the input
+func readShellHistoryFrom(shell string, r io.Reader, size int64) ([]HistoryEntry, error) {
+	switch shell {
+	case "zsh":
+		return parseZshHistory(r, size)
+	case "bash":
+		return parseBashHistory(r, size)
+	default:
+		return nil, fmt.Errorf("unknown shell: %s", shell)
+	}
+}
+
+// findRotatedHistoryFiles returns rotated or archived copies of historyPath,
+// oldest first by modification time. pattern is a glob relative to the history
+// file's directory; when empty, "<name>.*" and "<name>-*" are used
+// (e.g. .zsh_history.1, .zsh_history-2024.gz). Lock files are ignored.
+func findRotatedHistoryFiles(historyPath string, pattern string) []string {
+	dir := filepath.Dir(historyPath)
+	name := filepath.Base(historyPath)
+
+	patterns := []string{name + ".*", name + "-*"}
+	if pattern != "" {
+		patterns = []string{pattern}
 	}
 
+	seen := make(map[string]bool)
+	var files []string
+	for _, p := range patterns {
+		matches, err := filepath.Glob(filepath.Join(dir, p))
+		if err != nil {
+			log.Printf("Invalid rotated history pattern %q: %v", p, err)
+			continue
+		}
+		for _, match := range matches {
+			if match == historyPath || seen[match] || strings.HasSuffix(strings.ToLower(match), ".lock") {
+				continue
+			}
+			if info, err := os.Stat(match); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			seen[match] = true
+			files = append(files, match)
+		}
+	}
+
+	sort.SliceStable(files, func(i, j int) bool {
+		fi, erri := os.Stat(files[i])
+		fj, errj := os.Stat(files[j])
+		if erri != nil || errj != nil {
+			return files[i] < files[j]
+		}
+		return fi.ModTime().Before(fj.ModTime())
+	})
+	return files
+}
+
+// isCommentLine reports whether a history command is only a shell comment.
+// Commands with a '#' later in the line (e.g. `echo "#1"` or `ls # note`) are kept.
+func isCommentLine(command string) bool {
+	return strings.HasPrefix(command, "#")
+}
+
+// isExcludedCommand reports whether the base command of command (ignoring any
+// leading path, so "./recaller fs" matches "recaller") is in excluded. It
+// also picks out boost_commands.
+func isExcludedCommand(command string, excluded []string) bool {
+	if len(excluded) == 0 {
+		return false
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	base := filepath.Base(fields[0])
+	for _, ex := range excluded {
+		if base == ex {
+			return true
+		}
+	}
+	return false
+}
+
+// envAssignment matches the start of a leading VAR=value word
+var envAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// splitEnvPrefix splits leading VAR=value assignments off command, e.g.
+// `FOO="a b" go build` into `FOO="a b"` and `go build`. Quoted and escaped
+// values may contain spaces. A command made only of assignments, or one whose
+// quoting does not close, has no prefix.
+func splitEnvPrefix(command string) (prefix, rest string) {
+	rest = strings.TrimLeft(command, " \t")
+	for envAssignment.MatchString(rest) {
+		end := len(envAssignment.FindString(rest))
+		var quote byte
+		for ; end < len(rest); end++ {
+			c := rest[end]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				} else if c == '\\' && quote == '"' {
+					end++
+				}
+				continue
+			}
+			if c == ' ' || c == '\t' {
+				break
+			}
+			switch c {
+			case '\'', '"':
+				quote = c
+			case '\\':
+				end++
+			}
+		}
+		if quote != 0 || end >= len(rest) {
+			return "", command
+		}
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	prefix = strings.TrimSpace(strings.TrimSuffix(strings.TrimLeft(command, " \t"), rest))
+	if prefix == "" || rest == "" {
+		return "", command
+	}
+	return prefix, rest
+}
+
+// sparklineDays returns the configured sparkline window, defaulting to 30 days
+func sparklineDays(days int) int {
+	if days <= 0 {
+		return 30
+	}
+	return days
+}
+
+// addDailyCount buckets one use of command at ts into a per-day slice covering
+// the window ending on now's calendar day. Older uses are ignored.
+func addDailyCount(daily map[string][]int, command string, ts time.Time, now time.Time, days int) {
+	days = sparklineDays(days)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	local := ts.In(now.Location())
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, now.Location())
+	// Round to tolerate 23h/25h days around DST changes
+	daysAgo := int(today.Sub(day).Round(24*time.Hour) / (24 * time.Hour))
+	if daysAgo < 0 || daysAgo >= days {
+		return
+	}
+
+	counts := daily[command]
+	if counts == nil {
+		counts = make([]int, days)
+		daily[command] = counts
+	}
+	counts[days-1-daysAgo]++
+}
+
+// decayWeight is the weight of one use at ts: 1 when it just happened, halving
+// every halfLifeDays. Uses without a timestamp, or from the future, count fully.
+func decayWeight(ts *time.Time, now time.Time, halfLifeDays int) float64 {
+	if ts == nil || halfLifeDays <= 0 {
+		return 1
+	}
+	ageDays := now.Sub(*ts).Hours() / 24
+	if ageDays <= 0 {
+		return 1
+	}
+	return math.Exp2(-ageDays / float64(halfLifeDays))
+}
+
+// populateTreeFromHistory aggregates parsed history entries into per-command
+// frequency and recency metadata and inserts them into tree.
+func populateTreeFromHistory(tree *AVLTree, history []HistoryEntry, config HistoryConfig) {
 	// Optimize: Pre-allocate frequency map with estimated capacity
 	// and track most recent timestamp per command for efficiency
 	capacity := len(history) / 4
@@ -222,8 +631,19 @@ func readHistoryAndPopulateTree(tree *AVLTree) error {
 	}
 	freqMap := make(map[string]int, capacity) // Estimate unique commands
 	lastTimestamp := make(map[string]*time.Time, capacity)
-	fallbackBase := time.Now()
+	related := make(map[string]map[string]int, capacity)
+	fallbackBase := timeNow()
 	fallbackCounter := 0
+	var aged map[string]float64
+	if config.FrequencyDecayDays > 0 {
+		aged = make(map[string]float64, capacity)
+	}
+	var daily map[string][]int
+	if config.UsageSparkline {
+		daily = make(map[string][]int, capacity)
+	}
+	// Command that ran right after the current one (we walk backwards)
+	nextCommand := ""
 
 	// Process history in reverse to get most recent timestamps efficiently
 	for i := len(history) - 1; i >= 0; i-- {
@@ -232,16 +652,43 @@ func readHistoryAndPopulateTree(tree *AVLTree) error {
 		if command == "" {
 			continue
 		}
+		if config.SkipComments && isCommentLine(command) {
+			continue
+		}
+		if isExcludedCommand(command, config.ExcludeCommands) {
+			continue
+		}
+		// A run of the same command (e.g. retrying a flaky test) counts once;
+		// its latest use was seen first since we walk backwards
+		if config.CollapseConsecutive && command == nextCommand {
+			continue
+		}
 
 		// Update frequency count
 		freqMap[command]++
+		if aged != nil {
+			aged[command] += decayWeight(hist.Timestamp, fallbackBase, config.FrequencyDecayDays)
+		}
+
+		// Record adjacency between consecutive commands in both directions
+		if nextCommand != "" && nextCommand != command {
+			addRelated(related, command, nextCommand)
+			addRelated(related, nextCommand, command)
+		}
+		nextCommand = command
 
 		switch {
 		case hist.Timestamp != nil:
 			if lastTimestamp[command] == nil || hist.Timestamp.After(*lastTimestamp[command]) {
 				lastTimestamp[command] = hist.Timestamp
 			}
+			if daily != nil {
+				addDailyCount(daily, command, *hist.Timestamp, fallbackBase, config.SparklineDays)
+			}
 		default:
+			// Plain histories (zsh without EXTENDED_HISTORY, bash without
+			// HISTTIMEFORMAT) have no timestamps: the line order stands in,
+			// each earlier line a second further before now
 			if lastTimestamp[command] == nil {
 				fallbackCounter++
 				fallback := fallbackBase.Add(-time.Duration(fallbackCounter) * time.Second)
@@ -256,9 +703,25 @@ func readHistoryAndPopulateTree(tree *AVLTree) error {
 			Command:   command,
 			Timestamp: lastTimestamp[command],
 			Frequency: frequency,
+			Related:   related[command],
+		}
+		if aged != nil {
+			metadata.AgedFrequency = aged[command]
+		}
+		if daily != nil {
+			metadata.DailyCounts = daily[command]
+			if metadata.DailyCounts == nil {
+				metadata.DailyCounts = make([]int, sparklineDays(config.SparklineDays))
+			}
 		}
 		tree.Insert(command, metadata)
 	}
+}
 
-	return nil
+// addRelated increments the co-occurrence count of other for command
+func addRelated(related map[string]map[string]int, command, other string) {
+	if related[command] == nil {
+		related[command] = make(map[string]int)
+	}
+	related[command][other]++
 }
@@ -0,0 +1,573 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// treeKeys returns all commands stored in the tree in key order.
+func treeKeys(tree *AVLTree) []string {
+	var keys []string
+	inOrderTraversal(tree.Root, &keys)
+	return keys
+}
+
+// writeHistoryFixture writes content to a temporary history file and returns its path.
+func writeHistoryFixture(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write history fixture: %v", err)
+	}
+	return path
+}
+
+// rankedCommandNames returns the commands of ranked results in order.
+func rankedCommandNames(results []RankedCommand) []string {
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		names = append(names, r.Command)
+	}
+	return names
+}
+
+func assertRanking(t *testing.T, tree *AVLTree, query string, fuzzy bool, expected []string) {
+	t.Helper()
+	got := rankedCommandNames(SearchWithRanking(tree, query, fuzzy))
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("SearchWithRanking(%q, fuzzy=%t) = %q; want %q", query, fuzzy, got, expected)
+	}
+}
+
+func TestZshHistoryPipeline(t *testing.T) {
+	now := time.Now().Unix()
+	path := writeHistoryFixture(t, ".zsh_history", fmt.Sprintf(
+		": 1700000000:0;git status\n"+
+			": 1700000100:0;git commit -m \"wip\"\n"+
+			": 1700000200:0;git status\n"+
+			": 1700000300:0;ls -la\n"+
+			": %d:0;git push\n"+
+			"git status\n", now))
+
+	tree := NewAVLTree()
+	if err := readHistoryFileAndPopulateTree(tree, "zsh", path, cloneDefaultConfig().History); err != nil {
+		t.Fatalf("readHistoryFileAndPopulateTree: %v", err)
+	}
+
+	assertRanking(t, tree, "git", true, []string{"git status", "git push", `git commit -m "wip"`})
+	assertRanking(t, tree, "git p", false, []string{"git push"})
+	assertRanking(t, tree, "status", true, []string{"git status"})
+	assertRanking(t, tree, "status", false, []string{})
+}
+
+func TestBashHistoryPipeline(t *testing.T) {
+	now := time.Now().Unix()
+	path := writeHistoryFixture(t, ".bash_history", fmt.Sprintf(
+		"#1700000000\n"+
+			"make build\n"+
+			"#%d\n"+
+			"make test\n"+
+			"make build\n"+
+			"recaller run\n", now))
+
+	tree := NewAVLTree()
+	if err := readHistoryFileAndPopulateTree(tree, "bash", path, cloneDefaultConfig().History); err != nil {
+		t.Fatalf("readHistoryFileAndPopulateTree: %v", err)
+	}
+
+	assertRanking(t, tree, "make", false, []string{"make build", "make test"})
+	assertRanking(t, tree, "recaller", true, []string{})
+}
+
+func TestPlainHistoriesRankByLineOrder(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+
+	tests := []struct {
+		shell, name string
+	}{
+		{"zsh", ".zsh_history"}, // setopt EXTENDED_HISTORY off
+		{"bash", ".bash_history"},
+	}
+	for _, tc := range tests {
+		path := writeHistoryFixture(t, tc.name, "ls -la\ngit status\nmake test\ngit status\ncd src\n")
+
+		entries, err := readShellHistory(tc.shell, path)
+		if err != nil {
+			t.Fatalf("%s: readShellHistory: %v", tc.shell, err)
+		}
+		for _, entry := range entries {
+			if entry.Timestamp != nil {
+				t.Errorf("%s: %q parsed with timestamp %v", tc.shell, entry.Command, entry.Timestamp)
+			}
+		}
+
+		tree := NewAVLTree()
+		populateTreeFromHistory(tree, entries, cloneDefaultConfig().History)
+		if got := len(treeKeys(tree)); got != 4 {
+			t.Fatalf("%s: tree holds %d commands; want 4", tc.shell, got)
+		}
+		// Most used first, then the most recent line
+		assertRanking(t, tree, "", true, []string{"git status", "cd src", "make test", "ls -la"})
+
+		ordered := getSuggestions("", tree, cloneDefaultConfig().History, 0, historyOrder{sortBy: "recency"})
+		if want := []string{"cd src", "git status", "make test", "ls -la"}; fmt.Sprint(ordered) != fmt.Sprint(want) {
+			t.Errorf("%s: by recency = %q; want %q", tc.shell, ordered, want)
+		}
+	}
+}
+
+func TestBashHistoryTimestampVariants(t *testing.T) {
+	path := writeHistoryFixture(t, ".bash_history",
+		"#1700000000\n"+
+			"epoch own line\n"+
+			"#2024-01-02 15:04:05\n"+
+			"formatted own line\n"+
+			"# just a comment\n"+
+			"after comment\n"+
+			"  42  2024-03-04 10:11:12 numbered history output\n"+
+			"[2024-05-06 07:08:09] bracketed inline\n"+
+			"06/07/24 08:09:10 bash manual format\n"+
+			": 1700000500:0;merged from zsh\n"+
+			"2024 was a good year\n")
+
+	entries, err := readBashHistoryWithEpoch(path)
+	if err != nil {
+		t.Fatalf("readBashHistoryWithEpoch: %v", err)
+	}
+
+	local := func(layout, value string) string {
+		ts, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts.Format(time.RFC3339)
+	}
+	want := []struct {
+		command string
+		ts      string // RFC3339, "" for no timestamp
+	}{
+		{"epoch own line", time.Unix(1700000000, 0).Format(time.RFC3339)},
+		{"formatted own line", local(time.DateTime, "2024-01-02 15:04:05")},
+		{"after comment", ""},
+		{"numbered history output", local(time.DateTime, "2024-03-04 10:11:12")},
+		{"bracketed inline", local(time.DateTime, "2024-05-06 07:08:09")},
+		{"bash manual format", local(time.DateTime, "2024-07-06 08:09:10")},
+		{"merged from zsh", time.Unix(1700000500, 0).Format(time.RFC3339)},
+		{"2024 was a good year", ""},
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries; want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		got := ""
+		if entries[i].Timestamp != nil {
+			got = entries[i].Timestamp.Format(time.RFC3339)
+		}
+		if entries[i].Command != w.command || got != w.ts {
+			t.Errorf("entry %d = %q at %q; want %q at %q", i, entries[i].Command, got, w.command, w.ts)
+		}
+	}
+}
+
+func TestHistoryPipelineIncludesRotatedFiles(t *testing.T) {
+	path := writeHistoryFixture(t, ".zsh_history", ": 1700000300:0;git push\n")
+	dir := filepath.Dir(path)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(": 1600000000:0;git push\n: 1600000100:0;make archive\n"))
+	zw.Close()
+
+	fixtures := map[string][]byte{
+		".zsh_history.2.gz": gz.Bytes(),
+		".zsh_history.1":    []byte(": 1650000000:0;git pull\n"),
+		".zsh_history.LOCK": []byte(": 1650000000:0;locked\n"),
+	}
+	for name, content := range fixtures {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(filepath.Join(dir, ".zsh_history.2.gz"), old, old)
+
+	rotated := findRotatedHistoryFiles(path, "")
+	expectedFiles := []string{filepath.Join(dir, ".zsh_history.2.gz"), filepath.Join(dir, ".zsh_history.1")}
+	if fmt.Sprint(rotated) != fmt.Sprint(expectedFiles) {
+		t.Errorf("findRotatedHistoryFiles = %v; want %v", rotated, expectedFiles)
+	}
+
+	config := HistoryConfig{IncludeRotated: true}
+	tree := NewAVLTree()
+	if err := readHistoryFileAndPopulateTree(tree, "zsh", path, config); err != nil {
+		t.Fatalf("readHistoryFileAndPopulateTree: %v", err)
+	}
+	if !verifyInOrderTraversal(t, tree.Root, []string{"git pull", "git push", "make archive"}) {
+		t.Errorf("unexpected commands in tree: %v", treeKeys(tree))
+	}
+	if value, _ := tree.Search("git push"); value.(CommandMetadata).Frequency != 2 {
+		t.Errorf("git push frequency = %d; want 2", value.(CommandMetadata).Frequency)
+	}
+
+	tree = NewAVLTree()
+	if err := readHistoryFileAndPopulateTree(tree, "zsh", path, HistoryConfig{}); err != nil {
+		t.Fatalf("readHistoryFileAndPopulateTree: %v", err)
+	}
+	if !verifyInOrderTraversal(t, tree.Root, []string{"git push"}) {
+		t.Errorf("rotated files read without include_rotated: %v", treeKeys(tree))
+	}
+}
+
+func TestHistoryPipelineMissingFile(t *testing.T) {
+	tree := NewAVLTree()
+	missing := filepath.Join(t.TempDir(), ".zsh_history")
+	if err := readHistoryFileAndPopulateTree(tree, "zsh", missing, HistoryConfig{}); err == nil {
+		t.Error("expected an error for a missing history file")
+	}
+	if err := readHistoryFileAndPopulateTree(tree, "fish", missing, HistoryConfig{}); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestPopulateTreeSkipsCommentsAndBlankLines(t *testing.T) {
+	history := []HistoryEntry{
+		{Command: "# deploy notes"},
+		{Command: ""},
+		{Command: "   "},
+		{Command: "  #indented comment"},
+		{Command: "ls -la"},
+		{Command: `echo "#1 item"`},
+		{Command: "make build # with a trailing note"},
+	}
+
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, history, HistoryConfig{SkipComments: true})
+
+	expected := []string{`echo "#1 item"`, "ls -la", "make build # with a trailing note"}
+	if !verifyInOrderTraversal(t, tree.Root, expected) {
+		t.Errorf("unexpected commands in tree: %v", treeKeys(tree))
+	}
+}
+
+func TestPopulateTreeKeepsCommentsWhenDisabled(t *testing.T) {
+	history := []HistoryEntry{
+		{Command: "# deploy notes"},
+		{Command: ""},
+		{Command: "ls -la"},
+	}
+
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, history, HistoryConfig{SkipComments: false})
+
+	expected := []string{"# deploy notes", "ls -la"}
+	if !verifyInOrderTraversal(t, tree.Root, expected) {
+		t.Errorf("unexpected commands in tree: %v", treeKeys(tree))
+	}
+}
+
+func TestPopulateTreeTracksRelatedCommands(t *testing.T) {
+	history := []HistoryEntry{
+		{Command: "make build"},
+		{Command: "make test"},
+		{Command: "make deploy"},
+		{Command: "make build"},
+		{Command: "make test"},
+		{Command: "ls"},
+	}
+
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, history, HistoryConfig{})
+
+	value, found := tree.Search("make test")
+	if !found {
+		t.Fatal("expected 'make test' to be indexed")
+	}
+
+	related := TopRelatedCommands(value.(CommandMetadata), 2)
+	expected := []string{"make build", "ls"}
+	if len(related) != len(expected) {
+		t.Fatalf("TopRelatedCommands = %v; want %v", related, expected)
+	}
+	for i := range expected {
+		if related[i] != expected[i] {
+			t.Errorf("TopRelatedCommands = %v; want %v", related, expected)
+			break
+		}
+	}
+}
+
+func TestPopulateTreeExcludesRecallerCommands(t *testing.T) {
+	history := []HistoryEntry{
+		{Command: "recaller run"},
+		{Command: "git status"},
+		{Command: "recaller fs index"},
+		{Command: "/usr/local/bin/recaller history"},
+		{Command: "recaller-backup sync"},
+		{Command: "htop"},
+	}
+
+	config := cloneDefaultConfig().History
+	config.ExcludeCommands = append(config.ExcludeCommands, "htop")
+
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, history, config)
+
+	expected := []string{"git status", "recaller-backup sync"}
+	if !verifyInOrderTraversal(t, tree.Root, expected) {
+		t.Errorf("unexpected commands in tree: %v", treeKeys(tree))
+	}
+}
+
+func TestPopulateTreeTracksDailyCounts(t *testing.T) {
+	now := time.Now()
+	twoDaysAgo := now.AddDate(0, 0, -2)
+	longAgo := now.AddDate(0, 0, -30)
+	history := []HistoryEntry{
+		{Command: "make build", Timestamp: &longAgo},
+		{Command: "make build", Timestamp: &twoDaysAgo},
+		{Command: "make build", Timestamp: &now},
+		{Command: "make build", Timestamp: &now},
+		{Command: "ls"},
+	}
+
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, history, HistoryConfig{UsageSparkline: true, SparklineDays: 7})
+
+	value, _ := tree.Search("make build")
+	want := []int{0, 0, 0, 0, 1, 0, 2}
+	if got := value.(CommandMetadata).DailyCounts; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("DailyCounts = %v; want %v", got, want)
+	}
+
+	value, _ = tree.Search("ls")
+	if got := value.(CommandMetadata).DailyCounts; len(got) != 7 {
+		t.Errorf("DailyCounts for untimed command = %v; want 7 empty days", got)
+	}
+
+	tree = NewAVLTree()
+	populateTreeFromHistory(tree, history, HistoryConfig{})
+	value, _ = tree.Search("make build")
+	if got := value.(CommandMetadata).DailyCounts; got != nil {
+		t.Errorf("DailyCounts with sparkline disabled = %v; want nil", got)
+	}
+}
+
+func TestFrequencyDecayFavorsRecentHabits(t *testing.T) {
+	now := time.Now()
+	lastYear := now.AddDate(-1, 0, 0)
+	var history []HistoryEntry
+	for i := 0; i < 100; i++ {
+		history = append(history, HistoryEntry{Command: "make old-target", Timestamp: &lastYear})
+	}
+	for i := 0; i < 10; i++ {
+		ts := now.Add(-time.Duration(i) * 12 * time.Hour)
+		history = append(history, HistoryEntry{Command: "make new-target", Timestamp: &ts})
+	}
+
+	ranking := func(config HistoryConfig) []string {
+		tree := NewAVLTree()
+		populateTreeFromHistory(tree, history, config)
+		var commands []string
+		for _, ranked := range rankNodes(tree.SearchFuzzy("make"), "make", historyScoring(config), nil) {
+			commands = append(commands, ranked.Command)
+		}
+		return commands
+	}
+
+	if raw := ranking(HistoryConfig{}); raw[0] != "make old-target" {
+		t.Errorf("raw counts ranking = %v; want the 100 old uses first", raw)
+	}
+	if aged := ranking(HistoryConfig{FrequencyDecayDays: 30}); aged[0] != "make new-target" {
+		t.Errorf("aged ranking = %v; want this week's command first", aged)
+	}
+
+	tree := NewAVLTree()
+	populateTreeFromHistory(tree, history, HistoryConfig{FrequencyDecayDays: 30})
+	value, _ := tree.Search("make old-target")
+	if metadata := value.(CommandMetadata); metadata.Frequency != 100 || metadata.AgedFrequency >= 1 {
+		t.Errorf("old command Frequency = %d, AgedFrequency = %f; want the raw count kept and a decayed weight", metadata.Frequency, metadata.AgedFrequency)
+	}
+
+	// A command decayed all the way to 0 must not fall back to its raw count
+	decayed := NewAVLTree()
+	decayed.Insert("make ancient", CommandMetadata{Frequency: 1000})
+	decayed.Insert("make recent", CommandMetadata{Frequency: 1, AgedFrequency: 0.5})
+	if got := SearchHistory(decayed, "make", HistoryConfig{FrequencyDecayDays: 30}); got[0].Command != "make recent" {
+		t.Errorf("with decay on %q ranked first; want the command with the higher aged frequency", got[0].Command)
+	}
+}
+
+func TestDecayWeight(t *testing.T) {
+	now := time.Now()
+	monthAgo := now.Add(-30 * 24 * time.Hour)
+	future := now.Add(time.Hour)
+
+	if got := decayWeight(&monthAgo, now, 30); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("decayWeight after one half-life = %f; want 0.5", got)
+	}
+	if got := decayWeight(nil, now, 30); got != 1 {
+		t.Errorf("decayWeight without timestamp = %f; want 1", got)
+	}
+	if got := decayWeight(&future, now, 30); got != 1 {
+		t.Errorf("decayWeight for a future timestamp = %f; want 1", got)
+	}
+}
+
+func TestWriteRawHistory(t *testing.T) {
+	ts := time.Date(2025, 3, 14, 9, 30, 0, 0, time.UTC)
+	history := []HistoryEntry{
+		{Command: "git status", Timestamp: &ts},
+		{Command: "echo 'a\nb'"},
+		{Command: "git status ", Timestamp: &ts},
+	}
+
+	var out bytes.Buffer
+	if err := writeRawHistory(&out, history); err != nil {
+		t.Fatalf("writeRawHistory: %v", err)
+	}
+
+	var got []rawHistoryEntry
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, out.String())
+	}
+	if len(got) != 3 {
+		t.Fatalf("got %d entries; want all 3 parsed entries", len(got))
+	}
+	if got[0].Frequency != 2 || got[1].Frequency != 1 {
+		t.Errorf("frequencies = %d, %d; want 2, 1", got[0].Frequency, got[1].Frequency)
+	}
+	if got[1].Command != "echo 'a\nb'" || got[1].Timestamp != nil {
+		t.Errorf("multi-line entry = %+v", got[1])
+	}
+	if got[0].Timestamp == nil || !got[0].Timestamp.Equal(ts) {
+		t.Errorf("timestamp = %v; want %v", got[0].Timestamp, ts)
+	}
+}
+
+func TestPopulateTreeCollapsesConsecutiveRuns(t *testing.T) {
+	first := time.Now().Add(-time.Hour)
+	last := time.Now()
+	var history []HistoryEntry
+	for i := 0; i < 5; i++ {
+		history = append(history, HistoryEntry{Command: "go test ./flaky", Timestamp: &first})
+	}
+	history = append(history,
+		HistoryEntry{Command: "git diff"},
+		HistoryEntry{Command: "go test ./flaky", Timestamp: &last},
+		HistoryEntry{Command: "go test ./flaky", Timestamp: &last},
+	)
+
+	frequency := func(config HistoryConfig) (int, *time.Time) {
+		tree := NewAVLTree()
+		populateTreeFromHistory(tree, history, config)
+		value, _ := tree.Search("go test ./flaky")
+		metadata := value.(CommandMetadata)
+		return metadata.Frequency, metadata.Timestamp
+	}
+
+	if got, _ := frequency(HistoryConfig{}); got != 7 {
+		t.Errorf("Frequency without collapsing = %d; want 7", got)
+	}
+	got, ts := frequency(HistoryConfig{CollapseConsecutive: true})
+	if got != 2 {
+		t.Errorf("Frequency with collapse_consecutive = %d; want 2 runs", got)
+	}
+	if ts == nil || !ts.Equal(last) {
+		t.Errorf("Timestamp = %v; want the latest use %v", ts, last)
+	}
+}
+
+func TestSplitEnvPrefix(t *testing.T) {
+	tests := []struct {
+		command, prefix, rest string
+	}{
+		{"FOO=bar go build", "FOO=bar", "go build"},
+		{"GOOS=linux GOARCH=arm64 go build ./...", "GOOS=linux GOARCH=arm64", "go build ./..."},
+		{`MSG="a b" DIR='x y' make`, `MSG="a b" DIR='x y'`, "make"},
+		{`A=x\ y cmd`, `A=x\ y`, "cmd"},
+		{"go build", "", "go build"},
+		{"FOO=bar", "", "FOO=bar"},
+		{`FOO="open make`, "", `FOO="open make`},
+		{"echo FOO=bar", "", "echo FOO=bar"},
+		{"1X=bar ls", "", "1X=bar ls"},
+	}
+
+	for _, tt := range tests {
+		prefix, rest := splitEnvPrefix(tt.command)
+		if prefix != tt.prefix || rest != tt.rest {
+			t.Errorf("splitEnvPrefix(%q) = %q, %q; want %q, %q", tt.command, prefix, rest, tt.prefix, tt.rest)
+		}
+	}
+}
+
+func TestHistoryFilePathHonorsHISTFILE(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		histFile, shell, want string
+	}{
+		{"", "zsh", filepath.Join(home, ".zsh_history")},
+		{"", "bash", filepath.Join(home, ".bash_history")},
+		{"/data/dotfiles/zsh/history", "zsh", "/data/dotfiles/zsh/history"},
+		{"~/.local/state/bash/history", "bash", filepath.Join(home, ".local/state/bash/history")},
+	}
+	for _, tc := range tests {
+		t.Setenv("HISTFILE", tc.histFile)
+		if got, err := historyFilePath(tc.shell); err != nil || got != tc.want {
+			t.Errorf("historyFilePath(%s) with HISTFILE=%q = %q, %v; want %q", tc.shell, tc.histFile, got, err, tc.want)
+		}
+	}
+
+	// The history is read from $HISTFILE, and a missing one is named in the error
+	histFile := writeHistoryFixture(t, "history", ": 1700000000:0;git status\n")
+	t.Setenv("HISTFILE", histFile)
+	t.Setenv("SHELL", "/bin/zsh")
+	entries, err := readHistoryEntries(cloneDefaultConfig().History)
+	if err != nil || len(entries) != 1 || entries[0].Command != "git status" {
+		t.Errorf("readHistoryEntries with HISTFILE = %+v, %v; want git status", entries, err)
+	}
+	missing := filepath.Join(home, "state", "zsh_history")
+	t.Setenv("HISTFILE", missing)
+	if _, err := readHistoryEntries(cloneDefaultConfig().History); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("error %v should name %s", err, missing)
+	}
+}
+
+func TestMissingHistoryFileIsNotExist(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), ".zsh_history")
+	for _, shell := range []string{"zsh", "bash"} {
+		_, err := readShellHistory(shell, missing)
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: error %v does not match os.ErrNotExist", shell, err)
+		}
+		if err == nil || !strings.Contains(err.Error(), missing) {
+			t.Errorf("%s: error %v should name the file to create", shell, err)
+		}
+	}
+}
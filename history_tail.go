@@ -0,0 +1,226 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// historyPollInterval is how often the history UI checks the history
+	// file for commands run in other terminals (history.live_reload)
+	historyPollInterval = 2 * time.Second
+	// historyTailAnchorSize is how many bytes before the read offset are
+	// compared on each check to notice a file that was rewritten
+	historyTailAnchorSize = 64
+)
+
+// historyTail follows a shell history file while the UI is open. Shells
+// append to their history, so each check reads only the bytes added since
+// the last one.
+type historyTail struct {
+	shell  string
+	path   string
+	offset int64  // Bytes of the file already read
+	anchor []byte // The bytes just before offset
+	last   string // Most recent command, which the next new command follows
+}
+
+// newHistoryTail follows path from its current end. last is the most recent
+// command already loaded into the tree.
+func newHistoryTail(shell string, path string, last string) *historyTail {
+	t := &historyTail{shell: shell, path: path, last: last}
+	if file, err := os.Open(path); err == nil {
+		if info, err := file.Stat(); err == nil {
+			t.resync(file, info.Size())
+		}
+		file.Close()
+	}
+	return t
+}
+
+// resync skips to size, the end of the file, without reading what is before it
+func (t *historyTail) resync(file *os.File, size int64) {
+	start := max(size-historyTailAnchorSize, 0)
+	anchor := make([]byte, size-start)
+	n, _ := file.ReadAt(anchor, start)
+	t.offset = start + int64(n)
+	t.anchor = anchor[:n]
+}
+
+// readNew returns the commands appended since the last call. A line still
+// being written is left for the next call. When the file was truncated or
+// rewritten (e.g. by 'history -w' or when zsh trims it to SAVEHIST) rather
+// than appended to, reading restarts from its new end.
+func (t *historyTail) readNew() ([]HistoryEntry, error) {
+	file, err := os.Open(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == t.offset {
+		return nil, nil
+	}
+	if size < t.offset {
+		t.resync(file, size)
+		return nil, nil
+	}
+
+	start := t.offset - int64(len(t.anchor))
+	data := make([]byte, size-start)
+	n, err := file.ReadAt(data, start)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	data = data[:n]
+	if !bytes.HasPrefix(data, t.anchor) {
+		t.resync(file, size)
+		return nil, nil
+	}
+	data = data[len(t.anchor):]
+
+	end := bytes.LastIndexByte(data, '\n') + 1
+	if t.shell == "bash" {
+		// bash writes a command's timestamp line just before it; keep a
+		// trailing one for the command that follows
+		for end > 0 {
+			lineStart := bytes.LastIndexByte(data[:end-1], '\n') + 1
+			if data[lineStart] != '#' {
+				break
+			}
+			end = lineStart
+		}
+	}
+	if end == 0 {
+		return nil, nil
+	}
+
+	chunk := data[:end]
+	t.offset += int64(end)
+	t.anchor = append(t.anchor, chunk...)
+	t.anchor = t.anchor[max(len(t.anchor)-historyTailAnchorSize, 0):]
+
+	return readShellHistoryFrom(t.shell, bytes.NewReader(chunk), int64(len(chunk)))
+}
+
+// watch checks the history file every interval, sending new commands to
+// updates, until stop is closed
+func (t *historyTail) watch(interval time.Duration, updates chan<- []HistoryEntry, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			entries, err := t.readNew()
+			if err != nil || len(entries) == 0 {
+				continue
+			}
+			select {
+			case updates <- entries:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// addHistoryEntries adds commands run after tree was built, oldest first,
+// updating the metadata of commands already in it the way
+// populateTreeFromHistory would have. It reports whether tree changed.
+func (t *historyTail) addHistoryEntries(tree *AVLTree, entries []HistoryEntry, config HistoryConfig) bool {
+	now := timeNow()
+	changed := false
+	for _, entry := range entries {
+		command := strings.TrimSpace(entry.Command)
+		if command == "" {
+			continue
+		}
+		if config.SkipComments && isCommentLine(command) {
+			continue
+		}
+		if isExcludedCommand(command, config.ExcludeCommands) {
+			continue
+		}
+		if config.CollapseConsecutive && command == t.last {
+			continue
+		}
+
+		ts := entry.Timestamp
+		if ts == nil {
+			ts = &now
+		}
+		node := tree.findNode(command)
+		if node == nil {
+			tree.Insert(command, CommandMetadata{Command: command})
+			node = tree.findNode(command)
+		}
+		metadata := &node.Value
+		metadata.Frequency++
+		if config.FrequencyDecayDays > 0 {
+			metadata.AgedFrequency += decayWeight(ts, now, config.FrequencyDecayDays)
+		}
+		if metadata.Timestamp == nil || ts.After(*metadata.Timestamp) {
+			metadata.Timestamp = ts
+		}
+		if config.UsageSparkline {
+			daily := map[string][]int{command: metadata.DailyCounts}
+			addDailyCount(daily, command, *ts, now, config.SparklineDays)
+			metadata.DailyCounts = daily[command]
+		}
+		if t.last != "" && t.last != command {
+			if previous := tree.findNode(t.last); previous != nil {
+				metadata.Related = incrementRelated(metadata.Related, t.last)
+				previous.Value.Related = incrementRelated(previous.Value.Related, command)
+			}
+		}
+		t.last = command
+		changed = true
+	}
+	return changed
+}
+
+// incrementRelated counts one more run of other next to a command
+func incrementRelated(related map[string]int, other string) map[string]int {
+	if related == nil {
+		related = make(map[string]int)
+	}
+	related[other]++
+	return related
+}
+
+// lastHistoryCommand returns the most recent non-empty command in history
+func lastHistoryCommand(history []HistoryEntry) string {
+	for i := len(history) - 1; i >= 0; i-- {
+		if command := strings.TrimSpace(history[i].Command); command != "" {
+			return command
+		}
+	}
+	return ""
+}
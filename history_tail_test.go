@@ -0,0 +1,146 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func appendToFile(t *testing.T, path string, text string) {
+	t.Helper()
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func commandsOf(entries []HistoryEntry) []string {
+	var commands []string
+	for _, entry := range entries {
+		commands = append(commands, entry.Command)
+	}
+	return commands
+}
+
+func TestHistoryTailReadsAppendedZshCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	appendToFile(t, path, ": 1700000000:0;git status\n: 1700000001:0;make test\n")
+
+	tail := newHistoryTail("zsh", path, "make test")
+	if entries, err := tail.readNew(); err != nil || len(entries) != 0 {
+		t.Fatalf("readNew before any change = %v, %v; want nothing", entries, err)
+	}
+
+	// A line still being written waits for the next check
+	appendToFile(t, path, ": 1700000002:0;git push\n: 1700000003:0;git lo")
+	entries, err := tail.readNew()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := commandsOf(entries); len(got) != 1 || got[0] != "git push" {
+		t.Errorf("first read = %q; want [git push]", got)
+	}
+	if entries[0].Timestamp == nil || entries[0].Timestamp.Unix() != 1700000002 {
+		t.Errorf("timestamp = %v; want 1700000002", entries[0].Timestamp)
+	}
+
+	appendToFile(t, path, "g\n")
+	entries, _ = tail.readNew()
+	if got := commandsOf(entries); len(got) != 1 || got[0] != "git log" {
+		t.Errorf("second read = %q; want [git log]", got)
+	}
+}
+
+func TestHistoryTailKeepsBashTimestampWithCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".bash_history")
+	appendToFile(t, path, "#1700000000\nls\n")
+	tail := newHistoryTail("bash", path, "ls")
+
+	appendToFile(t, path, "#1700000005\n")
+	if entries, _ := tail.readNew(); len(entries) != 0 {
+		t.Errorf("a lone timestamp line produced %q", commandsOf(entries))
+	}
+	appendToFile(t, path, "docker ps\n")
+	entries, _ := tail.readNew()
+	if len(entries) != 1 || entries[0].Command != "docker ps" || entries[0].Timestamp == nil || entries[0].Timestamp.Unix() != 1700000005 {
+		t.Errorf("read %+v; want docker ps at 1700000005", entries)
+	}
+}
+
+func TestHistoryTailSkipsRewrittenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".zsh_history")
+	appendToFile(t, path, ": 1700000000:0;git status\n")
+	tail := newHistoryTail("zsh", path, "git status")
+
+	// history -w style rewrite: different content, larger size
+	rewritten := ": 1700000010:0;echo one\n: 1700000011:0;echo two\n: 1700000012:0;echo three\n"
+	if err := os.WriteFile(path, []byte(rewritten), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := tail.readNew(); len(entries) != 0 {
+		t.Errorf("rewritten file produced %q; want it skipped", commandsOf(entries))
+	}
+
+	appendToFile(t, path, ": 1700000013:0;echo four\n")
+	if entries, _ := tail.readNew(); len(entries) != 1 || entries[0].Command != "echo four" {
+		t.Errorf("after a rewrite read %q; want [echo four]", commandsOf(entries))
+	}
+}
+
+func TestAddHistoryEntries(t *testing.T) {
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)
+	freezeTime(t, now)
+	earlier := now.Add(-time.Hour)
+
+	config := cloneDefaultConfig().History
+	tree := NewAVLTree()
+	history := []HistoryEntry{{Command: "git status", Timestamp: &earlier}, {Command: "make test", Timestamp: &earlier}}
+	populateTreeFromHistory(tree, history, config)
+
+	tail := &historyTail{last: lastHistoryCommand(history)}
+	changed := tail.addHistoryEntries(tree, []HistoryEntry{
+		{Command: "git status", Timestamp: &now},
+		{Command: "recaller"}, // Excluded by default
+		{Command: "git push", Timestamp: &now},
+	}, config)
+	if !changed {
+		t.Fatal("addHistoryEntries reported no change")
+	}
+
+	status := tree.findNode("git status").Value
+	if status.Frequency != 2 || !status.Timestamp.Equal(now) {
+		t.Errorf("git status = %d uses, last %v; want 2 uses, last %v", status.Frequency, status.Timestamp, now)
+	}
+	if status.Related["make test"] != 2 || status.Related["git push"] != 1 {
+		t.Errorf("git status related = %v", status.Related)
+	}
+	push := tree.findNode("git push")
+	if push == nil || push.Value.Frequency != 1 {
+		t.Fatalf("git push not added: %+v", push)
+	}
+	if tree.findNode("recaller") != nil {
+		t.Error("excluded command was added")
+	}
+	if tail.last != "git push" {
+		t.Errorf("last = %q; want git push", tail.last)
+	}
+}
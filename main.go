@@ -15,16 +15,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func main() {
+	configureOutput(os.Args[1:])
+
 	asciiLogo := `
 ██████╗ ███████╗ ██████╗ █████╗ ██╗     ██╗     ███████╗██████╗
 ██╔══██╗██╔════╝██╔════╝██╔══██╗██║     ██║     ██╔════╝██╔══██╗
@@ -38,7 +45,10 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 
 `
 
-	asciiLogo = fmt.Sprintf(asciiLogo, Green, version, Reset)
+	asciiLogo = plainText(fmt.Sprintf(asciiLogo, Green, version, Reset))
+	if !bannerEnabled(os.Args[1:]) {
+		asciiLogo = fmt.Sprintf("Recaller %s\n", version)
+	}
 
 	var cmdRun = &cobra.Command{
 		Use:   "run",
@@ -46,14 +56,7 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Run command opens Recaller UI with search from history`),
 		Args:  cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
-			// Parse the command-line flags
-			helpCache := NewOptimizedHelpCache()
-
-			tree := NewAVLTree()
-			if err := readHistoryAndPopulateTree(tree); err != nil {
-				log.Fatalf("Error reading history: %v", err)
-			}
-			run(tree, helpCache)
+			launchHistorySearch()
 		},
 	}
 
@@ -67,36 +70,183 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 		},
 	}
 
+	var cmdHelp = &cobra.Command{
+		Use:   "help [command]",
+		Short: "Show documentation for a shell command or help for a recaller command",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Print the documentation recaller shows in its help pane for a shell command, e.g. 'recaller help git status'. Pass --debug to list which help strategies were tried and why each failed. Recaller's own commands show their usage as before. Put '--' before commands whose arguments look like flags.`),
+		Run: func(cmd *cobra.Command, args []string) {
+			root := cmd.Root()
+			if len(args) == 0 {
+				root.Help()
+				return
+			}
+			if target, _, err := root.Find(args); err == nil && target != root {
+				target.Help()
+				return
+			}
+
+			config, err := LoadConfig()
+			if err != nil {
+				config = cloneDefaultConfig()
+			}
+			configureHelpManager(config.Help)
+			debug, _ := cmd.Flags().GetBool("debug")
+			if !debug {
+				if text, ok := cachedCommandHelp(sharedHelpCache(config.Help), args); ok {
+					fmt.Println(text)
+					return
+				}
+			}
+
+			attempts, result, err := traceCommandHelp(context.Background(), args)
+			if debug {
+				cliPrintf("%s", formatHelpTrace(strings.Join(args, " "), attempts, result, err))
+				return
+			}
+			if err != nil {
+				cliFprintf(os.Stderr, "❌ %s\n", commandHelpFailureText(args, err))
+				os.Exit(1)
+			}
+			hc := sharedHelpCache(config.Help)
+			CacheHelpPageWithSource(hc, strings.Join(args, " "), result.Text, result.Invocation)
+			saveHelpDiskCache(hc, config.Help)
+			fmt.Println(result.Text)
+		},
+	}
+
+	cmdHelp.Flags().Bool("debug", false, "show which help strategies were tried and why each failed")
+
+	var cmdHelpWarm = &cobra.Command{
+		Use:   "warm",
+		Short: "Fetch help for your most used commands into the disk cache",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Look up help for your top-N most frequent commands and save it in the help disk cache (help.disk_cache), so the help pane shows it at once from the first launch. Run it from cron or your shell startup to keep documentation ready.`),
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+			top, _ := cmd.Flags().GetInt("top")
+			if err := runHelpWarm(config, top); err != nil {
+				cliFprintf(os.Stderr, "❌ %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdHelpWarm.Flags().Int("top", 50, "number of most used commands to fetch help for (0 for all)")
+
 	var cmdHistory = &cobra.Command{
 		Use:   "history",
 		Short: "Fetch history sorted by time and frequency. Pass a string to find a match. Ex: recaller history s3api",
-		Long:  fmt.Sprintf("%s\n%s", asciiLogo, "Suggest list of past %d most frequently used commands"),
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Print past commands ranked by frequency and recency, best first. Use --match to filter them and --top to cap the list (default: history.default_top, 20; 0 prints every match). --sort orders them by recency, frequency or alpha instead of score, and --reverse prints the kept commands last to first.`),
 		Args:  cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
-			// Parse the command-line flags
+			// Load configuration for history parsing and fuzzy search
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			if raw, _ := cmd.Flags().GetBool("raw"); raw {
+				history, err := readHistoryEntries(config.History)
+				if err != nil {
+					log.Fatalf("Error reading history: %v", err)
+				}
+				if err := writeRawHistory(os.Stdout, history); err != nil {
+					log.Fatalf("Error writing history: %v", err)
+				}
+				return
+			}
+
+			order := historyOrder{sortBy: cmd.Flag("sort").Value.String()}
+			order.reverse, _ = cmd.Flags().GetBool("reverse")
+			if !slices.Contains(historySortKeys, order.sortBy) {
+				cliFprintf(os.Stderr, "❌ --sort must be one of: %s\n", strings.Join(historySortKeys, ", "))
+				os.Exit(1)
+			}
+
 			tree := NewAVLTree()
-			if err := readHistoryAndPopulateTree(tree); err != nil {
+			if err := readHistoryAndPopulateTree(tree, config.History); err != nil {
 				log.Fatalf("Error reading history: %v", err)
 			}
 
-			// Load configuration for fuzzy search
+			top := config.History.DefaultTop
+			if cmd.Flags().Changed("top") {
+				top, _ = cmd.Flags().GetInt("top")
+			}
+
+			res := getSuggestions(cmd.Flag("match").Value.String(), tree, config.History, top, order)
+			fmt.Println(strings.Join(res, "\n"))
+		},
+	}
+
+	cmdHistory.Flags().String("match", "", "match string prefix to look in history")
+	cmdHistory.Flags().Int("top", defaultConfig.History.DefaultTop, "number of commands to print (0 for all)")
+	cmdHistory.Flags().String("sort", "score", "order commands by "+strings.Join(historySortKeys, ", "))
+	cmdHistory.Flags().Bool("reverse", false, "print the commands in reverse order, e.g. oldest first with --sort recency")
+	// For debugging the history parsers and attaching to bug reports
+	cmdHistory.Flags().Bool("raw", false, "print the parsed history entries as JSON without ranking them")
+	cmdHistory.Flags().MarkHidden("raw")
+
+	var cmdSearch = &cobra.Command{
+		Use:   "search [query]",
+		Short: "Search history and indexed files together",
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Search shell history and the filesystem index with one query and print a merged list, tagged by type (🔧 command, 📄 file, 📁 directory). Files are included when filesystem search is enabled; --dirs-only or --files-only print just those paths. Start the query with '>' to search only commands or '@' to search only files (search.command_sigil, search.file_sigil). Without a query, or with --interactive, the results are listed in a UI where Enter copies, sends or runs a command (history.enter_action) and opens a file or directory.`),
+		Args:  cobra.ArbitraryArgs,
+		Run: func(cmd *cobra.Command, args []string) {
 			config, err := LoadConfig()
 			if err != nil {
 				log.Printf("Failed to load configuration: %v. Using default settings.", err)
-				config = &Config{History: HistoryConfig{EnableFuzzing: true}}
+				config = cloneDefaultConfig()
 			}
 
-			res := getSuggestions(cmd.Flag("match").Value.String(), tree, config.History.EnableFuzzing)
-			fmt.Println(strings.Join(res, "\n"))
+			tree := NewAVLTree()
+			if err := readHistoryAndPopulateTree(tree, config.History); err != nil {
+				log.Printf("Error reading history: %v", err)
+			}
+
+			var fsIndexer *FilesystemIndexer
+			if config.Filesystem.Enabled {
+				fsIndexer = NewFilesystemIndexer(config.Filesystem)
+				if err := fsIndexer.LoadOrCreateIndex(false); err != nil {
+					log.Printf("Failed to load filesystem index: %v", err)
+					fsIndexer = nil
+				}
+			}
+
+			query := strings.Join(args, " ")
+			if interactive, _ := cmd.Flags().GetBool("interactive"); interactive || query == "" {
+				if !isTerminal(os.Stdin) {
+					cliPrintf("❌ A query is needed when not running in a terminal: recaller search <query>\n")
+					return
+				}
+				if err := runCombinedSearch(tree, fsIndexer, config, query, pathFilterFlag(cmd)); err != nil {
+					cliFprintf(os.Stderr, "❌ %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			limit, _ := cmd.Flags().GetInt("limit")
+			for _, result := range SearchAll(tree, fsIndexer, query, config, pathFilterFlag(cmd), limit) {
+				fmt.Printf("%s %s\n", result.Tag(), result.Text)
+			}
 		},
 	}
 
-	cmdHistory.Flags().String("match", "", "match string prefix to look in history")
+	cmdSearch.Flags().BoolP("interactive", "i", false, "list the results in a UI where Enter acts on each, as when no query is given")
+	cmdSearch.Flags().Int("limit", 20, "maximum number of results (0 for all)")
+	cmdSearch.Flags().Bool("dirs-only", false, "print only indexed directories, like the Dirs filter (Ctrl+T) in 'recaller fs'")
+	cmdSearch.Flags().Bool("files-only", false, "print only indexed files, like the Files filter (Ctrl+T) in 'recaller fs'")
+	cmdSearch.MarkFlagsMutuallyExclusive("dirs-only", "files-only")
 
 	var cmdFs = &cobra.Command{
 		Use:   "fs",
 		Short: "Filesystem search commands",
-		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Launch filesystem search UI using existing index, or use subcommands to manage the index. Use 'recaller fs index [path]' to index directories first.`),
+		Long:  fmt.Sprintf("%s\n%s", asciiLogo, `Launch filesystem search UI using existing index, or use subcommands to manage the index. Use 'recaller fs index [path]' to index directories first. Tracked paths are re-indexed on launch once the index is older than filesystem.index_cache_duration_hours; --no-refresh skips that.`),
 		Run: func(cmd *cobra.Command, args []string) {
 			// Load configuration
 			config, err := LoadConfig()
@@ -105,11 +255,19 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 				config = cloneDefaultConfig()
 			}
 
+			// Offer guided setup once, before a config file has been written
+			if !config.Filesystem.Enabled && !configFileExists() && isTerminal(os.Stdin) {
+				if err := runFilesystemSetup(config, os.Stdin, os.Stdout); err != nil {
+					cliPrintf("❌ Filesystem setup failed: %v\n", err)
+				}
+				return
+			}
+
 			if !config.Filesystem.Enabled {
-				fmt.Printf("❌ Filesystem search is disabled. Enable it in configuration:\n")
-				fmt.Printf("Edit ~/.recaller.yaml and set:\n")
-				fmt.Printf("filesystem:\n  enabled: true\n\n")
-				fmt.Printf("Or run: recaller settings list\n")
+				cliPrintf("❌ Filesystem search is disabled. Enable it in configuration:\n")
+				cliPrintf("Edit %s and set:\n", displayConfigPath())
+				cliPrintf("filesystem:\n  enabled: true\n\n")
+				cliPrintf("Or run: recaller fs setup\n")
 				return
 			}
 
@@ -118,38 +276,121 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 
 			// Load existing index
 			if err := fsIndexer.LoadOrCreateIndex(!config.Quiet); err != nil {
-				fmt.Printf("❌ Failed to load filesystem index: %v\n", err)
-				fmt.Printf("💡 Run 'recaller fs index [path]' to create an index first.\n")
+				cliPrintf("❌ Failed to load filesystem index: %v\n", err)
+				cliPrintf("💡 Run 'recaller fs index [path]' to create an index first.\n")
 				return
 			}
 
 			// Check if index has any data
 			if !fsIndexer.HasIndexedFiles() {
-				fmt.Printf("📂 No files found in index.\n")
-				fmt.Printf("💡 Run 'recaller fs index [path]' to index directories first.\n")
+				cliPrintf("📂 No files found in index.\n")
+				cliPrintf("💡 Run 'recaller fs index [path]' to index directories first.\n")
 				return
 			}
 
-			// Auto re-index existing paths to discover new files
-			if len(fsIndexer.GetRootPaths()) > 0 {
-				if err := fsIndexer.RefreshIndex(!config.Quiet, false); err != nil {
-					log.Printf("Warning: Re-indexing completed with errors: %v", err)
+			// Auto re-index existing paths to discover new files, unless the
+			// index is newer than filesystem.index_cache_duration_hours
+			noRefresh, _ := cmd.Flags().GetBool("no-refresh")
+			if len(fsIndexer.GetRootPaths()) > 0 && !noRefresh {
+				if fsIndexer.RefreshDue() {
+					if err := fsIndexer.RefreshIndex(!config.Quiet, false); err != nil {
+						log.Printf("Warning: Re-indexing completed with errors: %v", err)
+					}
+				} else if !config.Quiet {
+					cliPrintf("⏭️  Index is up to date (last indexed %s); run 'recaller fs refresh' to re-index now\n", fsIndexer.LastIndexed().Format("2006-01-02 15:04"))
 				}
 			}
 
 			// Show index statistics
-			fmt.Printf("📊 %s\n", fsIndexer.GetIndexStats())
+			cliPrintf("📊 %s\n", fsIndexer.GetIndexStats())
+
+			var scope string
+			if scopeFlag, _ := cmd.Flags().GetString("scope"); scopeFlag != "" {
+				scopes := absolutePaths([]string{scopeFlag})
+				if len(scopes) == 0 {
+					cliPrintf("❌ Cannot resolve scope %s\n", scopeFlag)
+					return
+				}
+				scope = scopes[0]
+			}
 
 			// Launch filesystem search UI
-			fmt.Printf("🚀 Launching filesystem search UI...\n")
-			runFilesystemSearch(fsIndexer, config)
+			cliPrintf("🚀 Launching filesystem search UI...\n")
+			runFilesystemSearch(fsIndexer, config, scope)
+		},
+	}
+
+	cmdFs.Flags().Bool("no-refresh", false, "skip re-indexing the tracked paths before launching")
+	cmdFs.Flags().String("scope", "", "only search files under this directory (\".\" for the current one); <ctrl+d> in the UI toggles a current-directory scope")
+
+	var cmdFsSetup = &cobra.Command{
+		Use:   "setup",
+		Short: "Enable filesystem search and build the first index",
+		Long:  `Interactively enable filesystem search, pick directories to index (~/Documents, ~/Projects, current directory), save them to the config file ($XDG_CONFIG_HOME/recaller/config.yaml, ~/.config/recaller/config.yaml by default) and build the initial index.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			if err := runFilesystemSetup(config, os.Stdin, os.Stdout); err != nil {
+				cliPrintf("❌ Filesystem setup failed: %v\n", err)
+			}
+		},
+	}
+
+	var cmdFsCd = &cobra.Command{
+		Use:   "cd <query>",
+		Short: "Print the best matching indexed directory for shell cd",
+		Long: `Print the top-ranked indexed directory matching the query and record the visit, so a shell function can jump to it:
+
+  rcd() { local dir; dir="$(recaller fs cd "$@")" && cd "$dir"; }
+
+Exits with status 1 when no existing directory matches.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			// Stdout carries only the directory; everything else goes to stderr
+			if !config.Filesystem.Enabled {
+				cliFprintf(os.Stderr, "❌ Filesystem search is disabled. Run: recaller fs setup\n")
+				os.Exit(1)
+			}
+
+			fsIndexer := NewFilesystemIndexer(config.Filesystem)
+			if err := fsIndexer.LoadOrCreateIndex(false); err != nil {
+				cliFprintf(os.Stderr, "❌ Failed to load filesystem index: %v\n", err)
+				os.Exit(1)
+			}
+
+			query := strings.Join(args, " ")
+			for _, match := range fsIndexer.SearchDirectories(query, config.History.EnableFuzzing) {
+				if info, err := os.Stat(match.Path); err != nil || !info.IsDir() {
+					continue
+				}
+
+				fsIndexer.AddPath(match.Path, time.Now(), true)
+				if err := fsIndexer.PersistIndex(false); err != nil {
+					log.Printf("Failed to persist index: %v", err)
+				}
+				fmt.Println(match.Path)
+				return
+			}
+
+			cliFprintf(os.Stderr, "📂 No indexed directory matches %q\n", query)
+			os.Exit(1)
 		},
 	}
 
 	var cmdFsIndex = &cobra.Command{
 		Use:   "index [path1] [path2] ...",
 		Short: "Index directories for filesystem search",
-		Long:  `Index one or more directories for filesystem search without launching the UI. Optional paths to index (defaults to current directory if none provided).`,
+		Long:  `Index one or more directories for filesystem search without launching the UI. Optional paths to index (defaults to current directory if none provided). --preset dev indexes ~/Projects, ~/src, ~/go/src, ~/code and the current directory, and --preset docs indexes ~/Documents, ~/Desktop, ~/Notes and ~/Dropbox; directories that do not exist are skipped.`,
 		Args:  cobra.ArbitraryArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Load configuration
@@ -160,10 +401,10 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 			}
 
 			if !config.Filesystem.Enabled {
-				fmt.Printf("❌ Filesystem search is disabled. Enable it in configuration:\n")
-				fmt.Printf("Edit ~/.recaller.yaml and set:\n")
-				fmt.Printf("filesystem:\n  enabled: true\n\n")
-				fmt.Printf("Or run: recaller settings list\n")
+				cliPrintf("❌ Filesystem search is disabled. Enable it in configuration:\n")
+				cliPrintf("Edit %s and set:\n", displayConfigPath())
+				cliPrintf("filesystem:\n  enabled: true\n\n")
+				cliPrintf("Or run: recaller fs setup\n")
 				return
 			}
 
@@ -172,6 +413,19 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 			if len(args) > 0 {
 				pathsToIndex = args
 			}
+			if preset, _ := cmd.Flags().GetString("preset"); preset != "" {
+				presetDirs, err := presetDirectories(preset)
+				if err != nil {
+					cliPrintf("❌ %v\n", err)
+					return
+				}
+				if len(presetDirs) == 0 {
+					cliPrintf("❌ None of the '%s' preset directories exist\n", preset)
+					return
+				}
+				cliPrintf("📂 Preset '%s' found %d directories\n", preset, len(presetDirs))
+				pathsToIndex = append(args, presetDirs...)
+			}
 
 			// Process each path: expand tilde, convert to absolute path, and verify existence
 			var validPaths []string
@@ -187,13 +441,13 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 				// Convert to absolute path
 				absPath, err := filepath.Abs(pathToIndex)
 				if err != nil {
-					fmt.Printf("❌ Invalid path: %s\n", pathToIndex)
+					cliPrintf("❌ Invalid path: %s\n", pathToIndex)
 					continue
 				}
 
 				// Verify path exists
 				if _, err := os.Stat(absPath); os.IsNotExist(err) {
-					fmt.Printf("❌ Path does not exist: %s\n", absPath)
+					cliPrintf("❌ Path does not exist: %s\n", absPath)
 					continue
 				}
 
@@ -201,7 +455,7 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 			}
 
 			if len(validPaths) == 0 {
-				fmt.Printf("❌ No valid paths to index\n")
+				cliPrintf("❌ No valid paths to index\n")
 				return
 			}
 
@@ -215,23 +469,23 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 
 			// Index the specified directories with progress
 			if len(validPaths) == 1 {
-				fmt.Printf("🔍 Starting filesystem indexing for: %s\n", validPaths[0])
+				cliPrintf("🔍 Starting filesystem indexing for: %s\n", validPaths[0])
 				if err := fsIndexer.IndexDirectoryWithProgress(validPaths[0], true); err != nil {
 					if err.Error() == "max indexed files limit reached" {
-						fmt.Printf("⚠️  Reached maximum file limit (%d files)\n", config.Filesystem.MaxIndexedFiles)
+						cliPrintf("⚠️  Reached maximum file limit (%d files)\n", config.Filesystem.MaxIndexedFiles)
 					} else {
 						log.Printf("Warning: Indexing completed with errors: %v", err)
 					}
 				}
 			} else {
-				fmt.Printf("🔍 Starting filesystem indexing for %d directories:\n", len(validPaths))
+				cliPrintf("🔍 Starting filesystem indexing for %d directories:\n", len(validPaths))
 				for i, path := range validPaths {
-					fmt.Printf("  %d. %s\n", i+1, path)
+					cliPrintf("  %d. %s\n", i+1, path)
 				}
 				fmt.Println()
 				if err := fsIndexer.IndexDirectoriesWithProgress(validPaths, true); err != nil {
 					if err.Error() == "max indexed files limit reached" {
-						fmt.Printf("⚠️  Reached maximum file limit (%d files)\n", config.Filesystem.MaxIndexedFiles)
+						cliPrintf("⚠️  Reached maximum file limit (%d files)\n", config.Filesystem.MaxIndexedFiles)
 					} else {
 						log.Printf("Warning: Indexing completed with errors: %v", err)
 					}
@@ -239,19 +493,21 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 			}
 
 			// Persist the index
-			fmt.Printf("\n💾 Saving index to disk...")
+			cliPrintf("\n💾 Saving index to disk...")
 			if err := fsIndexer.PersistIndex(!config.Quiet); err != nil {
 				log.Printf("Warning: Failed to persist index: %v", err)
 			} else {
-				fmt.Printf(" ✅\n")
+				cliPrintf(" ✅\n")
 			}
 
 			// Show index statistics
-			fmt.Printf("\n%s\n", fsIndexer.GetIndexStats())
-			fmt.Printf("\n💡 Run 'recaller fs' to launch the search UI.\n")
+			cliPrintf("\n%s\n", fsIndexer.GetIndexStats())
+			cliPrintf("\n💡 Run 'recaller fs' to launch the search UI.\n")
 		},
 	}
 
+	cmdFsIndex.Flags().String("preset", "", "also index a predefined set of common directories: "+strings.Join(indexPresetNames(), ", "))
+
 	var cmdFsClean = &cobra.Command{
 		Use:   "clean [path]",
 		Short: "Clean filesystem index",
@@ -266,7 +522,7 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 			}
 
 			if !config.Filesystem.Enabled {
-				fmt.Printf("❌ Filesystem search is disabled. Enable it first.\n")
+				cliPrintf("❌ Filesystem search is disabled. Enable it first.\n")
 				return
 			}
 
@@ -275,15 +531,15 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 
 			// Load existing index
 			if err := fsIndexer.LoadOrCreateIndex(!config.Quiet); err != nil {
-				fmt.Printf("❌ Failed to load filesystem index: %v\n", err)
+				cliPrintf("❌ Failed to load filesystem index: %v\n", err)
 				return
 			}
 
 			// Get current index stats
 			initialSize, _ := fsIndexer.GetIndexFileSize()
-			fmt.Printf("📊 Current index: %s\n", fsIndexer.GetIndexStats())
+			cliPrintf("📊 Current index: %s\n", fsIndexer.GetIndexStats())
 			if initialSize > 0 {
-				fmt.Printf("💾 Index file size: %.2f KB\n\n", float64(initialSize)/1024)
+				cliPrintf("💾 Index file size: %.2f KB\n\n", float64(initialSize)/1024)
 			}
 
 			// Parse flags
@@ -294,29 +550,30 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 
 			if clearAll {
 				if dryRun {
-					fmt.Printf("🔍 [DRY RUN] Would clear entire index (%d entries)\n", len(fsIndexer.pathRecords))
+					cliPrintf("🔍 [DRY RUN] Would clear entire index (%d entries)\n", len(fsIndexer.pathRecords))
 					return
 				}
 
-				fmt.Printf("⚠️  This will clear the entire filesystem index. Continue? [y/N]: ")
+				cliPrintf("⚠️  This will clear the entire filesystem index. Continue? [y/N]: ")
 				var response string
 				fmt.Scanln(&response)
 				if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
-					fmt.Printf("❌ Operation cancelled.\n")
+					cliPrintf("❌ Operation cancelled.\n")
 					return
 				}
 
 				if err := fsIndexer.ClearIndex(); err != nil {
-					fmt.Printf("❌ Failed to clear index: %v\n", err)
+					cliPrintf("❌ Failed to clear index: %v\n", err)
 					return
 				}
 
 				if err := fsIndexer.PersistIndex(!config.Quiet); err != nil {
-					fmt.Printf("❌ Failed to persist cleared index: %v\n", err)
+					cliPrintf("❌ Failed to persist cleared index: %v\n", err)
 					return
 				}
 
-				fmt.Printf("✅ Index cleared successfully!\n")
+				cliPrintf("✅ Index cleared successfully!\n")
+				cliPrintf("💡 Changed your mind? Run 'recaller fs restore' to bring it back.\n")
 				return
 			}
 
@@ -344,54 +601,55 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 
 			// Perform dry run first if requested
 			if dryRun {
-				fmt.Printf("🔍 [DRY RUN] Analyzing what would be cleaned...\n")
+				cliPrintf("🔍 [DRY RUN] Analyzing what would be cleaned...\n")
 				options.ShowProgress = false
 			} else {
-				fmt.Printf("🧹 Starting cleanup...\n")
+				cliPrintf("🧹 Starting cleanup...\n")
 			}
 
 			// Run cleanup
 			stats, err := fsIndexer.CleanupIndex(options)
 			if err != nil {
-				fmt.Printf("❌ Cleanup failed: %v\n", err)
+				cliPrintf("❌ Cleanup failed: %v\n", err)
 				return
 			}
 
 			// Display results
-			fmt.Printf("\n📈 Cleanup Results:\n")
-			fmt.Printf("   Total entries: %d\n", stats.TotalEntries)
+			cliPrintf("\n📈 Cleanup Results:\n")
+			cliPrintf("   Total entries: %d\n", stats.TotalEntries)
 			if stats.StaleFiles > 0 {
-				fmt.Printf("   Stale files removed: %d\n", stats.StaleFiles)
+				cliPrintf("   Stale files removed: %d\n", stats.StaleFiles)
 			}
 			if stats.OldFiles > 0 {
-				fmt.Printf("   Old entries removed: %d\n", stats.OldFiles)
+				cliPrintf("   Old entries removed: %d\n", stats.OldFiles)
 			}
 			if pathPrefix != "" {
-				fmt.Printf("   Path-filtered entries removed: %d\n", stats.RemovedEntries)
+				cliPrintf("   Path-filtered entries removed: %d\n", stats.RemovedEntries)
 			}
-			fmt.Printf("   Total removed: %d entries\n", stats.RemovedEntries)
-			fmt.Printf("   Memory freed: %.2f KB\n", stats.FreedKB)
+			cliPrintf("   Total removed: %d entries\n", stats.RemovedEntries)
+			cliPrintf("   Memory freed: %.2f KB\n", stats.FreedKB)
 
 			if !dryRun && stats.RemovedEntries > 0 {
 				// Persist changes
-				fmt.Printf("\n💾 Saving cleaned index...")
+				cliPrintf("\n💾 Saving cleaned index...")
 				if err := fsIndexer.PersistIndex(!config.Quiet); err != nil {
-					fmt.Printf(" ❌ Failed: %v\n", err)
+					cliPrintf(" ❌ Failed: %v\n", err)
 				} else {
-					fmt.Printf(" ✅\n")
+					cliPrintf(" ✅\n")
 
 					// Show new stats
 					newSize, _ := fsIndexer.GetIndexFileSize()
-					fmt.Printf("\n📊 Updated index: %s\n", fsIndexer.GetIndexStats())
+					cliPrintf("\n📊 Updated index: %s\n", fsIndexer.GetIndexStats())
 					if initialSize > 0 && newSize > 0 {
 						freed := float64(initialSize-newSize) / 1024
-						fmt.Printf("💾 Disk space freed: %.2f KB\n", freed)
+						cliPrintf("💾 Disk space freed: %.2f KB\n", freed)
 					}
+					cliPrintf("💡 Removed too much? Run 'recaller fs restore' to undo.\n")
 				}
 			} else if dryRun {
-				fmt.Printf("\n💡 Run without --dry-run to actually perform the cleanup.\n")
+				cliPrintf("\n💡 Run without --dry-run to actually perform the cleanup.\n")
 			} else {
-				fmt.Printf("\n✅ No cleanup needed - index is already clean!\n")
+				cliPrintf("\n✅ No cleanup needed - index is already clean!\n")
 			}
 		},
 	}
@@ -405,7 +663,7 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 	var cmdFsRefresh = &cobra.Command{
 		Use:   "refresh",
 		Short: "Re-index all tracked paths to discover new files",
-		Long:  `Re-index all previously indexed directories to discover new files and directories without launching the search UI. This is useful for manually updating your index.`,
+		Long:  `Re-index all previously indexed directories to discover new files and directories without launching the search UI. This is useful for manually updating your index. Roots under filesystem.no_auto_refresh_paths are skipped; update one with 'recaller fs index <path>'.`,
 		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Load configuration
@@ -416,10 +674,10 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 			}
 
 			if !config.Filesystem.Enabled {
-				fmt.Printf("❌ Filesystem search is disabled. Enable it in configuration:\n")
-				fmt.Printf("Edit ~/.recaller.yaml and set:\n")
-				fmt.Printf("filesystem:\n  enabled: true\n\n")
-				fmt.Printf("Or run: recaller settings list\n")
+				cliPrintf("❌ Filesystem search is disabled. Enable it in configuration:\n")
+				cliPrintf("Edit %s and set:\n", displayConfigPath())
+				cliPrintf("filesystem:\n  enabled: true\n\n")
+				cliPrintf("Or run: recaller fs setup\n")
 				return
 			}
 
@@ -428,25 +686,326 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 
 			// Load existing index
 			if err := fsIndexer.LoadOrCreateIndex(!config.Quiet); err != nil {
-				fmt.Printf("❌ Failed to load filesystem index: %v\n", err)
-				fmt.Printf("💡 Run 'recaller fs index [path]' to create an index first.\n")
+				cliPrintf("❌ Failed to load filesystem index: %v\n", err)
+				cliPrintf("💡 Run 'recaller fs index [path]' to create an index first.\n")
 				return
 			}
 
 			// Refresh the index using the shared function
 			if err := fsIndexer.RefreshIndex(!config.Quiet, true); err != nil {
 				if err.Error() == "no tracked paths found in index" {
-					fmt.Printf("📂 No tracked paths found in index.\n")
-					fmt.Printf("💡 Run 'recaller fs index [path]' to index directories first.\n")
+					cliPrintf("📂 No tracked paths found in index.\n")
+					cliPrintf("💡 Run 'recaller fs index [path]' to index directories first.\n")
 				} else if err.Error() == "max indexed files limit reached" {
-					fmt.Printf("⚠️  Reached maximum file limit (%d files)\n", config.Filesystem.MaxIndexedFiles)
+					cliPrintf("⚠️  Reached maximum file limit (%d files)\n", config.Filesystem.MaxIndexedFiles)
 				} else {
-					fmt.Printf("❌ Refresh failed: %v\n", err)
+					cliPrintf("❌ Refresh failed: %v\n", err)
+				}
+				return
+			}
+
+			cliPrintf("✔️ Refresh completed successfully!\n")
+		},
+	}
+
+	var cmdFsRoots = &cobra.Command{
+		Use:   "roots",
+		Short: "List the directories tracked as index roots",
+		Long:  `List the root directories the filesystem index tracks, which 'recaller fs refresh' re-indexes, marking any that no longer exist and counting the indexed entries under each. --remove untracks a root; add --purge to also drop its indexed entries.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Load configuration
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			if !config.Filesystem.Enabled {
+				cliPrintf("❌ Filesystem search is disabled. Enable it first.\n")
+				return
+			}
+
+			// Load existing index
+			fsIndexer := NewFilesystemIndexer(config.Filesystem)
+			if err := fsIndexer.LoadOrCreateIndex(!config.Quiet); err != nil {
+				cliPrintf("❌ Failed to load filesystem index: %v\n", err)
+				return
+			}
+
+			if remove, _ := cmd.Flags().GetString("remove"); remove != "" {
+				if strings.HasPrefix(remove, "~/") {
+					if homeDir, err := os.UserHomeDir(); err == nil {
+						remove = filepath.Join(homeDir, remove[2:])
+					}
+				}
+				purge, _ := cmd.Flags().GetBool("purge")
+				removed, err := fsIndexer.RemoveRootPath(remove, purge)
+				if err != nil {
+					cliPrintf("❌ %v\n", err)
+					cliPrintf("💡 Run 'recaller fs roots' to list the tracked roots.\n")
+					return
+				}
+				if err := fsIndexer.PersistIndex(!config.Quiet); err != nil {
+					cliPrintf("❌ Failed to save index: %v\n", err)
+					return
+				}
+				cliPrintf("✅ Stopped tracking %s\n", remove)
+				if purge {
+					cliPrintf("🗑️  Removed %d indexed entries\n", removed)
 				}
 				return
 			}
 
-			fmt.Printf("✔️ Refresh completed successfully!\n")
+			roots := fsIndexer.RootPathStats()
+			if len(roots) == 0 {
+				cliPrintf("📂 No tracked paths found in index.\n")
+				cliPrintf("💡 Run 'recaller fs index [path]' to index directories first.\n")
+				return
+			}
+			cliPrintf("📂 Tracked index roots (%d):\n", len(roots))
+			for _, root := range roots {
+				if root.Exists {
+					cliPrintf("  ✅ %s (%d entries)\n", root.Path, root.Records)
+				} else {
+					cliPrintf("  ❌ %s (%d entries, missing on disk)\n", root.Path, root.Records)
+				}
+			}
+		},
+	}
+
+	cmdFsRoots.Flags().String("remove", "", "stop tracking this root directory")
+	cmdFsRoots.Flags().Bool("purge", false, "with --remove, also drop the root's indexed entries")
+
+	var cmdFsStats = &cobra.Command{
+		Use:   "stats",
+		Short: "Show what the filesystem index is made of",
+		Long:  `Show the size of the filesystem index, the size it would reach at max_indexed_files next to the free space on its volume (with a warning when that could fill the disk), and a breakdown of the indexed files by extension, with the number of files and their total size. Use it to spot file types worth adding to the ignore patterns, such as thousands of generated .map files. --top limits the table to the N most common extensions (0 lists them all).`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Load configuration
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			if !config.Filesystem.Enabled {
+				cliPrintf("❌ Filesystem search is disabled. Enable it first.\n")
+				return
+			}
+
+			top, _ := cmd.Flags().GetInt("top")
+			if top < 0 {
+				cliPrintf("❌ --top must be 0 or more\n")
+				return
+			}
+
+			// Load existing index
+			fsIndexer := NewFilesystemIndexer(config.Filesystem)
+			if err := fsIndexer.LoadOrCreateIndex(!config.Quiet); err != nil {
+				cliPrintf("❌ Failed to load filesystem index: %v\n", err)
+				return
+			}
+
+			cliPrintf("📊 %s\n", fsIndexer.GetIndexStats())
+			printIndexCapacity(os.Stdout, fsIndexer.IndexCapacity())
+			extensions := fsIndexer.ExtensionStats()
+			if len(extensions) == 0 {
+				cliPrintf("💡 Run 'recaller fs index [path]' to index directories first.\n")
+				return
+			}
+			printExtensionStats(os.Stdout, extensions, top)
+		},
+	}
+
+	cmdFsStats.Flags().Int("top", 20, "show only the N most common extensions (0 for all)")
+
+	var cmdFsExport = &cobra.Command{
+		Use:   "export [path]",
+		Short: "Dump the filesystem index as JSON or CSV",
+		Long: `Write every entry of the filesystem index with its path, access count, last access time, directory/hidden/symlink flags and size, for inspection or for use in scripts.
+
+--format picks json (default) or csv. The output goes to stdout unless --out names a file. Pass a path to export only the entries under it.`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			// Stdout may carry the export; everything else goes to stderr
+			if !config.Filesystem.Enabled {
+				cliFprintf(os.Stderr, "❌ Filesystem search is disabled. Enable it first.\n")
+				os.Exit(1)
+			}
+
+			format, _ := cmd.Flags().GetString("format")
+			if !slices.Contains(indexExportFormats, format) {
+				cliFprintf(os.Stderr, "❌ --format must be one of: %s\n", strings.Join(indexExportFormats, ", "))
+				os.Exit(1)
+			}
+
+			var root string
+			if len(args) > 0 {
+				roots := absolutePaths(args[:1])
+				if len(roots) == 0 {
+					cliFprintf(os.Stderr, "❌ Cannot resolve path %s\n", args[0])
+					os.Exit(1)
+				}
+				root = roots[0]
+			}
+
+			fsIndexer := NewFilesystemIndexer(config.Filesystem)
+			if err := fsIndexer.LoadOrCreateIndex(false); err != nil {
+				cliFprintf(os.Stderr, "❌ Failed to load filesystem index: %v\n", err)
+				os.Exit(1)
+			}
+			records := fsIndexer.ExportRecords(root)
+
+			outPath, _ := cmd.Flags().GetString("out")
+			if outPath == "" {
+				if err := writeIndexExport(os.Stdout, records, format); err != nil {
+					cliFprintf(os.Stderr, "❌ Export failed: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			file, err := os.Create(outPath)
+			if err != nil {
+				cliFprintf(os.Stderr, "❌ Failed to create %s: %v\n", outPath, err)
+				os.Exit(1)
+			}
+			err = writeIndexExport(file, records, format)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				cliFprintf(os.Stderr, "❌ Export failed: %v\n", err)
+				os.Exit(1)
+			}
+			cliFprintf(os.Stderr, "✅ Exported %d entries to %s\n", len(records), outPath)
+		},
+	}
+
+	cmdFsExport.Flags().String("format", "json", "output format: json or csv")
+	cmdFsExport.Flags().String("out", "", "write to this file instead of stdout")
+
+	var cmdFsMigrate = &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade an older filesystem index to the current format",
+		Long:  `Load a filesystem index written by an older version of recaller and rewrite it in the current format, preserving all records and tracked root paths. This avoids a full re-index when the index format changes.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Load configuration
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			if !config.Filesystem.Enabled {
+				cliPrintf("❌ Filesystem search is disabled. Enable it first.\n")
+				return
+			}
+
+			// Create filesystem indexer
+			fsIndexer := NewFilesystemIndexer(config.Filesystem)
+
+			fromVersion, err := fsIndexer.MigrateIndex()
+			if err != nil {
+				cliPrintf("❌ Migration failed: %v\n", err)
+				return
+			}
+
+			if fromVersion == IndexFormatVersion {
+				cliPrintf("✅ Index is already in the current format (v%d). Nothing to do.\n", IndexFormatVersion)
+				return
+			}
+
+			cliPrintf("✅ Migrated index from v%d to v%d\n", fromVersion, IndexFormatVersion)
+			cliPrintf("📊 %s\n", fsIndexer.GetIndexStats())
+		},
+	}
+
+	var cmdFsRestore = &cobra.Command{
+		Use:   "restore",
+		Short: "Undo the last index clean by restoring the backup",
+		Long:  `Swap the filesystem index with the backup taken before the last destructive change ('fs clean'). Only the most recent backup is kept; running restore again swaps back.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Load configuration
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			fsIndexer := NewFilesystemIndexer(config.Filesystem)
+			if err := fsIndexer.RestoreBackup(); err != nil {
+				cliPrintf("❌ Restore failed: %v\n", err)
+				return
+			}
+
+			if err := fsIndexer.LoadOrCreateIndex(false); err != nil {
+				cliPrintf("❌ Restored index could not be loaded: %v\n", err)
+				return
+			}
+			cliPrintf("✅ Restored index from backup\n")
+			cliPrintf("📊 %s\n", fsIndexer.GetIndexStats())
+		},
+	}
+
+	var cmdFsDuplicates = &cobra.Command{
+		Use:   "duplicates",
+		Short: "List indexed files with identical contents",
+		Long:  `Group indexed files by content hash and list duplicates, sorted by wasted space. Requires filesystem.hash_contents to be enabled before indexing; run 'recaller fs refresh' after enabling it to hash existing entries.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			// Load configuration
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			if !config.Filesystem.Enabled {
+				cliPrintf("❌ Filesystem search is disabled. Enable it first.\n")
+				return
+			}
+
+			if !config.Filesystem.HashContents {
+				cliPrintf("❌ Content hashing is disabled. Set filesystem.hash_contents: true and run 'recaller fs refresh'.\n")
+				return
+			}
+
+			// Create filesystem indexer
+			fsIndexer := NewFilesystemIndexer(config.Filesystem)
+
+			// Load existing index
+			if err := fsIndexer.LoadOrCreateIndex(!config.Quiet); err != nil {
+				cliPrintf("❌ Failed to load filesystem index: %v\n", err)
+				return
+			}
+
+			groups := fsIndexer.FindDuplicates()
+			if len(groups) == 0 {
+				cliPrintf("✅ No duplicate files found.\n")
+				return
+			}
+
+			var totalWasted int64
+			for _, group := range groups {
+				totalWasted += group.WastedBytes()
+				cliPrintf("\n🗂️  %d copies of %s (%s wasted)\n", len(group.Paths), formatFileSize(group.Size), formatFileSize(group.WastedBytes()))
+				for _, path := range group.Paths {
+					cliPrintf("   %s\n", path)
+				}
+			}
+
+			cliPrintf("\n📊 %d duplicate groups, %s wasted in total\n", len(groups), formatFileSize(totalWasted))
 		},
 	}
 
@@ -459,12 +1018,76 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 		},
 	}
 
+	var cmdSettingsOpen = &cobra.Command{
+		Use:     "open",
+		Aliases: []string{"edit"},
+		Short:   "Open the configuration file in your editor",
+		Long:    "Open the config file ($XDG_CONFIG_HOME/recaller/config.yaml, ~/.config/recaller/config.yaml by default) in $VISUAL or $EDITOR (vi if neither is set), creating it with the defaults if it does not exist, and check it for errors once the editor exits",
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := editConfigFile(os.Stdin, os.Stdout); err != nil {
+				cliFprintf(os.Stderr, "❌ %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
 	var cmdSettings = &cobra.Command{
 		Use:   "settings",
 		Short: "Manage Recaller configuration settings",
 		Long:  "Commands for viewing and managing Recaller configuration",
 	}
 
+	var cmdBench = &cobra.Command{
+		Use:    "bench",
+		Short:  "Measure search latency on your shell history",
+		Long:   "Load the real history tree and time representative queries (prefix and fuzzy), reporting p50/p95 latency. Useful when reporting slowness.",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := LoadConfig()
+			if err != nil {
+				log.Printf("Failed to load configuration: %v. Using default settings.", err)
+				config = cloneDefaultConfig()
+			}
+
+			loadStart := time.Now()
+			tree := NewAVLTree()
+			if err := readHistoryAndPopulateTree(tree, config.History); err != nil {
+				log.Fatalf("Error reading history: %v", err)
+			}
+			loadTime := time.Since(loadStart)
+
+			iterations, _ := cmd.Flags().GetInt("iterations")
+			queries := representativeQueries(tree)
+			if len(queries) == 0 {
+				cliPrintf("❌ History is empty, nothing to benchmark.\n")
+				return
+			}
+
+			cliPrintf("📊 Commands in tree: %d (loaded in %v)\n", tree.Size(), loadTime.Round(time.Microsecond))
+			cliPrintf("⏱️  %d iterations per query\n\n", iterations)
+			cliPrintf("%-22s %-16s %8s %12s %12s\n", "QUERY", "TEXT", "MATCHES", "P50", "P95")
+			for _, r := range runSearchBenchmark(tree, queries, iterations) {
+				cliPrintf("%-22s %-16q %8d %12v %12v\n", r.Name, r.Query, r.Matches, r.P50, r.P95)
+			}
+		},
+	}
+
+	cmdBench.Flags().Int("iterations", 200, "number of times each query is run")
+
+	var cmdDoctor = &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment for problems",
+		Long:  "Check that copying to the clipboard will work and that the config file ($XDG_CONFIG_HOME/recaller/config.yaml, ~/.config/recaller/config.yaml by default) is valid, printing how to fix anything that is not",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !runDoctor(os.Stdout) {
+				os.Exit(1)
+			}
+		},
+	}
+
 	var cmdVersion = &cobra.Command{
 		Use:   "version",
 		Short: "Print Recaller version",
@@ -480,18 +1103,88 @@ Copyright @ Naren Yellavula (Please give us a star ⭐ here: https://github.com/
 		Long:    asciiLogo,
 		Run: func(cmd *cobra.Command, args []string) {
 			// Default to run command when no subcommand is provided
-			helpCache := NewOptimizedHelpCache()
-
-			tree := NewAVLTree()
-			if err := readHistoryAndPopulateTree(tree); err != nil {
-				log.Fatalf("Error reading history: %v", err)
-			}
-			run(tree, helpCache)
+			launchHistorySearch()
 		},
 	}
 
-	cmdSettings.AddCommand(cmdSettingsList)
-	cmdFs.AddCommand(cmdFsIndex, cmdFsClean, cmdFsRefresh)
-	rootCmd.AddCommand(cmdRun, cmdUsage, cmdVersion, cmdHistory, cmdFs, cmdSettings)
+	// Parsed early by configureOutput and bannerEnabled; registered so cobra accepts them
+	rootCmd.PersistentFlags().Bool("plain", false, "Disable colors and emoji in output (NO_COLOR disables colors only)")
+	rootCmd.PersistentFlags().Bool("no-banner", false, "Replace the ASCII logo in help text with a one-line title")
+
+	cmdSettings.AddCommand(cmdSettingsList, cmdSettingsOpen)
+	cmdFs.AddCommand(cmdFsSetup, cmdFsCd, cmdFsIndex, cmdFsClean, cmdFsRefresh, cmdFsRoots, cmdFsStats, cmdFsExport, cmdFsMigrate, cmdFsRestore, cmdFsDuplicates)
+	cmdHelp.AddCommand(cmdHelpWarm)
+	rootCmd.SetHelpCommand(cmdHelp)
+	rootCmd.AddCommand(cmdRun, cmdUsage, cmdVersion, cmdDoctor, cmdHistory, cmdSearch, cmdFs, cmdSettings, cmdBench)
 	rootCmd.Execute()
 }
+
+// launchHistorySearch opens the history search UI. When stdout is not a
+// terminal (e.g. `recaller | head`), it prints ranked history instead. A
+// missing history file opens the UI with instructions instead of failing.
+func launchHistorySearch() {
+	config, err := LoadConfig()
+	if err != nil {
+		log.Printf("Failed to load configuration: %v. Using default settings.", err)
+		config = cloneDefaultConfig()
+	}
+
+	tree := NewAVLTree()
+	history, historyErr := readHistoryEntries(config.History)
+	if historyErr != nil && (!errors.Is(historyErr, os.ErrNotExist) || !isTerminal(os.Stdout)) {
+		cliFprintf(os.Stderr, "❌ Error reading history: %v\n", historyErr)
+		os.Exit(1)
+	}
+	populateTreeFromHistory(tree, history, config.History)
+
+	if !isTerminal(os.Stdout) {
+		fmt.Println(strings.Join(getSuggestions("", tree, config.History, 0, historyOrder{}), "\n"))
+		return
+	}
+
+	var tail *historyTail
+	if config.History.LiveReload {
+		if shell, historyPath, err := currentHistoryFile(); err == nil {
+			tail = newHistoryTail(shell, historyPath, lastHistoryCommand(history))
+		}
+	}
+
+	hc := sharedHelpCache(config.Help)
+	err = run(tree, hc, historyErr, tail)
+	saveHelpDiskCache(hc, config.Help)
+	if err != nil {
+		cliFprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// bannerEnabled reports whether help text should carry the ASCII logo. It is
+// on unless --no-banner is passed or ui.show_banner is false.
+func bannerEnabled(args []string) bool {
+	if hasBoolFlag(args, "no-banner") {
+		return false
+	}
+	config, err := LoadConfig()
+	if err != nil {
+		return true
+	}
+	return config.UI.ShowBanner
+}
+
+// pathFilterFlag returns the filesystem filter mode chosen with --dirs-only
+// or --files-only
+func pathFilterFlag(cmd *cobra.Command) int {
+	if dirsOnly, _ := cmd.Flags().GetBool("dirs-only"); dirsOnly {
+		return filterModeDirs
+	}
+	if filesOnly, _ := cmd.Flags().GetBool("files-only"); filesOnly {
+		return filterModeFiles
+	}
+	return filterModeAll
+}
+
+// isTerminal reports whether f is a terminal. Other character devices, such
+// as /dev/null, are not.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommandNote is a user annotation for a command. Command matches one exact
+// command line; Pattern is a regular expression matched against commands.
+type CommandNote struct {
+	Command string `yaml:"command,omitempty"`
+	Pattern string `yaml:"pattern,omitempty"`
+	Note    string `yaml:"note"`
+}
+
+// CommandNotes is the user-maintained dictionary of notes in notes.yaml:
+//
+//	notes:
+//	  - command: "make deploy ENV=staging"
+//	    note: "this deploys staging"
+//	  - pattern: "^terraform (plan|apply)"
+//	    note: "infra changes, check the workspace first"
+type CommandNotes struct {
+	Notes []CommandNote `yaml:"notes"`
+
+	path     string
+	patterns map[string]*regexp.Regexp
+	// loadErr is why the file could not be read or parsed; the notes are
+	// read-only then, so saving cannot overwrite the user's file
+	loadErr error
+}
+
+// getNotesPath returns $XDG_CONFIG_HOME/recaller/notes.yaml (under
+// ~/.config by default), moving an existing ~/.recaller_notes.yaml there
+// first
+func getNotesPath() (string, error) {
+	return recallerFilePath(os.Getenv, ".recaller_notes.yaml", "XDG_CONFIG_HOME", "notes.yaml", true)
+}
+
+// loadCommandNotes loads the notes file for the UI, logging problems
+// rather than failing so a bad notes file never blocks searching. It must
+// run before ui.Init, as it may also report moving the file.
+func loadCommandNotes() *CommandNotes {
+	path, err := getNotesPath()
+	if err != nil {
+		log.Printf("Failed to locate notes file: %v", err)
+		return nil
+	}
+	notes, err := LoadCommandNotes(path)
+	if err != nil {
+		log.Printf("Notes file %s: %v", path, err)
+	}
+	return notes
+}
+
+// LoadCommandNotes reads the notes file at path. A missing file yields an
+// empty dictionary. A file that cannot be read or parsed yields read-only
+// empty notes along with the error. Notes with invalid patterns are kept (so
+// saving does not drop them) but never match; they are reported in the
+// returned error.
+func LoadCommandNotes(path string) (*CommandNotes, error) {
+	notes := &CommandNotes{path: path, patterns: make(map[string]*regexp.Regexp)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return notes, nil
+	} else if err != nil {
+		notes.loadErr = fmt.Errorf("failed to read notes file: %w", err)
+		return notes, notes.loadErr
+	}
+	if err := yaml.Unmarshal(data, notes); err != nil {
+		notes.Notes = nil
+		notes.loadErr = fmt.Errorf("failed to parse notes file: %w", err)
+		return notes, notes.loadErr
+	}
+
+	var errs []error
+	for _, n := range notes.Notes {
+		if n.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(n.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid note pattern %q: %w", n.Pattern, err))
+			continue
+		}
+		notes.patterns[n.Pattern] = re
+	}
+	return notes, errors.Join(errs...)
+}
+
+// Lookup returns the note for command. An exact command note wins over
+// patterns; patterns are tried in file order.
+func (cn *CommandNotes) Lookup(command string) string {
+	if cn == nil || command == "" {
+		return ""
+	}
+	if note := cn.ExactNote(command); note != "" {
+		return note
+	}
+	for _, n := range cn.Notes {
+		if re := cn.patterns[n.Pattern]; re != nil && re.MatchString(command) {
+			return n.Note
+		}
+	}
+	return ""
+}
+
+// ExactNote returns the note stored for exactly command, ignoring patterns
+func (cn *CommandNotes) ExactNote(command string) string {
+	for _, n := range cn.Notes {
+		if n.Command == command {
+			return n.Note
+		}
+	}
+	return ""
+}
+
+// Set stores note for exactly command, replacing any previous one. An empty
+// note removes it.
+func (cn *CommandNotes) Set(command, note string) {
+	for i, n := range cn.Notes {
+		if n.Command != command {
+			continue
+		}
+		if note == "" {
+			cn.Notes = append(cn.Notes[:i], cn.Notes[i+1:]...)
+		} else {
+			cn.Notes[i].Note = note
+		}
+		return
+	}
+	if note != "" {
+		cn.Notes = append(cn.Notes, CommandNote{Command: command, Note: note})
+	}
+}
+
+// ReadOnly returns why the notes cannot be saved, or nil when they can
+func (cn *CommandNotes) ReadOnly() error {
+	if cn.loadErr == nil {
+		return nil
+	}
+	return fmt.Errorf("notes are read-only until %s is fixed: %w", cn.path, cn.loadErr)
+}
+
+// Save writes the notes back to the file they were loaded from. It refuses
+// to when the file failed to load, as that would erase it.
+func (cn *CommandNotes) Save() error {
+	if err := cn.ReadOnly(); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(cn)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notes: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(cn.path), 0755); err != nil {
+		return fmt.Errorf("failed to create notes directory: %w", err)
+	}
+	if err := os.WriteFile(cn.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write notes file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gizak/termui/v3/widgets"
+)
+
+func TestCommandNotes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.yaml")
+	content := `notes:
+  - pattern: "^make deploy"
+    note: "deploys something"
+  - command: "make deploy ENV=staging"
+    note: "this deploys staging"
+  - pattern: "(unclosed"
+    note: "broken"
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := LoadCommandNotes(path)
+	if err == nil {
+		t.Error("expected an error for the invalid pattern")
+	}
+
+	tests := map[string]string{
+		"make deploy ENV=staging": "this deploys staging", // exact beats pattern
+		"make deploy ENV=prod":    "deploys something",
+		"(unclosed":               "",
+		"ls":                      "",
+	}
+	for command, want := range tests {
+		if got := notes.Lookup(command); got != want {
+			t.Errorf("Lookup(%q) = %q; want %q", command, got, want)
+		}
+	}
+
+	notes.Set("ls -la", "long listing")
+	notes.Set("make deploy ENV=staging", "")
+	if err := notes.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, _ := LoadCommandNotes(path)
+	if got := reloaded.Lookup("ls -la"); got != "long listing" {
+		t.Errorf("reloaded note = %q; want %q", got, "long listing")
+	}
+	if got := reloaded.Lookup("make deploy ENV=staging"); got != "deploys something" {
+		t.Errorf("removed exact note should fall back to the pattern, got %q", got)
+	}
+	if len(reloaded.Notes) != 3 {
+		t.Errorf("saved %d notes; want the invalid pattern kept too", len(reloaded.Notes))
+	}
+}
+
+func TestLoadCommandNotesMissingFile(t *testing.T) {
+	notes, err := LoadCommandNotes(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil || len(notes.Notes) != 0 {
+		t.Errorf("LoadCommandNotes(missing) = %v, %v; want empty notes", notes.Notes, err)
+	}
+}
+
+func TestUnparsableNotesAreReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.yaml")
+	broken := "notes:\n  - command: ls\n    note: [unterminated\n"
+	if err := os.WriteFile(path, []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := LoadCommandNotes(path)
+	if err == nil || notes.ReadOnly() == nil {
+		t.Fatalf("LoadCommandNotes(broken) error = %v, read-only = %v; want both set", err, notes.ReadOnly())
+	}
+	notes.Set("make test", "runs the suite")
+	if err := notes.Save(); err == nil {
+		t.Error("Save after a failed load should refuse to write")
+	}
+	if data, _ := os.ReadFile(path); string(data) != broken {
+		t.Errorf("notes file was overwritten:\n%s", data)
+	}
+
+	state := &historySearchState{notes: notes, currentCommands: []RankedCommand{{Command: "ls"}}}
+	input := widgets.NewParagraph()
+	state.startNoteEdit(input)
+	if state.noteEditing || !strings.Contains(input.Title, "read-only") {
+		t.Errorf("note editing = %t, title %q; want editing refused with the error shown", state.noteEditing, input.Title)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Terminal colors used by CLI output. They are cleared when colors are disabled.
+var (
+	Green = "\033[32m"
+	Red   = "\033[31m"
+	Reset = "\033[0m"
+)
+
+// plainOutput strips emoji and colors from CLI output (--plain)
+var plainOutput bool
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// configureOutput applies the output mode requested on the command line or
+// environment. --plain removes colors and emoji; NO_COLOR (https://no-color.org)
+// removes colors only. It runs before cobra parses flags because the banner
+// is rendered up front.
+func configureOutput(args []string) {
+	if hasBoolFlag(args, "plain") {
+		plainOutput = true
+	}
+
+	if plainOutput || os.Getenv("NO_COLOR") != "" {
+		Green, Red, Reset = "", "", ""
+	}
+}
+
+// hasBoolFlag reports whether the boolean flag --name is set in args, for
+// flags that must take effect before cobra parses the command line
+func hasBoolFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--"+name || arg == "--"+name+"=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// cliPrintf is fmt.Printf for user-facing CLI messages, honoring plain mode
+func cliPrintf(format string, a ...any) {
+	cliFprintf(os.Stdout, format, a...)
+}
+
+// cliFprintf is fmt.Fprintf for user-facing CLI messages, honoring plain mode
+func cliFprintf(w io.Writer, format string, a ...any) {
+	io.WriteString(w, plainText(fmt.Sprintf(format, a...)))
+}
+
+// plainText returns s unchanged unless plain mode is on, in which case emoji
+// and ANSI color codes are removed
+func plainText(s string) string {
+	if !plainOutput {
+		return s
+	}
+	return stripEmoji(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// stripEmoji removes emoji (and the spacing that followed them) from s
+func stripEmoji(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	dropSpaces := false
+	for _, r := range s {
+		if isEmoji(r) {
+			dropSpaces = true
+			continue
+		}
+		if dropSpaces && r == ' ' {
+			continue
+		}
+		dropSpaces = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// isEmoji reports whether r is a pictograph, dingbat or emoji modifier
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // pictographs, emoticons, transport, symbols
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // misc symbols and dingbats (✅ ❌ ⚠ ✂)
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // misc technical (⏳ ⌛)
+		return true
+	case r == 0x2B50 || r == 0x2B55: // ⭐ ⭕
+		return true
+	case r == 0xFE0F || r == 0x200D: // variation selector, zero width joiner
+		return true
+	}
+	return unicode.Is(unicode.Variation_Selector, r)
+}
+
+// truncateWidth shortens s to at most width terminal cells, replacing the cut
+// tail with "...". Widths are measured in cells, so wide CJK characters and
+// emoji count double and are never split mid-rune.
+func truncateWidth(s string, width int) string {
+	if runewidth.StringWidth(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return strings.Repeat(".", max(width, 0))
+	}
+	return runewidth.Truncate(s, width, "...")
+}
+
+// truncateWidthLeft is truncateWidth for paths: it keeps the end of s and
+// replaces the cut head with "...". A wide character split by the cut becomes
+// a space so the result is still exactly width cells.
+func truncateWidthLeft(s string, width int) string {
+	total := runewidth.StringWidth(s)
+	if total <= width {
+		return s
+	}
+	if width <= 3 {
+		return strings.Repeat(".", max(width, 0))
+	}
+	return runewidth.TruncateLeft(s, total-(width-3), "...")
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxDiffCells bounds the lines(before) * lines(after) table outputDiff
+// builds; larger outputs are reported as replaced wholesale
+const maxDiffCells = 4_000_000
+
+// lastOutputPath returns where the output of command's last run is kept:
+// $XDG_STATE_HOME/recaller/outputs (~/.local/state by default), or an
+// existing ~/.recaller_outputs, in a file named by the command's hash
+func lastOutputPath(command string) (string, error) {
+	sum := sha256.Sum256([]byte(command))
+	name := hex.EncodeToString(sum[:]) + ".txt"
+	dir, err := recallerFilePath(os.Getenv, ".recaller_outputs", "XDG_STATE_HOME", "outputs", false)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// swapLastOutput stores output as the latest for command and returns the one
+// it replaces, if any
+func swapLastOutput(command string, output string) (previous string, found bool, err error) {
+	path, err := lastOutputPath(command)
+	if err != nil {
+		return "", false, err
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		previous, found = string(data), true
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return previous, found, err
+	}
+	return previous, found, os.WriteFile(path, []byte(output), 0600)
+}
+
+// normalizeOutput makes captured terminal output comparable between runs by
+// dropping colors and carriage returns
+func normalizeOutput(output string) string {
+	return strings.ReplaceAll(StripANSI(output), "\r\n", "\n")
+}
+
+// outputDiff renders a line diff from before to after: removed lines in red
+// with "-", added lines in green with "+", and unchanged lines indented
+func outputDiff(before, after string) string {
+	a := strings.Split(strings.TrimSuffix(before, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(after, "\n"), "\n")
+
+	var out strings.Builder
+	removed := func(line string) { fmt.Fprintf(&out, "%s- %s%s\n", Red, line, Reset) }
+	added := func(line string) { fmt.Fprintf(&out, "%s+ %s%s\n", Green, line, Reset) }
+
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			removed(line)
+		}
+		for _, line := range b {
+			added(line)
+		}
+		return out.String()
+	}
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&out, "  %s\n", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			removed(a[i])
+			i++
+		default:
+			added(b[j])
+			j++
+		}
+	}
+	return out.String()
+}
+
+// reportOutputChanges saves output as command's latest and prints how it
+// differs from the previous run
+func reportOutputChanges(command string, output string) {
+	output = normalizeOutput(output)
+	previous, found, err := swapLastOutput(command, output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to store command output:", err)
+	}
+	switch {
+	case !found:
+		cliPrintf("\n🔁 Output saved; the next run shows what changed\n")
+	case previous == output:
+		cliPrintf("\n🔁 Output unchanged since the last run\n")
+	default:
+		cliPrintf("\n🔁 Changes since the last run:\n%s", outputDiff(previous, output))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutputDiff(t *testing.T) {
+	defer func(green, red, reset string) { Green, Red, Reset = green, red, reset }(Green, Red, Reset)
+	Green, Red, Reset = "", "", ""
+
+	before := "NAME    STATUS\nweb-1   Running\nweb-2   Pending\n"
+	after := "NAME    STATUS\nweb-1   Running\nweb-2   Running\nweb-3   Pending\n"
+	want := "  NAME    STATUS\n" +
+		"  web-1   Running\n" +
+		"- web-2   Pending\n" +
+		"+ web-2   Running\n" +
+		"+ web-3   Pending\n"
+	if got := outputDiff(before, after); got != want {
+		t.Errorf("outputDiff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSwapLastOutput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	state := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", state)
+
+	if _, found, err := swapLastOutput("kubectl get pods", "one\n"); err != nil || found {
+		t.Fatalf("first swap found = %t, err = %v; want nothing stored yet", found, err)
+	}
+	previous, found, err := swapLastOutput("kubectl get pods", "two\n")
+	if err != nil || !found || previous != "one\n" {
+		t.Errorf("second swap = %q, %t, %v; want the first output", previous, found, err)
+	}
+	if _, found, _ := swapLastOutput("kubectl get nodes", "three\n"); found {
+		t.Error("another command shared the stored output")
+	}
+
+	path, _ := lastOutputPath("kubectl get pods")
+	if !strings.HasPrefix(path, filepath.Join(state, "recaller", "outputs")) {
+		t.Errorf("output stored at %s; want it under XDG_STATE_HOME", path)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "two\n" {
+		t.Errorf("stored output = %q", data)
+	}
+}
+
+func TestNormalizeOutput(t *testing.T) {
+	if got := normalizeOutput("\x1b[32mok\x1b[0m\r\nnext\r\n"); got != "ok\nnext\n" {
+		t.Errorf("normalizeOutput = %q", got)
+	}
+}
+
+func TestRunCommandHereDiffsOutput(t *testing.T) {
+	setTestHome(t)
+	t.Setenv("SHELL", "/bin/sh")
+	pods := filepath.Join(t.TempDir(), "pods")
+	command := "cat " + pods
+
+	stdout, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	realStdout := os.Stdout
+	os.Stdout = stdout
+	defer func() { os.Stdout = realStdout }()
+
+	for _, listing := range []string{"web-1 Running\nweb-2 Pending\n", "web-1 Running\nweb-2 Running\n"} {
+		if err := os.WriteFile(pods, []byte(listing), 0644); err != nil {
+			t.Fatal(err)
+		}
+		runCommandHere(command, true)
+	}
+	os.Stdout = realStdout
+
+	printed, _ := os.ReadFile(stdout.Name())
+	for _, want := range []string{"Output saved", "Changes since the last run", "- web-2 Pending", "+ web-2 Running"} {
+		if !strings.Contains(string(printed), want) {
+			t.Errorf("output is missing %q:\n%s", want, printed)
+		}
+	}
+	path, _ := lastOutputPath(command)
+	if data, _ := os.ReadFile(path); string(data) != "web-1 Running\nweb-2 Running\n" {
+		t.Errorf("stored output = %q; want the latest run", data)
+	}
+}
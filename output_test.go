@@ -0,0 +1,114 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestStripEmoji(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"📊 Index stats", "Index stats"},
+		{"⚠️  Reached maximum file limit", "Reached maximum file limit"},
+		{"\n💾 Saving index to disk... ✅\n", "\nSaving index to disk... \n"},
+		{"✏️  Modified: today", "Modified: today"},
+		{"plain text — unchanged", "plain text — unchanged"},
+	}
+
+	for _, tt := range tests {
+		if got := stripEmoji(tt.in); got != tt.want {
+			t.Errorf("stripEmoji(%q) = %q; want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConfigureOutput(t *testing.T) {
+	restore := func() {
+		plainOutput = false
+		Green, Reset = "\033[32m", "\033[0m"
+	}
+	t.Cleanup(restore)
+
+	t.Setenv("NO_COLOR", "")
+	configureOutput([]string{"fs", "index"})
+	if plainOutput || Green == "" {
+		t.Errorf("default output changed: plain=%t green=%q", plainOutput, Green)
+	}
+
+	configureOutput([]string{"fs", "--", "--plain"})
+	if plainOutput {
+		t.Error("--plain after -- should not enable plain mode")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	configureOutput(nil)
+	if plainOutput || Green != "" || Reset != "" {
+		t.Errorf("NO_COLOR: plain=%t green=%q reset=%q; want colors off only", plainOutput, Green, Reset)
+	}
+	restore()
+
+	t.Setenv("NO_COLOR", "")
+	configureOutput([]string{"settings", "list", "--plain"})
+	if !plainOutput || Green != "" {
+		t.Errorf("--plain: plain=%t green=%q", plainOutput, Green)
+	}
+	if got := plainText(Green + "✅ Done\033[0m"); got != "Done" {
+		t.Errorf("plainText = %q; want %q", got, "Done")
+	}
+}
+
+func TestBannerEnabled(t *testing.T) {
+	setTestHome(t)
+
+	if !bannerEnabled(nil) {
+		t.Error("banner should be on by default")
+	}
+	if bannerEnabled([]string{"fs", "--no-banner", "--help"}) {
+		t.Error("--no-banner should turn the banner off")
+	}
+
+	config := cloneDefaultConfig()
+	config.UI.ShowBanner = false
+	if err := writeConfigFile(config); err != nil {
+		t.Fatal(err)
+	}
+	if bannerEnabled(nil) {
+		t.Error("ui.show_banner: false should turn the banner off")
+	}
+}
+
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		in       string
+		width    int
+		want     string
+		wantLeft string
+	}{
+		{"short.txt", 20, "short.txt", "short.txt"},
+		{"abcdefghij", 8, "abcde...", "...fghij"},
+		{"日本語のファイル名.txt", 10, "日本語...", "... 名.txt"},
+		{"🎉🎉🎉🎉🎉🎉", 9, "🎉🎉🎉...", "...🎉🎉🎉"},
+		{"日本語", 2, "..", ".."},
+	}
+	for _, tt := range tests {
+		if got := truncateWidth(tt.in, tt.width); got != tt.want {
+			t.Errorf("truncateWidth(%q, %d) = %q; want %q", tt.in, tt.width, got, tt.want)
+		}
+		if got := truncateWidthLeft(tt.in, tt.width); got != tt.wantLeft {
+			t.Errorf("truncateWidthLeft(%q, %d) = %q; want %q", tt.in, tt.width, got, tt.wantLeft)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// shortcut is a key binding, listed in a UI's footer and its command palette
+type shortcut struct {
+	label  string // Keys as shown to the user, e.g. "<ctrl+e>"
+	action string // What the keys do
+	key    string // Event ID the palette replays to run the action; "" when it needs more keys
+}
+
+// shortcutsText renders shortcuts as a footer line
+func shortcutsText(shortcuts []shortcut) string {
+	parts := make([]string, len(shortcuts))
+	for i, s := range shortcuts {
+		parts[i] = fmt.Sprintf("[%s](fg:green) %s", s.label, s.action)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// Palette size limits, in terminal cells
+const (
+	paletteMaxWidth = 64
+	paletteMargin   = 2
+)
+
+// commandPalette overlays a searchable list of a UI's shortcuts. Typing
+// filters by keys or description, <enter> runs the selected action by
+// replaying its key and <esc> closes the palette.
+type commandPalette struct {
+	open      bool
+	query     string
+	shortcuts []shortcut
+	matches   []shortcut // shortcuts matching query, as listed
+	list      *widgets.List
+}
+
+func newCommandPalette(shortcuts []shortcut) *commandPalette {
+	list := widgets.NewList()
+	list.TextStyle.Fg = ui.ColorWhite
+	list.SelectedRowStyle = ui.NewStyle(ui.ColorBlack, ui.ColorGreen)
+	list.BorderStyle = ui.NewStyle(ui.ColorYellow)
+	return &commandPalette{shortcuts: shortcuts, list: list}
+}
+
+// show opens the palette with an empty filter
+func (p *commandPalette) show() {
+	p.open = true
+	p.query = ""
+	p.filter()
+}
+
+// filter lists the shortcuts whose keys or description contain every word of
+// the query, ignoring case
+func (p *commandPalette) filter() {
+	words := strings.Fields(strings.ToLower(p.query))
+	p.matches = p.matches[:0]
+	p.list.Rows = p.list.Rows[:0]
+	for _, s := range p.shortcuts {
+		text := strings.ToLower(s.label + " " + s.action)
+		if !containsAll(text, words) {
+			continue
+		}
+		row := fmt.Sprintf("[%-13s](fg:green) %s", s.label, s.action)
+		if s.key == "" {
+			row += " (keys only)"
+		}
+		p.matches = append(p.matches, s)
+		p.list.Rows = append(p.list.Rows, row)
+	}
+	p.list.SelectedRow = 0
+	p.list.Title = " Actions | <enter> Run  <esc> Close "
+	if p.query != "" {
+		p.list.Title = fmt.Sprintf(" Actions: %s ", p.query)
+	}
+}
+
+// containsAll reports whether text contains each of words
+func containsAll(text string, words []string) bool {
+	for _, w := range words {
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// handleKey edits the filter, moves the selection or closes the palette. It
+// returns the event ID to run when an action was chosen.
+func (p *commandPalette) handleKey(e ui.Event) string {
+	switch e.ID {
+	case "<Escape>", "<C-c>", "<C-p>":
+		p.open = false
+	case "<Enter>":
+		if p.list.SelectedRow < len(p.matches) && p.matches[p.list.SelectedRow].key != "" {
+			p.open = false
+			return p.matches[p.list.SelectedRow].key
+		}
+	case "<Up>":
+		p.list.ScrollUp()
+	case "<Down>":
+		p.list.ScrollDown()
+	case "<Backspace>":
+		p.query = dropLastRune(p.query)
+		p.filter()
+	case "<Space>":
+		p.query += " "
+		p.filter()
+	default:
+		if text, ok := typedText(e); ok {
+			p.query += text
+			p.filter()
+		}
+	}
+	return ""
+}
+
+// place centers the palette on a terminal of the given size, tall enough
+// for every shortcut so filtering does not resize it
+func (p *commandPalette) place(termWidth, termHeight int) {
+	width := min(paletteMaxWidth, termWidth-2*paletteMargin)
+	height := min(len(p.shortcuts)+2, termHeight-2*paletteMargin)
+	x, y := (termWidth-width)/2, (termHeight-height)/2
+	p.list.SetRect(x, y, x+width, y+height)
+}
+
+// renderUnderPalette draws grid and, while palette is open, the palette over
+// it. Renders from outside the event loop (searches, help fetches, history
+// updates) go through here so they never draw over an open palette.
+func renderUnderPalette(grid *ui.Grid, palette *commandPalette) {
+	ui.Render(grid)
+	palette.render()
+}
+
+// render draws the palette over the UI while it is open
+func (p *commandPalette) render() {
+	if !p.open {
+		return
+	}
+	p.place(ui.TerminalDimensions())
+	ui.Render(p.list)
+}
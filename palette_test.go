@@ -0,0 +1,106 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// TestCommandPaletteKeyIsShared checks that <C-p> opens the palette in every
+// UI rather than running another action in one of them.
+func TestCommandPaletteKeyIsShared(t *testing.T) {
+	actions := commandKeyActions("copy")
+	for name, shortcuts := range map[string][]shortcut{
+		"history":    historyShortcuts(actions),
+		"filesystem": filesystemShortcuts,
+		"search":     combinedShortcuts(actions),
+	} {
+		for _, s := range shortcuts {
+			if s.key == "<C-p>" {
+				t.Errorf("%s UI binds <C-p> to %s", name, s.action)
+			}
+			if s.action == "Actions" && s.label != "<ctrl+p> ?" {
+				t.Errorf("%s UI opens the palette with %s; want <ctrl+p> ?", name, s.label)
+			}
+		}
+	}
+}
+
+func TestCommandPaletteListsFooterShortcuts(t *testing.T) {
+	shortcuts := historyShortcuts(commandKeyActions("copy"))
+	p := newCommandPalette(shortcuts)
+	p.show()
+	if len(p.list.Rows) != len(shortcuts) {
+		t.Errorf("palette lists %d actions; want all %d from the footer", len(p.list.Rows), len(shortcuts))
+	}
+	if got := createKeyboardShortcutsWidget(commandKeyActions("copy")).Text; got != shortcutsText(shortcuts) {
+		t.Errorf("footer = %q; want it rendered from the same shortcuts", got)
+	}
+}
+
+func TestCommandPaletteRunsChosenAction(t *testing.T) {
+	p := newCommandPalette(historyShortcuts(commandKeyActions("copy")))
+	p.show()
+	press := func(ids ...string) string {
+		var run string
+		for _, id := range ids {
+			run = p.handleKey(ui.Event{Type: ui.KeyboardEvent, ID: id})
+		}
+		return run
+	}
+
+	press("C", "o", "p", "y", "<Space>", "h")
+	var labels []string
+	for _, s := range p.matches {
+		labels = append(labels, s.label)
+	}
+	if fmt.Sprint(labels) != "[<ctrl+x>]" {
+		t.Fatalf("filter %q matched %v; want only <ctrl+x> Copy help", p.query, labels)
+	}
+	if run := press("<Enter>"); run != "<C-x>" || p.open {
+		t.Errorf("enter ran %q with the palette open = %t; want <C-x> and the palette closed", run, p.open)
+	}
+
+	// Actions needing more keys are listed but cannot be run
+	p.show()
+	if run := press("j", "u", "m", "p", "<Enter>"); run != "" || !p.open {
+		t.Errorf("enter on Jump first/last ran %q; want nothing with the palette still open", run)
+	}
+	press("<Backspace>", "<Backspace>", "<Backspace>", "<Backspace>", "z")
+	if run := press("<Enter>"); run != "<C-z>" {
+		t.Errorf("enter on %q ran %q; want <C-z>", p.query, run)
+	}
+
+	p.show()
+	press("<Down>", "<Escape>")
+	if p.open {
+		t.Error("escape should close the palette")
+	}
+}
+
+func TestCommandPalettePlacement(t *testing.T) {
+	p := newCommandPalette(filesystemShortcuts)
+	p.place(120, 40)
+	if got := p.list.Rectangle; got.Dx() != paletteMaxWidth || got.Dy() != len(filesystemShortcuts)+2 || got.Min.X != 28 {
+		t.Errorf("palette on 120x40 = %v; want a centered %dx%d box", got, paletteMaxWidth, len(filesystemShortcuts)+2)
+	}
+	p.place(30, 10)
+	if got := p.list.Rectangle; got.Dx() != 26 || got.Dy() != 6 {
+		t.Errorf("palette on 30x10 = %v; want it to fit inside the margins", got)
+	}
+}
@@ -79,6 +79,27 @@ func (pm *ProcessManager) killAll() {
 	}
 }
 
+// runInShell runs command with $SHELL -c (bash when unset) attached to this
+// process's stdin and stderr and writing to stdout, with no time or output
+// limit. Interrupts typed at the terminal reach the command, which recaller
+// outlives.
+func runInShell(command string, stdout io.Writer) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/bash"
+	}
+	cmd := exec.Command(shell, "-c", command)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, stdout, os.Stderr
+
+	// Catching (not ignoring) SIGINT keeps recaller alive while the command,
+	// in the same foreground process group, still gets the default action
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	defer signal.Stop(sigChan)
+
+	return cmd.Run()
+}
+
 func execCommandInPTY(command string) {
 	execCommandInPTYWithConfig(command, DefaultProcessConfig())
 }
@@ -0,0 +1,35 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestRunInShell(t *testing.T) {
+	t.Setenv("SHELL", "/bin/sh")
+	var out bytes.Buffer
+	err := runInShell(`echo "$0 ran"; exit 3`, &out)
+	if out.String() != "/bin/sh ran\n" {
+		t.Errorf("output = %q; want the command run by $SHELL", out.String())
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("runInShell error = %v; want exit status 3", err)
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// SafetyConfig guards against running destructive commands by accident
+type SafetyConfig struct {
+	// DangerousPatterns are regular expressions for commands that need an
+	// explicit confirmation before being sent to a terminal or run and are
+	// flagged when copied. An empty list disables the check.
+	DangerousPatterns []string `yaml:"dangerous_patterns"`
+}
+
+// defaultDangerousPatterns flags the usual ways of losing data
+var defaultDangerousPatterns = []string{
+	`\brm\s+(\S+\s+)*(-[^-\s]*[rRf]|--recursive|--force)`,
+	`\bdd\s+.*\bif=`,
+	`\bmkfs(\.\w+)?\b`,
+	`\bgit\s+push\b.*\s(--force|-f)\b`,
+	`\bgit\s+(reset\s+--hard|clean\s+-\S*f)`,
+	`>\s*/dev/(sd|nvme|disk)`,
+	`\b(shred|wipefs)\b`,
+	`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}`,
+}
+
+// dangerousPatterns holds the compiled safety.dangerous_patterns
+type dangerousPatterns []*regexp.Regexp
+
+// compileDangerousPatterns compiles the configured patterns. Invalid ones
+// are skipped and reported in the returned error.
+func compileDangerousPatterns(patterns []string) (dangerousPatterns, error) {
+	var compiled dangerousPatterns
+	var errs []error
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid dangerous pattern %q: %w", pattern, err))
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, errors.Join(errs...)
+}
+
+// Match returns the first pattern command matches, if any
+func (dp dangerousPatterns) Match(command string) (string, bool) {
+	for _, re := range dp {
+		if re.MatchString(command) {
+			return re.String(), true
+		}
+	}
+	return "", false
+}
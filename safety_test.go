@@ -0,0 +1,68 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func TestDefaultDangerousPatterns(t *testing.T) {
+	patterns, err := compileDangerousPatterns(defaultDangerousPatterns)
+	if err != nil {
+		t.Fatalf("default patterns do not compile: %v", err)
+	}
+
+	dangerous := []string{
+		"rm -rf /tmp/build",
+		"rm -v -fr dist",
+		"sudo rm --recursive logs",
+		"dd if=/dev/zero of=/dev/sda bs=1M",
+		"mkfs.ext4 /dev/sdb1",
+		"git push --force origin main",
+		"git push -f",
+		"git reset --hard HEAD~3",
+		"git clean -fdx",
+		"cat image.iso > /dev/sdb",
+		"shred -u secrets.txt",
+	}
+	for _, command := range dangerous {
+		if _, ok := patterns.Match(command); !ok {
+			t.Errorf("%q should be flagged as dangerous", command)
+		}
+	}
+
+	safe := []string{
+		"rm notes.txt",
+		"rm -i old-file",
+		"git push origin main",
+		"git reset HEAD file.go",
+		"ls -lrt",
+		"docker run --rm -it ubuntu",
+		"echo dd",
+	}
+	for _, command := range safe {
+		if pattern, ok := patterns.Match(command); ok {
+			t.Errorf("%q should not be flagged, matched %s", command, pattern)
+		}
+	}
+}
+
+func TestCompileDangerousPatternsSkipsInvalid(t *testing.T) {
+	patterns, err := compileDangerousPatterns([]string{"(unclosed", `\bterraform\s+destroy\b`})
+	if err == nil {
+		t.Error("expected an error for the invalid pattern")
+	}
+	if _, ok := patterns.Match("terraform destroy -auto-approve"); !ok {
+		t.Error("valid patterns should still be used")
+	}
+}
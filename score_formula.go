@@ -0,0 +1,317 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// scoreVars are the values a history.score_formula can refer to
+type scoreVars struct {
+	frequency float64 // Times the command was run
+	recency   float64 // 1 / (hours since last run + 1), 0 when unknown
+	exact     float64 // 1 when the command equals the query, else 0
+	position  float64 // 1 / (characters before the match + 1)
+	length    float64 // Command length in characters
+}
+
+var scoreVarNames = map[string]func(v *scoreVars) float64{
+	"frequency": func(v *scoreVars) float64 { return v.frequency },
+	"recency":   func(v *scoreVars) float64 { return v.recency },
+	"exact":     func(v *scoreVars) float64 { return v.exact },
+	"position":  func(v *scoreVars) float64 { return v.position },
+	"length":    func(v *scoreVars) float64 { return v.length },
+}
+
+var scoreFuncs = map[string]struct {
+	arity int
+	fn    func(args []float64) float64
+}{
+	"log":  {1, func(a []float64) float64 { return math.Log(a[0]) }},
+	"sqrt": {1, func(a []float64) float64 { return math.Sqrt(a[0]) }},
+	"abs":  {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"min":  {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max":  {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+}
+
+// ScoreFormula is a parsed history.score_formula such as
+// "0.5*frequency + 2*recency + 10*exact - 0.01*length". It supports numbers,
+// the variables frequency, recency, exact, position and length, + - * / ^, parentheses
+// and the functions log, sqrt, abs, min and max.
+type ScoreFormula struct {
+	eval func(v *scoreVars) float64
+}
+
+// Eval computes the score. Results that are not finite (e.g. division by
+// zero) score 0.
+func (f *ScoreFormula) Eval(v scoreVars) float64 {
+	score := f.eval(&v)
+	if math.IsNaN(score) || math.IsInf(score, 0) {
+		return 0
+	}
+	return score
+}
+
+// ParseScoreFormula parses a ranking expression, rejecting unknown variables
+// and functions up front so a typo falls back to the built-in ranking.
+func ParseScoreFormula(source string) (*ScoreFormula, error) {
+	p := &formulaParser{src: source}
+	p.next()
+	eval, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, p.errorf("unexpected %q", p.tok)
+	}
+	return &ScoreFormula{eval: eval}, nil
+}
+
+// compiledFormulas memoizes parsed formulas by source; invalid ones map to nil
+var compiledFormulas sync.Map
+
+// compiledScoreFormula returns the parsed formula for source, or nil when it
+// is empty or invalid. Parse errors are reported by checkScoreFormula when
+// the config is loaded, before any UI is drawn.
+func compiledScoreFormula(source string) *ScoreFormula {
+	if strings.TrimSpace(source) == "" {
+		return nil
+	}
+	if f, ok := compiledFormulas.Load(source); ok {
+		return f.(*ScoreFormula)
+	}
+	f, _ := ParseScoreFormula(source)
+	compiledFormulas.Store(source, f)
+	return f
+}
+
+// checkScoreFormula reports a score_formula that does not parse, which
+// compiledScoreFormula would silently replace with the built-in ranking
+func (c HistoryConfig) checkScoreFormula() error {
+	if strings.TrimSpace(c.ScoreFormula) == "" {
+		return nil
+	}
+	if _, err := ParseScoreFormula(c.ScoreFormula); err != nil {
+		return fmt.Errorf("history.score_formula is invalid: %v", err)
+	}
+	return nil
+}
+
+// scoreFormulaDescription summarizes a history.score_formula for settings list
+func scoreFormulaDescription(source string) string {
+	if strings.TrimSpace(source) == "" {
+		return "built-in (0.6*frequency + 0.4*recency + 0.5*position)"
+	}
+	if _, err := ParseScoreFormula(source); err != nil {
+		return fmt.Sprintf("%s (invalid, using built-in: %v)", source, err)
+	}
+	return source
+}
+
+// formulaParser is a recursive descent parser over a token stream:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | power
+//	power   = primary [ "^" unary ]
+//	primary = number | name | name "(" expr { "," expr } ")" | "(" expr ")"
+type formulaParser struct {
+	src string
+	pos int    // Offset of the next unread byte
+	tok string // Current token, "" at the end of input
+	at  int    // Offset of the current token, for error messages
+}
+
+func (p *formulaParser) errorf(format string, a ...any) error {
+	return fmt.Errorf("score formula %q at offset %d: %s", p.src, p.at, fmt.Sprintf(format, a...))
+}
+
+// next advances to the following token
+func (p *formulaParser) next() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+	p.at = p.pos
+	if p.pos >= len(p.src) {
+		p.tok = ""
+		return
+	}
+
+	start := p.pos
+	c := rune(p.src[p.pos])
+	switch {
+	case unicode.IsDigit(c) || c == '.':
+		for p.pos < len(p.src) && (unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == '.') {
+			p.pos++
+		}
+	case unicode.IsLetter(c) || c == '_':
+		for p.pos < len(p.src) && (unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == '_') {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.tok = p.src[start:p.pos]
+}
+
+func (p *formulaParser) parseExpr() (func(*scoreVars) float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "+" || p.tok == "-" {
+		op := p.tok
+		p.next()
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "+" {
+			left = func(v *scoreVars) float64 { return l(v) + right(v) }
+		} else {
+			left = func(v *scoreVars) float64 { return l(v) - right(v) }
+		}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseTerm() (func(*scoreVars) float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "*" || p.tok == "/" {
+		op := p.tok
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		if op == "*" {
+			left = func(v *scoreVars) float64 { return l(v) * right(v) }
+		} else {
+			left = func(v *scoreVars) float64 { return l(v) / right(v) }
+		}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseUnary() (func(*scoreVars) float64, error) {
+	if p.tok == "-" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(v *scoreVars) float64 { return -operand(v) }, nil
+	}
+	return p.parsePower()
+}
+
+func (p *formulaParser) parsePower() (func(*scoreVars) float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "^" {
+		return base, nil
+	}
+	p.next()
+	exponent, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return func(v *scoreVars) float64 { return math.Pow(base(v), exponent(v)) }, nil
+}
+
+func (p *formulaParser) parsePrimary() (func(*scoreVars) float64, error) {
+	tok := p.tok
+	switch {
+	case tok == "":
+		return nil, p.errorf("unexpected end of formula")
+	case tok == "(":
+		p.next()
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != ")" {
+			return nil, p.errorf("missing closing parenthesis")
+		}
+		p.next()
+		return inner, nil
+	case unicode.IsDigit(rune(tok[0])) || tok[0] == '.':
+		value, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", tok)
+		}
+		p.next()
+		return func(*scoreVars) float64 { return value }, nil
+	case unicode.IsLetter(rune(tok[0])) || tok[0] == '_':
+		p.next()
+		if p.tok == "(" {
+			return p.parseCall(tok)
+		}
+		variable, ok := scoreVarNames[tok]
+		if !ok {
+			return nil, p.errorf("unknown variable %q (use frequency, recency, exact, position or length)", tok)
+		}
+		return variable, nil
+	}
+	return nil, p.errorf("unexpected %q", tok)
+}
+
+// parseCall parses the argument list of function name; the current token is "("
+func (p *formulaParser) parseCall(name string) (func(*scoreVars) float64, error) {
+	fn, ok := scoreFuncs[name]
+	if !ok {
+		return nil, p.errorf("unknown function %q", name)
+	}
+
+	var args []func(*scoreVars) float64
+	for {
+		p.next()
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.tok != "," {
+			break
+		}
+	}
+	if p.tok != ")" {
+		return nil, p.errorf("missing closing parenthesis after %s arguments", name)
+	}
+	p.next()
+	if len(args) != fn.arity {
+		return nil, p.errorf("%s takes %d argument(s), got %d", name, fn.arity, len(args))
+	}
+
+	return func(v *scoreVars) float64 {
+		values := make([]float64, len(args))
+		for i, arg := range args {
+			values[i] = arg(v)
+		}
+		return fn.fn(values)
+	}, nil
+}
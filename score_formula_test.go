@@ -0,0 +1,78 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseScoreFormula(t *testing.T) {
+	vars := scoreVars{frequency: 4, recency: 0.5, exact: 1, length: 10}
+	tests := []struct {
+		formula string
+		want    float64
+	}{
+		{"frequency", 4},
+		{"0.6*frequency + 0.4*recency", 2.6},
+		{"2 + 3 * 4", 14},
+		{"(2 + 3) * 4", 20},
+		{"-frequency + 10", 6},
+		{"2 ^ 3 ^ 2", 512},
+		{"sqrt(frequency) + max(exact, recency) - min(length, 1)", 2},
+		{"log(frequency) / log(2)", 2},
+		{"10 * exact - 0.1*length", 9},
+		{"frequency / 0", 0}, // not finite
+	}
+	for _, tt := range tests {
+		f, err := ParseScoreFormula(tt.formula)
+		if err != nil {
+			t.Errorf("ParseScoreFormula(%q): %v", tt.formula, err)
+			continue
+		}
+		if got := f.Eval(vars); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%q = %v; want %v", tt.formula, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "frequency +", "freq * 2", "pow(2, 3)", "max(1)", "(1 + 2", "1 2", "2 $ 3"} {
+		if _, err := ParseScoreFormula(bad); err == nil {
+			t.Errorf("ParseScoreFormula(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSearchHistoryUsesScoreFormula(t *testing.T) {
+	tree := NewAVLTree()
+	now := time.Now()
+	tree.Insert("git status --short", CommandMetadata{Timestamp: &now, Frequency: 50})
+	tree.Insert("git status", CommandMetadata{Timestamp: &now, Frequency: 1})
+
+	config := cloneDefaultConfig().History
+	if got := rankedCommandNames(SearchHistory(tree, "git status", config)); got[0] != "git status --short" {
+		t.Fatalf("default ranking put %q first; want the frequent command", got[0])
+	}
+
+	config.ScoreFormula = "frequency + 100*exact"
+	if got := rankedCommandNames(SearchHistory(tree, "git status", config)); got[0] != "git status" {
+		t.Errorf("formula ranking put %q first; want the exact match", got[0])
+	}
+
+	config.ScoreFormula = "frequency +* exact"
+	if got := rankedCommandNames(SearchHistory(tree, "git status", config)); got[0] != "git status --short" {
+		t.Errorf("invalid formula should fall back to the default ranking, got %q first", got[0])
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// SearchConfig configures the combined search of `recaller search`
+type SearchConfig struct {
+	// CommandSigil at the start of a query searches history only, e.g. ">git"
+	CommandSigil string `yaml:"command_sigil"`
+	// FileSigil at the start of a query searches indexed paths only, e.g. "@report"
+	FileSigil string `yaml:"file_sigil"`
+}
+
+// Search scopes selected by a query sigil
+const (
+	scopeAll = iota
+	scopeCommands
+	scopeFiles
+)
+
+// parseSearchScope strips a leading scope sigil from query and returns the
+// scope it selects. Empty sigils are disabled.
+func parseSearchScope(query string, config SearchConfig) (int, string) {
+	query = strings.TrimLeft(query, " ")
+	switch {
+	case config.CommandSigil != "" && strings.HasPrefix(query, config.CommandSigil):
+		return scopeCommands, strings.TrimSpace(strings.TrimPrefix(query, config.CommandSigil))
+	case config.FileSigil != "" && strings.HasPrefix(query, config.FileSigil):
+		return scopeFiles, strings.TrimSpace(strings.TrimPrefix(query, config.FileSigil))
+	}
+	return scopeAll, query
+}
+
+// Kinds of results returned by SearchAll
+const (
+	resultCommand = iota
+	resultFile
+	resultDirectory
+)
+
+// CombinedResult is a history command or indexed path matching a query.
+// Score is relative to the best result of the same kind (0..1), so commands
+// and files can be ranked against each other.
+type CombinedResult struct {
+	Kind  int
+	Text  string
+	Score float64
+}
+
+// Tag labels the result's kind for display
+func (r CombinedResult) Tag() string {
+	tags := []string{"🔧", "📄", "📁"}
+	if plainOutput {
+		tags = []string{"cmd ", "file", "dir "}
+	}
+	return tags[r.Kind]
+}
+
+// SearchAll runs query against both the history tree and the filesystem index
+// and merges the results by relative score. fsIndexer may be nil when
+// filesystem search is disabled. filter is a filesystem filter mode;
+// filterModeDirs or filterModeFiles return only those paths and no commands.
+// A leading search sigil (search.command_sigil, search.file_sigil) limits the
+// query to one side. limit <= 0 returns every match.
+func SearchAll(tree *AVLTree, fsIndexer *FilesystemIndexer, query string, config *Config, filter int, limit int) []CombinedResult {
+	var results []CombinedResult
+	scope, query := parseSearchScope(query, config.Search)
+
+	var commands []RankedCommand
+	if filter == filterModeAll && scope != scopeFiles {
+		commands = SearchHistory(tree, query, config.History)
+	}
+	if len(commands) > 0 {
+		best := commands[0].Score
+		for _, cmd := range commands {
+			results = append(results, CombinedResult{Kind: resultCommand, Text: cmd.Command, Score: relativeScore(cmd.Score, best)})
+		}
+	}
+
+	if fsIndexer != nil && query != "" && scope != scopeCommands {
+		files := filterFilesByMode(fsIndexer.SearchFiles(query, config.History.EnableFuzzing), filter)
+		if len(files) > 0 {
+			best := files[0].Score
+			for _, file := range files {
+				kind := resultFile
+				if file.Metadata.IsDirectory {
+					kind = resultDirectory
+				}
+				results = append(results, CombinedResult{Kind: kind, Text: file.Path, Score: relativeScore(file.Score, best)})
+			}
+		}
+	}
+
+	// Stable sort keeps each list's own order for equal relative scores
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// relativeScore scales score against the best score of its list
+func relativeScore(score, best float64) float64 {
+	if best <= 0 {
+		return 0
+	}
+	return score / best
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+)
+
+func TestSearchAllMergesCommandsAndFiles(t *testing.T) {
+	now := time.Now()
+	tree := NewAVLTree()
+	tree.Insert("docker ps", CommandMetadata{Frequency: 10, Timestamp: &now})
+	tree.Insert("docker build .", CommandMetadata{Frequency: 2, Timestamp: &now})
+
+	dir := t.TempDir()
+	compose := filepath.Join(dir, "docker-compose.yml")
+	if err := os.WriteFile(compose, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := cloneDefaultConfig()
+	fi := NewFilesystemIndexer(config.Filesystem)
+	fi.AddPath(compose, now, true)
+
+	var got []string
+	for _, r := range SearchAll(tree, fi, "docker", config, filterModeAll, 0) {
+		got = append(got, fmt.Sprintf("%d:%s", r.Kind, filepath.Base(r.Text)))
+	}
+	want := []string{"0:docker ps", "1:docker-compose.yml", "0:docker build ."}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("SearchAll = %v; want %v", got, want)
+	}
+
+	if results := SearchAll(tree, nil, "docker", config, filterModeAll, 1); len(results) != 1 || results[0].Kind != resultCommand {
+		t.Errorf("SearchAll without an index and limit 1 = %v", results)
+	}
+}
+
+func TestSearchAllPathFilters(t *testing.T) {
+	now := time.Now()
+	tree := NewAVLTree()
+	tree.Insert("cd reports", CommandMetadata{Frequency: 5, Timestamp: &now})
+
+	dir := t.TempDir()
+	reportsDir := filepath.Join(dir, "reports")
+	reportFile := filepath.Join(dir, "reports.csv")
+	if err := os.Mkdir(reportsDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(reportFile, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := cloneDefaultConfig()
+	fi := NewFilesystemIndexer(config.Filesystem)
+	fi.AddPath(reportsDir, now, true)
+	fi.AddPath(reportFile, now, true)
+
+	kinds := func(filter int) []int {
+		var got []int
+		for _, r := range SearchAll(tree, fi, "reports", config, filter, 0) {
+			got = append(got, r.Kind)
+		}
+		return got
+	}
+
+	if got := kinds(filterModeDirs); fmt.Sprint(got) != fmt.Sprint([]int{resultDirectory}) {
+		t.Errorf("dirs only = %v; want just the directory", got)
+	}
+	if got := kinds(filterModeFiles); fmt.Sprint(got) != fmt.Sprint([]int{resultFile}) {
+		t.Errorf("files only = %v; want just the file", got)
+	}
+	if got := kinds(filterModeAll); len(got) != 3 {
+		t.Errorf("all = %v; want the command, file and directory", got)
+	}
+}
+
+func TestParseSearchScope(t *testing.T) {
+	sigils := SearchConfig{CommandSigil: ">", FileSigil: "@"}
+	testCases := []struct {
+		query    string
+		config   SearchConfig
+		scope    int
+		stripped string
+	}{
+		{">git", sigils, scopeCommands, "git"},
+		{"> git status", sigils, scopeCommands, "git status"},
+		{"@report", sigils, scopeFiles, "report"},
+		{"docker", sigils, scopeAll, "docker"},
+		{"git >log", sigils, scopeAll, "git >log"},
+		{">git", SearchConfig{FileSigil: "@"}, scopeAll, ">git"},
+		{"f:notes", SearchConfig{FileSigil: "f:"}, scopeFiles, "notes"},
+	}
+
+	for _, tc := range testCases {
+		scope, stripped := parseSearchScope(tc.query, tc.config)
+		if scope != tc.scope || stripped != tc.stripped {
+			t.Errorf("parseSearchScope(%q) = %d, %q; want %d, %q", tc.query, scope, stripped, tc.scope, tc.stripped)
+		}
+	}
+}
+
+func TestSearchAllSigils(t *testing.T) {
+	now := time.Now()
+	tree := NewAVLTree()
+	tree.Insert("docker ps", CommandMetadata{Frequency: 10, Timestamp: &now})
+
+	dir := t.TempDir()
+	compose := filepath.Join(dir, "docker-compose.yml")
+	if err := os.WriteFile(compose, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := cloneDefaultConfig()
+	fi := NewFilesystemIndexer(config.Filesystem)
+	fi.AddPath(compose, now, true)
+
+	if results := SearchAll(tree, fi, ">docker", config, filterModeAll, 0); len(results) != 1 || results[0].Kind != resultCommand {
+		t.Errorf("'>docker' = %v; want only the command", results)
+	}
+	if results := SearchAll(tree, fi, "@docker", config, filterModeAll, 0); len(results) != 1 || results[0].Kind != resultFile {
+		t.Errorf("'@docker' = %v; want only the file", results)
+	}
+}
+
+func TestCombinedSearchStateListsResults(t *testing.T) {
+	now := time.Now()
+	tree := NewAVLTree()
+	tree.Insert("make test", CommandMetadata{Frequency: 10, Timestamp: &now})
+	tree.Insert("make build", CommandMetadata{Frequency: 2, Timestamp: &now})
+
+	list := widgets.NewList()
+	state := &combinedSearchState{tree: tree, config: cloneDefaultConfig(), query: "make"}
+	state.search(list)
+	want := []string{"🔧 make test", "🔧 make build"}
+	if fmt.Sprint(list.Rows) != fmt.Sprint(want) {
+		t.Errorf("rows = %q; want %q", list.Rows, want)
+	}
+
+	state.move(list, 5)
+	if state.selected != 1 || list.SelectedRow != 1 {
+		t.Errorf("moving past the end selected %d (row %d); want 1", state.selected, list.SelectedRow)
+	}
+	state.search(list)
+	if state.selected != 0 || list.SelectedRow != 0 {
+		t.Errorf("a new search selected %d; want the first result", state.selected)
+	}
+
+	state.query = "nomatch"
+	state.search(list)
+	if len(list.Rows) != 0 || state.selected != 0 {
+		t.Errorf("no results listed %q with selection %d", list.Rows, state.selected)
+	}
+}
+
+func TestOpenIndexedPathsReturnsTerminalOpeners(t *testing.T) {
+	dir := t.TempDir()
+	notes := filepath.Join(dir, "notes.md")
+	if err := os.WriteFile(notes, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := cloneDefaultConfig()
+	config.Filesystem.OpenWith = map[string]OpenWithRule{"md": {Command: "vim", Terminal: true}}
+	fi := NewFilesystemIndexer(config.Filesystem)
+
+	commands := openIndexedPaths(fi, config, []string{notes})
+	if want := []string{"vim '" + notes + "'"}; fmt.Sprint(commands) != fmt.Sprint(want) {
+		t.Errorf("openIndexedPaths = %q; want %q", commands, want)
+	}
+	idx, ok := fi.pathIndex[notes]
+	if !ok || fi.pathRecords[idx].AccessCount == 0 {
+		t.Errorf("opening %s did not count an access in the index", notes)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// combinedSearchLimit bounds the results listed by the `recaller search` UI
+const combinedSearchLimit = 200
+
+// combinedShortcuts lists the `recaller search` UI keys, with the command
+// actions of Enter, <C-e> and <C-o> as returned by commandKeyActions
+func combinedShortcuts(actions [3]string) []shortcut {
+	return []shortcut{
+		{"<enter>", commandActionLabels[actions[0]] + " / Open path", "<Enter>"},
+		{"<ctrl+e>", commandActionLabels[actions[1]], "<C-e>"},
+		{"<ctrl+o>", commandActionLabels[actions[2]], "<C-o>"},
+		{"<ctrl+r>", "Reset input", "<C-r>"},
+		{"<up/down>", "Navigate", ""},
+		{"<ctrl+j/k>", "Jump first/last", ""},
+		{"<ctrl+p> ?", "Actions", ""},
+		{"<esc>", "Quit", "<Escape>"},
+	}
+}
+
+// combinedSearchState is the state of the `recaller search` UI, which lists
+// history commands and indexed paths matching one query
+type combinedSearchState struct {
+	tree       *AVLTree
+	fsIndexer  *FilesystemIndexer // nil when filesystem search is off
+	config     *Config
+	filter     int // filterModeAll, or only files or directories
+	keyActions [3]string
+	dangerous  dangerousPatterns
+
+	query    string
+	results  []CombinedResult
+	selected int
+
+	// A dangerous command waiting for "y" before confirmAction
+	confirmCommand string
+	confirmAction  string
+
+	palette *commandPalette
+}
+
+// search runs the query and lists the results, selecting the first
+func (s *combinedSearchState) search(list *widgets.List) {
+	s.results = SearchAll(s.tree, s.fsIndexer, s.query, s.config, s.filter, combinedSearchLimit)
+	list.Rows = make([]string, len(s.results))
+	for i, result := range s.results {
+		list.Rows[i] = fmt.Sprintf("%s %s", result.Tag(), result.Text)
+	}
+	list.Title = fmt.Sprintf(" History & Files (%d) ", len(s.results))
+	s.move(list, 0)
+}
+
+// move selects result i, clamped to the results
+func (s *combinedSearchState) move(list *widgets.List, i int) {
+	s.selected = max(0, min(i, len(s.results)-1))
+	list.SelectedRow = s.selected
+}
+
+// act performs the action of key on the selected result: a command is
+// copied, sent or run as for the history UI (Enter, <C-e>, <C-o>), and Enter
+// opens a path. A command matching safety.dangerous_patterns is only sent or
+// run once confirmed with "y". It reports whether the UI was closed.
+func (s *combinedSearchState) act(key string, input *widgets.Paragraph) bool {
+	if s.selected >= len(s.results) {
+		return false
+	}
+	result := s.results[s.selected]
+	if result.Kind != resultCommand {
+		if key != "<Enter>" {
+			return false
+		}
+		terminalCommands := openIndexedPaths(s.fsIndexer, s.config, []string{result.Text})
+		ui.Close()
+		if err := s.fsIndexer.PersistIndex(false); err != nil {
+			log.Printf("Failed to persist index: %v", err)
+		}
+		runTerminalOpeners(terminalCommands)
+		return true
+	}
+
+	command := result.Text
+	if s.config.History.CopyStripEnvPrefix {
+		_, command = splitEnvPrefix(command)
+	}
+	action := s.keyActions[commandKeys[key]]
+	if action == commandActionCopy {
+		err := copyToClipboard(command)
+		ui.Close()
+		if err != nil {
+			reportClipboardFailure(os.Stderr, err, command)
+		} else {
+			cliFprintf(os.Stderr, "📋 Copied %s%s%s to clipboard.\n", Green, command, Reset)
+		}
+		warnIfDangerous(s.dangerous, command)
+		return true
+	}
+	if pattern, dangerous := s.dangerous.Match(command); dangerous {
+		s.confirmCommand, s.confirmAction = command, action
+		input.Title = fmt.Sprintf(" ⚠️  Looks destructive | <y> %s anyway  <any key> Cancel ", commandActionLabels[action])
+		input.Text = fmt.Sprintf("%s  (matches %s)", command, pattern)
+		return false
+	}
+	ui.Close()
+	runCommandAction(action, command, s.config.History.DiffOutput)
+	return true
+}
+
+// runCombinedSearch shows the `recaller search` UI, starting from query and
+// listing only the paths allowed by filter. fsIndexer may be nil when
+// filesystem search is disabled.
+func runCombinedSearch(tree *AVLTree, fsIndexer *FilesystemIndexer, config *Config, query string, filter int) error {
+	if err := ui.Init(); err != nil {
+		return fmt.Errorf("failed to initialize termui: %v", err)
+	}
+	DisableMouseInput()
+	defer ui.Close()
+	defer restoreTerminalOnSignal()()
+
+	keyActions := commandKeyActions(config.History.EnterAction)
+	const inputTitle = " Search History & Files "
+	input := createInputWidget()
+	input.Title = inputTitle
+	list := createFileListWidget()
+	footer := widgets.NewParagraph()
+	footer.Title = " Keyboard Shortcuts "
+	footer.Text = shortcutsText(combinedShortcuts(keyActions))
+	footer.TextStyle.Fg = ui.ColorWhite
+
+	termWidth, termHeight := ui.TerminalDimensions()
+	grid := ui.NewGrid()
+	grid.SetRect(0, 0, termWidth, termHeight)
+	grid.Set(
+		ui.NewRow(0.12, input),
+		ui.NewRow(0.8, list),
+		ui.NewRow(0.08, footer),
+	)
+
+	state := &combinedSearchState{
+		tree:       tree,
+		fsIndexer:  fsIndexer,
+		config:     config,
+		filter:     filter,
+		keyActions: keyActions,
+		query:      query,
+		palette:    newCommandPalette(combinedShortcuts(keyActions)),
+	}
+	var err error
+	state.dangerous, err = compileDangerousPatterns(config.Safety.DangerousPatterns)
+	if err != nil {
+		log.Printf("Safety settings: %v", err)
+	}
+	state.search(list)
+	input.Text = state.query
+	ui.Render(grid)
+
+	// Searches run on this goroutine once typing pauses
+	searchDebouncer := time.NewTimer(time.Hour)
+	searchDebouncer.Stop()
+	uiEvents := ui.PollEvents()
+
+	for {
+		var e ui.Event
+		select {
+		case <-searchDebouncer.C:
+			state.search(list)
+			renderUnderPalette(grid, state.palette)
+			continue
+		case e = <-uiEvents:
+		}
+
+		// A dangerous command waits for "y" before being sent or run; any other key cancels
+		if state.confirmCommand != "" {
+			command, action := state.confirmCommand, state.confirmAction
+			state.confirmCommand, state.confirmAction = "", ""
+			if e.ID == "y" || e.ID == "Y" {
+				ui.Close()
+				runCommandAction(action, command, config.History.DiffOutput)
+				return nil
+			}
+			input.Title = inputTitle
+			input.Text = state.query
+			ui.Render(grid)
+			continue
+		}
+
+		// The command palette takes every key; a chosen action runs as if its key was pressed
+		if state.palette.open && e.ID != "<Resize>" {
+			key := state.palette.handleKey(e)
+			if key == "" {
+				renderUnderPalette(grid, state.palette)
+				continue
+			}
+			e = ui.Event{Type: ui.KeyboardEvent, ID: key}
+		}
+
+		switch e.ID {
+		case "<C-c>", "<Escape>":
+			return nil
+		case "<Enter>", "<C-e>", "<C-o>":
+			if state.act(e.ID, input) {
+				return nil
+			}
+			if state.confirmCommand != "" {
+				ui.Render(grid)
+				continue
+			}
+		case "<Up>":
+			state.move(list, state.selected-1)
+		case "<Down>":
+			state.move(list, state.selected+1)
+		case "<C-k>":
+			state.move(list, 0)
+		case "<C-j>":
+			state.move(list, len(state.results)-1)
+		case "<C-p>":
+			state.palette.show()
+		case "<C-r>":
+			state.query = ""
+			searchDebouncer.Reset(debounceDelay)
+		case "<Backspace>":
+			state.query = dropLastRune(state.query)
+			searchDebouncer.Reset(debounceDelay)
+		case "<Space>":
+			state.query += " "
+			searchDebouncer.Reset(debounceDelay)
+		case "<Resize>":
+			if payload, ok := e.Payload.(ui.Resize); ok {
+				grid.SetRect(0, 0, payload.Width, payload.Height)
+			}
+			ui.Clear()
+		default:
+			if e.ID == "?" && state.query == "" {
+				state.palette.show()
+			} else if text, ok := typedText(e); ok {
+				state.query += text
+				searchDebouncer.Reset(debounceDelay)
+			}
+		}
+
+		input.Text = state.query
+		renderUnderPalette(grid, state.palette)
+	}
+}
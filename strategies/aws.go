@@ -14,7 +14,10 @@
 
 package strategies
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // AwsHelpStrategy handles AWS CLI commands with multiple sub-command levels
 type AwsHelpStrategy struct {
@@ -33,18 +36,19 @@ func (a *AwsHelpStrategy) Priority() int {
 	return 2
 }
 
-func (a *AwsHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+func (a *AwsHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	if !cmd.HasSubCommand(1) {
-		return a.cmdRunner.Run("aws", "help")
+		return a.cmdRunner.Help(ctx, "aws", "help")
 	}
 
 	// AWS CLI supports help at multiple levels: aws s3 help, aws s3 cp help
 	args := append(cmd.SubCmds, "help")
-	if out, err := a.cmdRunner.Run("aws", args...); err == nil {
-		return RemoveOverstrike(out), nil
+	if res, err := a.cmdRunner.Help(ctx, "aws", args...); err == nil {
+		res.Text = RemoveOverstrike(res.Text)
+		return res, nil
 	}
 
-	return "", fmt.Errorf("AWS command %q is invalid or not found", cmd.FullName)
+	return nil, fmt.Errorf("AWS command %q is invalid or not found", cmd.FullName)
 }
@@ -0,0 +1,153 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Environment describes the help tooling of the system recaller runs on
+type Environment struct {
+	// Busybox is set when core utilities are busybox applets (Alpine and
+	// other minimal images)
+	Busybox bool
+	// ManMinimal is set when man pages are absent or stripped, so looking
+	// them up only wastes time
+	ManMinimal bool
+}
+
+// envProbe is the filesystem access environment detection relies on
+type envProbe struct {
+	lookPath func(file string) (string, error)
+	resolve  func(path string) (string, error)
+	exists   func(path string) bool
+	// manPath returns man's colon-separated search path
+	manPath func() (string, error)
+}
+
+var systemProbe = envProbe{
+	lookPath: exec.LookPath,
+	resolve:  filepath.EvalSymlinks,
+	exists: func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	},
+	manPath: systemManPath,
+}
+
+// systemManPath asks `manpath`, or `man -w` where it is missing, where man
+// looks for pages
+func systemManPath() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), FastCmdTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "manpath").Output()
+	if err != nil {
+		out, err = exec.CommandContext(ctx, "man", "-w").Output()
+	}
+	return strings.TrimSpace(string(out)), err
+}
+
+// DetectEnvironment inspects the system for busybox and minimized man pages
+func DetectEnvironment() Environment {
+	return systemProbe.detect()
+}
+
+func (p envProbe) detect() Environment {
+	var env Environment
+	for _, path := range []string{"/bin/ls", "/bin/sh"} {
+		if p.isBusybox(path) {
+			env.Busybox = true
+			break
+		}
+	}
+
+	// Ubuntu's minimized images replace man with a stub until unminimize runs
+	manPath, err := p.lookPath("man")
+	switch {
+	case err != nil:
+		env.ManMinimal = true
+	case p.isBusybox(manPath):
+		env.ManMinimal = true
+	case p.exists("/usr/local/sbin/unminimize"):
+		env.ManMinimal = true
+	case !p.hasManPages():
+		env.ManMinimal = true
+	}
+	return env
+}
+
+// hasManPages reports whether a directory on man's search path exists, so
+// pages under /usr/local, /opt/homebrew or $MANPATH count. /usr/share/man is
+// checked when man cannot report its path.
+func (p envProbe) hasManPages() bool {
+	dirs := "/usr/share/man"
+	if path, err := p.manPath(); err == nil && path != "" {
+		dirs = path
+	}
+	for _, dir := range filepath.SplitList(dirs) {
+		if dir != "" && p.exists(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBusybox reports whether path is the busybox binary or a link to it
+func (p envProbe) isBusybox(path string) bool {
+	resolved, err := p.resolve(path)
+	return err == nil && filepath.Base(resolved) == "busybox"
+}
+
+// BusyboxHelpStrategy asks busybox for the usage of its applets, whose
+// --help output differs from the GNU tools and which have no man pages
+type BusyboxHelpStrategy struct {
+	cmdRunner *CommandRunner
+	probe     envProbe
+}
+
+func NewBusyboxHelpStrategy(cmdRunner *CommandRunner) *BusyboxHelpStrategy {
+	return &BusyboxHelpStrategy{cmdRunner: cmdRunner, probe: systemProbe}
+}
+
+// SupportsCommand accepts busybox itself and commands on PATH that are links
+// to it
+func (b *BusyboxHelpStrategy) SupportsCommand(baseCmd string) bool {
+	path, err := b.probe.lookPath(baseCmd)
+	return err == nil && b.probe.isBusybox(path)
+}
+
+func (b *BusyboxHelpStrategy) Priority() int {
+	return 4 // Before man pages, which minimal images rarely ship
+}
+
+func (b *BusyboxHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
+	cmd := NewCommand(cmdParts)
+
+	// busybox prints applet usage on stderr; `busybox --help` lists the applets
+	args := []string{cmd.BaseCmd, "--help"}
+	if cmd.BaseCmd == "busybox" {
+		args = []string{"--help"}
+	}
+	if res, err := b.cmdRunner.Help(ctx, "busybox", args...); err == nil && res.Text != "" {
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("busybox has no help for %q", cmd.BaseCmd)
+}
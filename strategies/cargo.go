@@ -14,6 +14,8 @@
 
 package strategies
 
+import "context"
+
 // CargoHelpStrategy handles Cargo commands
 type CargoHelpStrategy struct {
 	cmdRunner *CommandRunner
@@ -31,13 +33,13 @@ func (c *CargoHelpStrategy) Priority() int {
 	return 2
 }
 
-func (c *CargoHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+func (c *CargoHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	if !cmd.HasSubCommand(1) {
-		return c.cmdRunner.Run("cargo", "--help")
+		return c.cmdRunner.Help(ctx, "cargo", "--help")
 	}
 
 	subCmd := cmd.GetSubCommand(0)
-	return c.cmdRunner.Run("cargo", subCmd, "--help")
+	return c.cmdRunner.Help(ctx, "cargo", subCmd, "--help")
 }
@@ -14,6 +14,8 @@
 
 package strategies
 
+import "context"
+
 // DockerHelpStrategy handles Docker commands
 type DockerHelpStrategy struct {
 	cmdRunner *CommandRunner
@@ -31,14 +33,14 @@ func (d *DockerHelpStrategy) Priority() int {
 	return 2
 }
 
-func (d *DockerHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+func (d *DockerHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	if !cmd.HasSubCommand(1) {
-		return d.cmdRunner.Run("docker", "--help")
+		return d.cmdRunner.Help(ctx, "docker", "--help")
 	}
 
 	// Handle docker subcommand help
 	args := append(cmd.SubCmds, "--help")
-	return d.cmdRunner.Run("docker", args...)
+	return d.cmdRunner.Help(ctx, "docker", args...)
 }
@@ -14,15 +14,32 @@
 
 package strategies
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultGenericMaxDepth is how many sub-command words the generic strategy
+// keeps before appending a help flag
+const DefaultGenericMaxDepth = 3
 
 // GenericHelpStrategy tries common help flags
 type GenericHelpStrategy struct {
 	cmdRunner *CommandRunner
+	maxDepth  int
 }
 
 func NewGenericHelpStrategy(cmdRunner *CommandRunner) *GenericHelpStrategy {
-	return &GenericHelpStrategy{cmdRunner: cmdRunner}
+	return &GenericHelpStrategy{cmdRunner: cmdRunner, maxDepth: DefaultGenericMaxDepth}
+}
+
+// SetMaxDepth limits how many sub-command words are kept before the help
+// flag. Values below 0 are treated as 0, which asks the base command only.
+func (g *GenericHelpStrategy) SetMaxDepth(n int) {
+	if n < 0 {
+		n = 0
+	}
+	g.maxDepth = n
 }
 
 func (g *GenericHelpStrategy) SupportsCommand(baseCmd string) bool {
@@ -33,18 +50,27 @@ func (g *GenericHelpStrategy) Priority() int {
 	return 8 // Lower priority than specific strategies
 }
 
-func (g *GenericHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+// GetHelp tries each help flag after the sub-commands, dropping the last
+// sub-command whenever all flags fail, so `tool a b c` falls back to
+// `tool a b --help`, then `tool a --help` and finally `tool --help`
+func (g *GenericHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	// Try different help flags
 	helpFlags := []string{"-h", "--help", "help"}
 
-	for _, flag := range helpFlags {
-		args := append(cmd.SubCmds, flag)
-		if out, err := g.cmdRunner.Run(cmd.BaseCmd, args...); err == nil && out != "" {
-			return out, nil
+	depth := min(len(cmd.SubCmds), g.maxDepth)
+	for ; depth >= 0; depth-- {
+		for _, flag := range helpFlags {
+			args := append(append([]string{}, cmd.SubCmds[:depth]...), flag)
+			if res, err := g.cmdRunner.Help(ctx, cmd.BaseCmd, args...); err == nil && res.Text != "" {
+				return res, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 		}
 	}
 
-	return "", fmt.Errorf("no help found for command %q", cmd.FullName)
+	return nil, fmt.Errorf("no help found for command %q", cmd.FullName)
 }
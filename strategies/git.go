@@ -39,34 +39,38 @@ func (g *GitHelpStrategy) Priority() int {
 	return 2
 }
 
-func (g *GitHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+func (g *GitHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	if !cmd.HasSubCommand(1) {
-		return g.cmdRunner.RunWithTimeout(GitCmdTimeout, "git", "help")
+		return g.cmdRunner.HelpWithTimeout(ctx, GitCmdTimeout, "git", "help")
 	}
 
 	// Handle git subcommand help
 	subCmd := cmd.GetSubCommand(0)
 
 	// Try git help <subcommand> first
-	if out, err := g.runGitHelp(subCmd); err == nil {
-		return RemoveOverstrike(out), nil
+	if out, err := g.runGitHelp(ctx, subCmd); err == nil {
+		return &HelpResult{
+			Text:       RemoveOverstrike(out),
+			Invocation: "GIT_PAGER=cat " + FormatInvocation("git", "help", subCmd),
+		}, nil
 	}
 
 	// For complex sub-commands like "git config --global", try git <subcommand> --help
 	if cmd.HasSubCommand(2) {
 		args := append(cmd.SubCmds, "--help")
-		if out, err := g.cmdRunner.RunWithTimeout(GitCmdTimeout, "git", args...); err == nil {
-			return RemoveOverstrike(out), nil
+		if res, err := g.cmdRunner.HelpWithTimeout(ctx, GitCmdTimeout, "git", args...); err == nil {
+			res.Text = RemoveOverstrike(res.Text)
+			return res, nil
 		}
 	}
 
-	return "", fmt.Errorf("failed to get Git help for %q", cmd.FullName)
+	return nil, fmt.Errorf("failed to get Git help for %q", cmd.FullName)
 }
 
-func (g *GitHelpStrategy) runGitHelp(subCmd string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), GitCmdTimeout)
+func (g *GitHelpStrategy) runGitHelp(ctx context.Context, subCmd string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, GitCmdTimeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, "git", "help", subCmd)
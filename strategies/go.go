@@ -14,6 +14,8 @@
 
 package strategies
 
+import "context"
+
 // GoHelpStrategy handles Go commands
 type GoHelpStrategy struct {
 	cmdRunner *CommandRunner
@@ -31,13 +33,13 @@ func (g *GoHelpStrategy) Priority() int {
 	return 2
 }
 
-func (g *GoHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+func (g *GoHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	if !cmd.HasSubCommand(1) {
-		return g.cmdRunner.Run("go", "help")
+		return g.cmdRunner.Help(ctx, "go", "help")
 	}
 
 	subCmd := cmd.GetSubCommand(0)
-	return g.cmdRunner.Run("go", "help", subCmd)
+	return g.cmdRunner.Help(ctx, "go", "help", subCmd)
 }
@@ -14,15 +14,25 @@
 
 package strategies
 
-import "strings"
+import (
+	"context"
+	"strings"
+)
 
 // HelpStrategy defines the interface for different command help strategies
 type HelpStrategy interface {
-	GetHelp(cmdParts []string) (string, error)
+	// GetHelp fetches help text; ctx cancels any in-flight subprocess or request
+	GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error)
 	SupportsCommand(baseCmd string) bool
 	Priority() int // Lower number = higher priority
 }
 
+// HelpResult is help text together with the invocation that produced it
+type HelpResult struct {
+	Text       string
+	Invocation string // Command line (or URL) used to fetch Text, e.g. "git help status"
+}
+
 // Command represents a parsed command with its parts
 type Command struct {
 	Parts    []string
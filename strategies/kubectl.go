@@ -14,6 +14,8 @@
 
 package strategies
 
+import "context"
+
 // KubectlHelpStrategy handles kubectl commands with sub-commands
 type KubectlHelpStrategy struct {
 	cmdRunner *CommandRunner
@@ -31,14 +33,14 @@ func (k *KubectlHelpStrategy) Priority() int {
 	return 2
 }
 
-func (k *KubectlHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+func (k *KubectlHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	if !cmd.HasSubCommand(1) {
-		return k.cmdRunner.Run("kubectl", "--help")
+		return k.cmdRunner.Help(ctx, "kubectl", "--help")
 	}
 
 	// Handle kubectl subcommand help - supports multiple levels
 	args := append(cmd.SubCmds, "--help")
-	return k.cmdRunner.Run("kubectl", args...)
+	return k.cmdRunner.Help(ctx, "kubectl", args...)
 }
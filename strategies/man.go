@@ -42,16 +42,17 @@ func (m *ManPageStrategy) Priority() int {
 	return 5 // Lower priority than specific strategies
 }
 
-func (m *ManPageStrategy) GetHelp(cmdParts []string) (string, error) {
+func (m *ManPageStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
-	if output, err := m.cmdRunner.Run("man", cmd.BaseCmd); err == nil {
+	if res, err := m.cmdRunner.Help(ctx, "man", cmd.BaseCmd); err == nil {
 		// Handle minimal environments where man prints a placeholder message
-		if strings.Contains(output, "No manual entry") || strings.Contains(output, "has been minimized") {
-			return "", fmt.Errorf("man page not found for command %q", cmd.BaseCmd)
+		if strings.Contains(res.Text, "No manual entry") || strings.Contains(res.Text, "has been minimized") {
+			return nil, fmt.Errorf("man page not found for command %q", cmd.BaseCmd)
 		}
-		return RemoveOverstrike(output), nil
+		res.Text = RemoveOverstrike(res.Text)
+		return res, nil
 	}
 
-	return "", fmt.Errorf("failed to get man page for %q", cmd.BaseCmd)
+	return nil, fmt.Errorf("failed to get man page for %q", cmd.BaseCmd)
 }
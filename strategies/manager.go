@@ -14,20 +14,39 @@
 
 package strategies
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxConcurrentFetches bounds simultaneous help lookups per manager
+const DefaultMaxConcurrentFetches = 2
 
 // HelpStrategyManager manages different help strategies
 type HelpStrategyManager struct {
 	strategies []HelpStrategy
 	cmdRunner  *CommandRunner
+	slots      chan struct{} // Semaphore limiting concurrent help fetches
 }
 
-// NewHelpStrategyManager creates a new strategy manager with all strategies
+// NewHelpStrategyManager creates a new strategy manager with the strategies
+// that suit the detected environment
 func NewHelpStrategyManager() *HelpStrategyManager {
+	return NewHelpStrategyManagerFor(DetectEnvironment())
+}
+
+// NewHelpStrategyManagerFor creates a strategy manager for env. Busybox
+// systems ask busybox for applet usage ahead of man pages and the generic
+// help flags (`ls -h` means human-readable sizes to busybox ls), and man
+// pages are skipped where they are known to be missing.
+func NewHelpStrategyManagerFor(env Environment) *HelpStrategyManager {
 	cmdRunner := NewCommandRunner()
 
 	manager := &HelpStrategyManager{
 		cmdRunner: cmdRunner,
+		slots:     make(chan struct{}, DefaultMaxConcurrentFetches),
 	}
 
 	// Register strategies in order of preference
@@ -40,7 +59,12 @@ func NewHelpStrategyManager() *HelpStrategyManager {
 	manager.RegisterStrategy(NewNpmHelpStrategy(cmdRunner))
 	manager.RegisterStrategy(NewAwsHelpStrategy(cmdRunner))
 	manager.RegisterStrategy(NewDockerHelpStrategy(cmdRunner))
-	manager.RegisterStrategy(NewManPageStrategy(cmdRunner))
+	if env.Busybox {
+		manager.RegisterStrategy(NewBusyboxHelpStrategy(cmdRunner))
+	}
+	if !env.ManMinimal {
+		manager.RegisterStrategy(NewManPageStrategy(cmdRunner))
+	}
 	manager.RegisterStrategy(NewGenericHelpStrategy(cmdRunner))
 
 	return manager
@@ -51,44 +75,148 @@ func (hsm *HelpStrategyManager) RegisterStrategy(strategy HelpStrategy) {
 	hsm.strategies = append(hsm.strategies, strategy)
 }
 
+// SetMaxConcurrentFetches limits how many help lookups (subprocesses or HTTP
+// requests) may run at once. Values below 1 are treated as 1. It must be
+// called before any lookups are in flight.
+func (hsm *HelpStrategyManager) SetMaxConcurrentFetches(n int) {
+	if n < 1 {
+		n = 1
+	}
+	hsm.slots = make(chan struct{}, n)
+}
+
+// SetGenericMaxDepth limits how many sub-command words the generic
+// strategy keeps before appending a help flag
+func (hsm *HelpStrategyManager) SetGenericMaxDepth(n int) {
+	for _, strategy := range hsm.strategies {
+		if generic, ok := strategy.(*GenericHelpStrategy); ok {
+			generic.SetMaxDepth(n)
+		}
+	}
+}
+
 // GetHelp gets help for a command using the best available strategy
 func (hsm *HelpStrategyManager) GetHelp(cmdParts []string) (string, error) {
+	res, err := hsm.Resolve(cmdParts)
+	if err != nil {
+		return "", err
+	}
+	return res.Text, nil
+}
+
+// Resolve gets help for a command using the best available strategy and
+// reports the invocation that produced it
+func (hsm *HelpStrategyManager) Resolve(cmdParts []string) (*HelpResult, error) {
+	return hsm.ResolveContext(context.Background(), cmdParts)
+}
+
+// ResolveContext is like Resolve but waits for a free fetch slot and aborts
+// the lookup, including any running subprocess, when ctx is cancelled
+func (hsm *HelpStrategyManager) ResolveContext(ctx context.Context, cmdParts []string) (*HelpResult, error) {
+	_, help, err := hsm.resolve(ctx, cmdParts, false)
+	return help, err
+}
+
+// StrategyAttempt records how one registered strategy fared during a lookup
+type StrategyAttempt struct {
+	Strategy  string      // Strategy type name, e.g. "GitHelpStrategy"
+	Supported bool        // Result of SupportsCommand
+	Tried     bool        // Whether GetHelp ran; lookup stops at the first success
+	Result    *HelpResult // Help returned by a successful GetHelp
+	Err       error       // Why GetHelp failed
+}
+
+// TraceContext resolves help like ResolveContext and also reports every
+// registered strategy in the order it was considered, for diagnosing
+// which strategy answered and why the others did not
+func (hsm *HelpStrategyManager) TraceContext(ctx context.Context, cmdParts []string) ([]StrategyAttempt, *HelpResult, error) {
+	return hsm.resolve(ctx, cmdParts, true)
+}
+
+// errEmptyHelp marks a strategy that succeeded without returning any text
+var errEmptyHelp = errors.New("strategy returned empty help text")
+
+func (hsm *HelpStrategyManager) resolve(ctx context.Context, cmdParts []string, trace bool) ([]StrategyAttempt, *HelpResult, error) {
 	if len(cmdParts) == 0 {
-		return "", fmt.Errorf("no command provided")
+		return nil, nil, fmt.Errorf("no command provided")
+	}
+
+	select {
+	case hsm.slots <- struct{}{}:
+		defer func() { <-hsm.slots }()
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
 	}
 
 	cmd := NewCommand(cmdParts)
 
+	// attempts mirrors the registered strategies; TLDR is tried regardless of support
+	var attempts []StrategyAttempt
+	if trace {
+		for _, strategy := range hsm.strategies {
+			_, isTldr := strategy.(*TldrStrategy)
+			attempts = append(attempts, StrategyAttempt{
+				Strategy:  strategyName(strategy),
+				Supported: isTldr || strategy.SupportsCommand(cmd.BaseCmd),
+			})
+		}
+	}
+	// try runs a strategy, recording the outcome against its registration slot
+	try := func(slot int, strategy HelpStrategy) (*HelpResult, error) {
+		help, err := strategy.GetHelp(ctx, cmdParts)
+		if err == nil && (help == nil || help.Text == "") {
+			help, err = nil, errEmptyHelp
+		}
+		if trace && slot >= 0 {
+			attempts[slot].Tried, attempts[slot].Result, attempts[slot].Err = true, help, err
+		}
+		return help, err
+	}
+
 	// Try TLDR first as it provides cleaner, more practical examples
-	tldrStrategy := &TldrStrategy{}
-	if help, err := tldrStrategy.GetHelp(cmdParts); err == nil && help != "" {
-		return help, nil
+	tldrSlot := -1
+	for i, strategy := range hsm.strategies {
+		if _, isTldr := strategy.(*TldrStrategy); isTldr {
+			tldrSlot = i
+			break
+		}
+	}
+	if help, err := try(tldrSlot, &TldrStrategy{}); err == nil {
+		return attempts, help, nil
 	}
 
 	// Find other strategies that support this command (excluding TLDR since we tried it first)
-	var supportedStrategies []HelpStrategy
-	for _, strategy := range hsm.strategies {
+	var supportedSlots []int
+	for i, strategy := range hsm.strategies {
 		if _, isTldr := strategy.(*TldrStrategy); isTldr {
 			continue // Skip TLDR since we already tried it
 		}
 		if strategy.SupportsCommand(cmd.BaseCmd) {
-			supportedStrategies = append(supportedStrategies, strategy)
+			supportedSlots = append(supportedSlots, i)
 		}
 	}
 
 	// Try strategies in priority order
 	var lastErr error
-	for _, strategy := range supportedStrategies {
-		if help, err := strategy.GetHelp(cmdParts); err == nil && help != "" {
-			return help, nil
-		} else {
+	for _, slot := range supportedSlots {
+		help, err := try(slot, hsm.strategies[slot])
+		if err == nil {
+			return attempts, help, nil
+		}
+		if err != errEmptyHelp {
 			lastErr = err
 		}
 	}
 
-	if len(supportedStrategies) == 0 && lastErr == nil {
-		return "", fmt.Errorf("no help strategy found for command %q", cmd.FullName)
+	if len(supportedSlots) == 0 && lastErr == nil {
+		return attempts, nil, fmt.Errorf("no help strategy found for command %q", cmd.FullName)
 	}
 
-	return "", fmt.Errorf("failed to get help for command %q: %v", cmd.FullName, lastErr)
+	return attempts, nil, fmt.Errorf("failed to get help for command %q: %w", cmd.FullName, lastErr)
+}
+
+// strategyName returns the type name of a strategy for diagnostics
+func strategyName(strategy HelpStrategy) string {
+	name := fmt.Sprintf("%T", strategy)
+	return name[strings.LastIndex(name, ".")+1:]
 }
@@ -15,8 +15,13 @@
 package strategies
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHelpStrategyManager(t *testing.T) {
@@ -62,3 +67,272 @@ func TestCommand(t *testing.T) {
 		t.Errorf("Expected FullName to be 'git config --global', got '%s'", cmd.FullName)
 	}
 }
+
+func TestFormatInvocation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"go", []string{"help", "build"}, "go help build"},
+		{"npm", nil, "npm"},
+		{"man", []string{"git log"}, "man 'git log'"},
+		{"echo", []string{"it's"}, `echo 'it'\''s'`},
+		{"echo", []string{""}, "echo ''"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatInvocation(tt.name, tt.args...); got != tt.want {
+			t.Errorf("FormatInvocation(%q, %q) = %q; want %q", tt.name, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestResolveContextWaitsForFetchSlot(t *testing.T) {
+	manager := NewHelpStrategyManager()
+	manager.SetMaxConcurrentFetches(1)
+
+	// Occupy the only slot so the lookup has to wait
+	manager.slots <- struct{}{}
+	defer func() { <-manager.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := manager.ResolveContext(ctx, []string{"ls"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ResolveContext error = %v; want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ResolveContext took %v; should return as soon as ctx is done", elapsed)
+	}
+}
+
+func TestRunContextCancelsSubprocess(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := NewCommandRunner().RunContext(ctx, DefaultCmdTimeout, "sleep", "5"); err == nil {
+		t.Fatal("expected an error from a cancelled command")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancelled command ran for %v", elapsed)
+	}
+}
+
+func TestRunContextReportsProgress(t *testing.T) {
+	var partials []string
+	ctx := WithProgress(context.Background(), func(partial string) {
+		partials = append(partials, partial)
+	})
+
+	out, err := NewCommandRunner().RunContext(ctx, DefaultCmdTimeout, "sh", "-c", "echo Usage: slow; sleep 0.3; echo '  --flag'")
+	if err != nil {
+		t.Fatalf("RunContext: %v", err)
+	}
+	if out != "Usage: slow\n  --flag\n" {
+		t.Errorf("output = %q; want the complete output", out)
+	}
+	if len(partials) == 0 || partials[0] != "Usage: slow\n" {
+		t.Fatalf("progress reports = %q; want the first line before the command finished", partials)
+	}
+}
+
+func TestRunContextClassifiesFailures(t *testing.T) {
+	runner := NewCommandRunner()
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		args    []string
+		kind    RunErrorKind
+		message string
+	}{
+		{"missing", DefaultCmdTimeout, []string{"recaller-no-such-command"}, RunNotInstalled, "recaller-no-such-command is not installed or not on PATH"},
+		{"timeout", 50 * time.Millisecond, []string{"sleep", "5"}, RunTimedOut, "`sleep 5` timed out after 50ms"},
+		{"exit", DefaultCmdTimeout, []string{"sh", "-c", "echo out; echo bad flag >&2; exit 3"}, RunExitStatus, "`sh -c 'echo out; echo bad flag >&2; exit 3'` exited with status 3: bad flag"},
+	}
+
+	for _, tt := range tests {
+		_, err := runner.RunContext(context.Background(), tt.timeout, tt.args[0], tt.args[1:]...)
+		var runErr *RunError
+		if !errors.As(err, &runErr) {
+			t.Errorf("%s: error %v is not a *RunError", tt.name, err)
+			continue
+		}
+		if runErr.Kind != tt.kind {
+			t.Errorf("%s: kind = %d; want %d", tt.name, runErr.Kind, tt.kind)
+		}
+		if runErr.Error() != tt.message {
+			t.Errorf("%s: message = %q; want %q", tt.name, runErr.Error(), tt.message)
+		}
+	}
+}
+
+// stubStrategy answers for one base command with fixed help or an error
+type stubStrategy struct {
+	baseCmd string
+	text    string
+	err     error
+}
+
+func (s *stubStrategy) SupportsCommand(baseCmd string) bool { return baseCmd == s.baseCmd }
+func (s *stubStrategy) Priority() int                       { return 0 }
+func (s *stubStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &HelpResult{Text: s.text, Invocation: s.baseCmd + " --help"}, nil
+}
+
+func TestTraceContextReportsEachStrategy(t *testing.T) {
+	manager := &HelpStrategyManager{slots: make(chan struct{}, 1)}
+	manager.RegisterStrategy(&TldrStrategy{})
+	manager.RegisterStrategy(&stubStrategy{baseCmd: "other", text: "other help"})
+	manager.RegisterStrategy(&stubStrategy{baseCmd: "zzrecallertest", err: errors.New("boom")})
+	manager.RegisterStrategy(&stubStrategy{baseCmd: "zzrecallertest", text: "usage"})
+	manager.RegisterStrategy(&stubStrategy{baseCmd: "zzrecallertest", text: "never reached"})
+
+	attempts, result, err := manager.TraceContext(context.Background(), []string{"zzrecallertest"})
+	if err != nil || result == nil || result.Text != "usage" {
+		t.Fatalf("TraceContext = %v, %v; want the third strategy's help", result, err)
+	}
+	if len(attempts) != 5 {
+		t.Fatalf("got %d attempts; want one per registered strategy", len(attempts))
+	}
+
+	// TLDR has no page for this made-up command
+	if !attempts[0].Tried || attempts[0].Err == nil {
+		t.Errorf("TLDR attempt = %+v; want tried and failed", attempts[0])
+	}
+	if attempts[1].Supported || attempts[1].Tried {
+		t.Errorf("unsupported strategy attempt = %+v; want skipped", attempts[1])
+	}
+	if !attempts[2].Tried || attempts[2].Err == nil || attempts[2].Err.Error() != "boom" {
+		t.Errorf("failing strategy attempt = %+v; want its error", attempts[2])
+	}
+	if !attempts[3].Tried || attempts[3].Err != nil || attempts[3].Result.Text != "usage" {
+		t.Errorf("winning strategy attempt = %+v", attempts[3])
+	}
+	if !attempts[4].Supported || attempts[4].Tried {
+		t.Errorf("strategy after the winner = %+v; want supported but not tried", attempts[4])
+	}
+}
+
+func TestGenericHelpFallsBackToShorterSubcommands(t *testing.T) {
+	// zzdeeptool only knows the sub-command "a" and fails for anything deeper
+	dir := t.TempDir()
+	script := "#!/bin/sh\n[ \"$1\" = a ] && [ \"$2\" = --help ] && echo 'usage: zzdeeptool a' && exit 0\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(dir, "zzdeeptool"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	generic := NewGenericHelpStrategy(NewCommandRunner())
+	res, err := generic.GetHelp(context.Background(), []string{"zzdeeptool", "a", "b", "c"})
+	if err != nil {
+		t.Fatalf("GetHelp: %v", err)
+	}
+	if res.Invocation != "zzdeeptool a --help" {
+		t.Errorf("invocation = %q; want %q", res.Invocation, "zzdeeptool a --help")
+	}
+
+	// Depth 0 only asks the base command, which has no help
+	generic.SetMaxDepth(0)
+	if _, err := generic.GetHelp(context.Background(), []string{"zzdeeptool", "a"}); err == nil {
+		t.Error("expected no help at depth 0")
+	}
+}
+
+// fakeProbe simulates a filesystem of links for environment detection, with
+// man searching /usr/local/share/man and /usr/share/man
+func fakeProbe(links map[string]string, onPath map[string]string, files ...string) envProbe {
+	return envProbe{
+		lookPath: func(file string) (string, error) {
+			if path, ok := onPath[file]; ok {
+				return path, nil
+			}
+			return "", errors.New("not found")
+		},
+		resolve: func(path string) (string, error) {
+			if target, ok := links[path]; ok {
+				return target, nil
+			}
+			return path, nil
+		},
+		exists: func(path string) bool {
+			for _, file := range files {
+				if file == path {
+					return true
+				}
+			}
+			return false
+		},
+		manPath: func() (string, error) {
+			return "/usr/local/share/man:/usr/share/man", nil
+		},
+	}
+}
+
+func TestDetectEnvironment(t *testing.T) {
+	busyboxLinks := map[string]string{"/bin/ls": "/bin/busybox", "/bin/sh": "/bin/busybox", "/usr/bin/man": "/bin/busybox"}
+	tests := []struct {
+		name  string
+		probe envProbe
+		want  Environment
+	}{
+		{"full distro", fakeProbe(nil, map[string]string{"man": "/usr/bin/man"}, "/usr/share/man"), Environment{}},
+		{"alpine without man", fakeProbe(busyboxLinks, nil), Environment{Busybox: true, ManMinimal: true}},
+		{"busybox man applet", fakeProbe(busyboxLinks, map[string]string{"man": "/usr/bin/man"}, "/usr/share/man"), Environment{Busybox: true, ManMinimal: true}},
+		{"minimized ubuntu", fakeProbe(nil, map[string]string{"man": "/usr/bin/man"}, "/usr/share/man", "/usr/local/sbin/unminimize"), Environment{ManMinimal: true}},
+		{"pages only under /usr/local", fakeProbe(nil, map[string]string{"man": "/usr/bin/man"}, "/usr/local/share/man"), Environment{}},
+		{"no pages on the man path", fakeProbe(nil, map[string]string{"man": "/usr/bin/man"}, "/usr/man"), Environment{ManMinimal: true}},
+	}
+	for _, tt := range tests {
+		if got := tt.probe.detect(); got != tt.want {
+			t.Errorf("%s: detect() = %+v; want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	// Without manpath or `man -w`, man pages are looked for in /usr/share/man
+	probe := fakeProbe(nil, map[string]string{"man": "/usr/bin/man"}, "/usr/share/man")
+	probe.manPath = func() (string, error) { return "", errors.New("not found") }
+	if got := probe.detect(); got != (Environment{}) {
+		t.Errorf("detect() without a man path = %+v; want man pages found in /usr/share/man", got)
+	}
+}
+
+func TestStrategyOrderFollowsEnvironment(t *testing.T) {
+	names := func(env Environment) string {
+		var names []string
+		for _, strategy := range NewHelpStrategyManagerFor(env).strategies {
+			names = append(names, strategyName(strategy))
+		}
+		return strings.Join(names, " ")
+	}
+
+	full := names(Environment{})
+	if strings.Contains(full, "BusyboxHelpStrategy") || !strings.HasSuffix(full, "ManPageStrategy GenericHelpStrategy") {
+		t.Errorf("full environment strategies = %s", full)
+	}
+	minimal := names(Environment{Busybox: true, ManMinimal: true})
+	if !strings.HasPrefix(minimal, "TldrStrategy") || !strings.HasSuffix(minimal, "BusyboxHelpStrategy GenericHelpStrategy") {
+		t.Errorf("busybox environment strategies = %s; want TLDR first and busybox before the generic flags, without man", minimal)
+	}
+}
+
+func TestBusyboxSupportsOnlyApplets(t *testing.T) {
+	strategy := &BusyboxHelpStrategy{
+		cmdRunner: NewCommandRunner(),
+		probe: fakeProbe(
+			map[string]string{"/bin/ls": "/bin/busybox", "/usr/bin/busybox": "/bin/busybox"},
+			map[string]string{"ls": "/bin/ls", "busybox": "/usr/bin/busybox", "git": "/usr/bin/git"},
+		),
+	}
+	for cmd, want := range map[string]bool{"ls": true, "busybox": true, "git": false, "missing": false} {
+		if got := strategy.SupportsCommand(cmd); got != want {
+			t.Errorf("SupportsCommand(%q) = %t; want %t", cmd, got, want)
+		}
+	}
+}
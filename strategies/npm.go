@@ -14,6 +14,8 @@
 
 package strategies
 
+import "context"
+
 // NpmHelpStrategy handles npm commands
 type NpmHelpStrategy struct {
 	cmdRunner *CommandRunner
@@ -31,18 +33,19 @@ func (n *NpmHelpStrategy) Priority() int {
 	return 2
 }
 
-func (n *NpmHelpStrategy) GetHelp(cmdParts []string) (string, error) {
+func (n *NpmHelpStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	if !cmd.HasSubCommand(1) {
-		return n.cmdRunner.Run("npm", "help")
+		return n.cmdRunner.Help(ctx, "npm", "help")
 	}
 
 	subCmd := cmd.GetSubCommand(0)
-	if out, err := n.cmdRunner.Run("npm", "help", subCmd); err == nil {
-		return RemoveOverstrike(out), nil
+	if res, err := n.cmdRunner.Help(ctx, "npm", "help", subCmd); err == nil {
+		res.Text = RemoveOverstrike(res.Text)
+		return res, nil
 	}
 
 	// Fallback to npm <subcommand> --help
-	return n.cmdRunner.Run("npm", subCmd, "--help")
+	return n.cmdRunner.Help(ctx, "npm", subCmd, "--help")
 }
@@ -17,8 +17,12 @@ package strategies
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os/exec"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -41,15 +45,28 @@ func NewCommandRunner() *CommandRunner {
 
 // RunWithTimeout runs a command with specified timeout and size limit
 func (cr *CommandRunner) RunWithTimeout(timeout time.Duration, name string, args ...string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	return cr.RunContext(context.Background(), timeout, name, args...)
+}
+
+// RunContext runs a command with specified timeout and size limit, killing it
+// early if ctx is cancelled
+func (cr *CommandRunner) RunContext(ctx context.Context, timeout time.Duration, name string, args ...string) (string, error) {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, name, args...)
 
-	var buf bytes.Buffer
+	var buf, stderr bytes.Buffer
 	limitedWriter := &LimitedWriter{w: &buf, limit: MaxOutputSize}
-	cmd.Stdout = limitedWriter
-	cmd.Stderr = limitedWriter
+	var output io.Writer = limitedWriter
+	if onOutput := progressFromContext(parent); onOutput != nil {
+		output = &progressWriter{w: limitedWriter, buf: &buf, onOutput: onOutput}
+	}
+	// stdout and stderr are copied on separate goroutines into the same buffer
+	var mu sync.Mutex
+	cmd.Stdout = &lockedWriter{mu: &mu, w: output}
+	cmd.Stderr = &lockedWriter{mu: &mu, w: io.MultiWriter(output, &LimitedWriter{w: &stderr, limit: maxStderrSnippet})}
 
 	err := cmd.Run()
 	result := buf.String()
@@ -58,9 +75,150 @@ func (cr *CommandRunner) RunWithTimeout(timeout time.Duration, name string, args
 		result += "\n[OUTPUT TRUNCATED - Size limit exceeded]"
 	}
 
+	// A cancelled caller is not a failure of the command itself
+	if err != nil && parent.Err() == nil {
+		err = newRunError(err, ctx, timeout, stderr.String(), name, args...)
+	}
 	return result, err
 }
 
+// progressInterval is the least time between two progress reports of a
+// streaming command, so a fast writer does not repaint the UI for every chunk
+const progressInterval = 100 * time.Millisecond
+
+// progressKey is the context key of the WithProgress callback
+type progressKey struct{}
+
+// WithProgress returns a context under which commands run by a CommandRunner
+// report the output received so far to onOutput as it arrives, so help that
+// streams slowly (aws starting Python) can be shown before it completes. The
+// complete output is still returned as usual. onOutput runs on the goroutine
+// copying the command's output and must not block.
+func WithProgress(ctx context.Context, onOutput func(partial string)) context.Context {
+	return context.WithValue(ctx, progressKey{}, onOutput)
+}
+
+func progressFromContext(ctx context.Context) func(string) {
+	onOutput, _ := ctx.Value(progressKey{}).(func(string))
+	return onOutput
+}
+
+// progressWriter passes writes to w and reports what buf, the buffer behind
+// w, holds so far at most once per progressInterval. stdout and stderr are
+// copied on separate goroutines, so writes are serialized.
+type progressWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	buf      *bytes.Buffer
+	onOutput func(string)
+	last     time.Time
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	n, err := pw.w.Write(p)
+	if now := time.Now(); now.Sub(pw.last) >= progressInterval {
+		pw.last = now
+		pw.onOutput(pw.buf.String())
+	}
+	return n, err
+}
+
+// lockedWriter serializes writes to w with writers sharing mu
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (lw *lockedWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	return lw.w.Write(p)
+}
+
+// maxStderrSnippet bounds the stderr kept for error messages
+const maxStderrSnippet = 512
+
+// RunErrorKind classifies why a subprocess failed
+type RunErrorKind int
+
+const (
+	RunFailed       RunErrorKind = iota // could not run for another reason
+	RunNotInstalled                     // executable not found on PATH
+	RunTimedOut                         // killed after exceeding its timeout
+	RunExitStatus                       // ran but exited non-zero
+)
+
+// RunError describes a failed subprocess in terms a user can act on
+type RunError struct {
+	Kind       RunErrorKind
+	Name       string
+	Invocation string
+	Timeout    time.Duration
+	ExitCode   int
+	Stderr     string // first lines of stderr, trimmed
+	Err        error
+}
+
+func newRunError(err error, ctx context.Context, timeout time.Duration, stderr string, name string, args ...string) *RunError {
+	re := &RunError{
+		Kind:       RunFailed,
+		Name:       name,
+		Invocation: FormatInvocation(name, args...),
+		Timeout:    timeout,
+		Stderr:     stderrSnippet(stderr),
+		Err:        err,
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		re.Kind = RunNotInstalled
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		re.Kind = RunTimedOut
+	case errors.As(err, &exitErr):
+		re.Kind = RunExitStatus
+		re.ExitCode = exitErr.ExitCode()
+	}
+	return re
+}
+
+func (e *RunError) Error() string {
+	switch e.Kind {
+	case RunNotInstalled:
+		return fmt.Sprintf("%s is not installed or not on PATH", e.Name)
+	case RunTimedOut:
+		return fmt.Sprintf("`%s` timed out after %v", e.Invocation, e.Timeout)
+	case RunExitStatus:
+		if e.Stderr != "" {
+			return fmt.Sprintf("`%s` exited with status %d: %s", e.Invocation, e.ExitCode, e.Stderr)
+		}
+		return fmt.Sprintf("`%s` exited with status %d", e.Invocation, e.ExitCode)
+	default:
+		return fmt.Sprintf("`%s` failed: %v", e.Invocation, e.Err)
+	}
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// stderrSnippet keeps the first few non-empty lines of stderr
+func stderrSnippet(stderr string) string {
+	var lines []string
+	for _, line := range strings.Split(stderr, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) == 3 {
+			break
+		}
+	}
+	return strings.Join(lines, " | ")
+}
+
 // Run runs a command with default timeout
 func (cr *CommandRunner) Run(name string, args ...string) (string, error) {
 	return cr.RunWithTimeout(DefaultCmdTimeout, name, args...)
@@ -71,6 +229,32 @@ func (cr *CommandRunner) RunFast(name string, args ...string) (string, error) {
 	return cr.RunWithTimeout(FastCmdTimeout, name, args...)
 }
 
+// HelpWithTimeout runs a command like RunContext and records the invocation
+// alongside its output
+func (cr *CommandRunner) HelpWithTimeout(ctx context.Context, timeout time.Duration, name string, args ...string) (*HelpResult, error) {
+	out, err := cr.RunContext(ctx, timeout, name, args...)
+	return &HelpResult{Text: out, Invocation: FormatInvocation(name, args...)}, err
+}
+
+// Help runs a command with default timeout and records the invocation alongside its output
+func (cr *CommandRunner) Help(ctx context.Context, name string, args ...string) (*HelpResult, error) {
+	return cr.HelpWithTimeout(ctx, DefaultCmdTimeout, name, args...)
+}
+
+// FormatInvocation renders a command and its arguments as a shell-like string,
+// quoting arguments that contain whitespace
+func FormatInvocation(name string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, name)
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
 // CheckCommandExists checks if a command exists using "which" or similar
 func (cr *CommandRunner) CheckCommandExists(cmd string) bool {
 	_, err := cr.RunFast("which", cmd)
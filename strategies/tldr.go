@@ -15,6 +15,7 @@
 package strategies
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -31,7 +32,7 @@ func (t *TldrStrategy) Priority() int {
 	return 0 // Highest priority - try first for better user experience
 }
 
-func (t *TldrStrategy) GetHelp(cmdParts []string) (string, error) {
+func (t *TldrStrategy) GetHelp(ctx context.Context, cmdParts []string) (*HelpResult, error) {
 	cmd := NewCommand(cmdParts)
 
 	baseUrl := "https://raw.githubusercontent.com/tldr-pages/tldr/refs/heads/main/pages/common"
@@ -45,21 +46,26 @@ func (t *TldrStrategy) GetHelp(cmdParts []string) (string, error) {
 		fullURL = fmt.Sprintf("%s/%s.md", baseUrl, cmd.BaseCmd)
 	}
 
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch TLDR page: %v", err)
+	}
+
 	client := &http.Client{Timeout: HttpTimeout}
-	resp, err := client.Get(fullURL)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch TLDR page: %v", err)
+		return nil, fmt.Errorf("failed to fetch TLDR page: %v", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("TLDR page not found (HTTP %d)", resp.StatusCode)
+		return nil, fmt.Errorf("TLDR page not found (HTTP %d)", resp.StatusCode)
 	}
 
 	limitedReader := io.LimitReader(resp.Body, MaxTldrSize)
 	body, err := io.ReadAll(limitedReader)
 	if err != nil {
-		return "", fmt.Errorf("failed to read TLDR response: %v", err)
+		return nil, fmt.Errorf("failed to read TLDR response: %v", err)
 	}
 
 	content := string(body)
@@ -67,5 +73,5 @@ func (t *TldrStrategy) GetHelp(cmdParts []string) (string, error) {
 		content = "📚 TLDR Documentation:\n\n" + content
 	}
 
-	return content, nil
+	return &HelpResult{Text: content, Invocation: "GET " + fullURL}, nil
 }
@@ -0,0 +1,110 @@
+// Copyright 2025 Naren Yellavula
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// xdgDefaults are the home-relative directories the XDG Base Directory spec
+// prescribes when a base directory variable is unset
+var xdgDefaults = map[string]string{
+	"XDG_CONFIG_HOME": ".config",
+	"XDG_CACHE_HOME":  ".cache",
+	"XDG_STATE_HOME":  filepath.Join(".local", "state"),
+}
+
+// xdgPath returns recaller/name under the XDG base directory named by
+// envVar (e.g. XDG_CONFIG_HOME), falling back to the spec's default under
+// the home directory when the variable is unset or relative. It returns ""
+// only when the home directory is unknown too.
+func xdgPath(getenv func(string) string, envVar string, name string) string {
+	dir := getenv(envVar)
+	if !filepath.IsAbs(dir) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(homeDir, xdgDefaults[envVar])
+	}
+	return filepath.Join(dir, "recaller", name)
+}
+
+// recallerFilePath locates one of recaller's files, which lives under the
+// XDG base directory named by envVar. Older versions kept it in the home
+// directory as legacyName: when migrate is set such a dotfile is moved on
+// first use, otherwise it keeps being used in place.
+func recallerFilePath(getenv func(string) string, legacyName string, envVar string, name string, migrate bool) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	legacyPath := filepath.Join(homeDir, legacyName)
+
+	xdg := xdgPath(getenv, envVar, name)
+	if _, err := os.Stat(xdg); err == nil {
+		return xdg, nil
+	}
+	if _, err := os.Stat(legacyPath); err != nil {
+		return xdg, nil
+	}
+	if !migrate {
+		return legacyPath, nil
+	}
+	if err := moveFile(legacyPath, xdg); err != nil {
+		cliFprintf(os.Stderr, "⚠️  Could not move %s to %s: %v\n", legacyPath, xdg, err)
+		return legacyPath, nil
+	}
+	cliFprintf(os.Stderr, "📦 Moved %s to %s\n", legacyPath, xdg)
+	return xdg, nil
+}
+
+// moveFile moves src to dst, creating dst's directory and copying when the
+// two are on different filesystems
+func moveFile(src string, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("failed to copy: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}